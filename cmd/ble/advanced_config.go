@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/nuki-io/nuki-cli/pkg/blecommands"
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+	"github.com/spf13/cobra"
+)
+
+var (
+	advancedConfigFile   string
+	advancedConfigDryRun bool
+)
+
+// advancedConfigCmd represents the advanced-config command
+var advancedConfigCmd = &cobra.Command{
+	Use:   "advanced-config",
+	Short: "Retrieves and changes the advanced configuration of the device",
+	Long: `The advanced configuration contains the lock positions, auto lock, night mode, button and battery settings.
+Use "get --format json" to save it to a file and "set -f" to write it back to the same or another device.`,
+}
+
+// advancedConfigGetCmd represents the advanced-config get command
+var advancedConfigGetCmd = &cobra.Command{
+	Use:     "get",
+	Short:   "Retrieves and displays the advanced configuration of the device",
+	PreRunE: mustDeviceId,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withAuthenticatedFlow(func(ctx context.Context, flow *bleflows.Flow) error {
+			cfg, err := flow.GetAdvancedConfig(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to read advanced config: %w", err)
+			}
+			if outputFormat == "json" {
+				return printJSON(cfg)
+			}
+			t := table.New().Rows(
+				[]string{"Total Degrees", fmt.Sprintf("%d", cfg.TotalDegrees)},
+				[]string{"Unlocked Position Offset", fmt.Sprintf("%d", cfg.UnlockedPositionOffsetDegrees)},
+				[]string{"Locked Position Offset", fmt.Sprintf("%d", cfg.LockedPositionOffsetDegrees)},
+				[]string{"Single Locked Position Offset", fmt.Sprintf("%d", cfg.SingleLockedPositionOffsetDegrees)},
+				[]string{"Unlocked to Locked Transition Offset", fmt.Sprintf("%d", cfg.UnlockedToLockedTransitionOffsetDegrees)},
+				[]string{"Lock'n'Go Timeout", fmt.Sprintf("%ds", cfg.LockNGoTimeout)},
+				[]string{"Single Button Press Action", cfg.SingleButtonPressAction.String()},
+				[]string{"Double Button Press Action", cfg.DoubleButtonPressAction.String()},
+				[]string{"Detached Cylinder", fmt.Sprintf("%t", cfg.DetachedCylinder)},
+				[]string{"Battery Type", cfg.BatteryType.String()},
+				[]string{"Automatic Battery Type Detection", fmt.Sprintf("%t", cfg.AutomaticBatteryTypeDetection)},
+				[]string{"Unlatch Duration", fmt.Sprintf("%ds", cfg.UnlatchDuration)},
+				[]string{"Auto Lock Enabled", fmt.Sprintf("%t", cfg.AutoLockEnabled)},
+				[]string{"Auto Lock Timeout", fmt.Sprintf("%ds", cfg.AutoLockTimeout)},
+				[]string{"Immediate Auto Lock Enabled", fmt.Sprintf("%t", cfg.ImmediateAutoLockEnabled)},
+				[]string{"Auto Unlock Disabled", fmt.Sprintf("%t", cfg.AutoUnlockDisabled)},
+				[]string{"Nightmode Enabled", fmt.Sprintf("%t", cfg.NightmodeEnabled)},
+				[]string{"Nightmode Start", cfg.NightmodeStartTime.String()},
+				[]string{"Nightmode End", cfg.NightmodeEndTime.String()},
+				[]string{"Nightmode Auto Lock Enabled", fmt.Sprintf("%t", cfg.NightmodeAutoLockEnabled)},
+				[]string{"Nightmode Auto Unlock Disabled", fmt.Sprintf("%t", cfg.NightmodeAutoUnlockDisabled)},
+				[]string{"Nightmode Immediate Lock on Start", fmt.Sprintf("%t", cfg.NightmodeImmediateLockOnStart)},
+				[]string{"Auto Update Enabled", fmt.Sprintf("%t", cfg.AutoUpdateEnabled)},
+				[]string{"Motor Speed", fmt.Sprintf("%d", cfg.MotorSpeed)},
+				[]string{"Slow Speed During Nightmode", fmt.Sprintf("%t", cfg.EnableSlowSpeedDuringNightmode)},
+			)
+			fmt.Println(t)
+			return nil
+		})
+	},
+}
+
+// advancedConfigSetCmd represents the advanced-config set command
+var advancedConfigSetCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Changes the advanced configuration of the device",
+	Long: `Reads the current advanced configuration, applies all fields given in the YAML or JSON file and writes the
+result back to the device. Fields missing in the file are kept. The total degrees are calibrated by the device and cannot be changed.`,
+	Example: `nukictl ble advanced-config get --format json > advanced.json
+nukictl ble advanced-config set -f advanced.json
+echo '{"autoLockEnabled": true, "autoLockTimeout": 120}' | nukictl ble advanced-config set -f -`,
+	PreRunE: mustDeviceId,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withAuthenticatedFlow(func(ctx context.Context, flow *bleflows.Flow) error {
+			current, err := flow.GetAdvancedConfig(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to read advanced config: %w", err)
+			}
+			updated := *current
+			if err := readDocument(advancedConfigFile, &updated); err != nil {
+				return err
+			}
+			updated.TotalDegrees = current.TotalDegrees
+
+			changes, err := diffFields(current, &updated)
+			if err != nil {
+				return err
+			}
+			if len(changes) == 0 {
+				fmt.Println("Advanced configuration is already up to date")
+				return nil
+			}
+			for _, c := range changes {
+				fmt.Println(c)
+			}
+			if advancedConfigDryRun {
+				return nil
+			}
+			return writeAdvancedConfig(ctx, flow, &updated)
+		})
+	},
+}
+
+// writeAdvancedConfig writes cfg to the device and verifies it by reading the advanced configuration again.
+func writeAdvancedConfig(ctx context.Context, flow *bleflows.Flow, cfg *blecommands.AdvancedConfig) error {
+	if err := flow.SetAdvancedConfig(ctx, cfg); err != nil {
+		return fmt.Errorf("failed to write advanced config: %w", err)
+	}
+	verify, err := flow.GetAdvancedConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read advanced config for verification: %w", err)
+	}
+	mismatches, err := diffFields(cfg, verify)
+	if err != nil {
+		return err
+	}
+	if len(mismatches) > 0 {
+		return fmt.Errorf("advanced config was written, but the device reports different values: %v", mismatches)
+	}
+	fmt.Println("Advanced configuration updated")
+	return nil
+}
+
+func init() {
+	bleCmd.AddCommand(advancedConfigCmd)
+	advancedConfigCmd.AddCommand(advancedConfigGetCmd, advancedConfigSetCmd)
+
+	advancedConfigSetCmd.Flags().StringVarP(&advancedConfigFile, "file", "f", "", "YAML or JSON file with the advanced configuration, - for stdin")
+	advancedConfigSetCmd.Flags().BoolVar(&advancedConfigDryRun, "dry-run", false, "Only show the changes, do not send them to the device")
+	advancedConfigSetCmd.MarkFlagRequired("file")
+}
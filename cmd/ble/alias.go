@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// aliases returns the device IDs by alias. Aliases are case-insensitive, like all keys in the config file.
+func aliases() map[string]string {
+	return viper.GetStringMapString("aliases")
+}
+
+// aliasCmd represents the alias command
+var aliasCmd = &cobra.Command{
+	Use:   "alias",
+	Short: "Manages aliases for devices",
+	Long: `Manages short names for devices, which can be used instead of the device ID with --device-id, set-context and
+the commands taking devices as arguments. Aliases are stored in the config file.`,
+}
+
+var aliasAddCmd = &cobra.Command{
+	Use:   "add <alias> <device>",
+	Short: "Adds an alias for a device",
+	Long: `Adds an alias for a device, given by its device ID or, if it is paired, its Nuki ID, name or another alias.
+An existing alias is changed to the new device.`,
+	Example: `  nukictl ble alias add frontdoor 54:D2:72:AA:BB:CC
+  nukictl ble alias add backdoor "Back Door"`,
+	Args: cobra.ExactArgs(2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) != 1 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completeDevices(cmd, args, toComplete)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		alias := strings.ToLower(args[0])
+		if alias == "" || strings.ContainsAny(alias, ". \t") {
+			return fmt.Errorf("invalid alias %q, it must not be empty or contain dots or spaces", args[0])
+		}
+		if _, ok, _ := loadStorage(alias); ok {
+			return fmt.Errorf("invalid alias %q, it is the ID of a paired device", args[0])
+		}
+		id, err := resolveDevice(args[1])
+		if err != nil {
+			return err
+		}
+		all := aliases()
+		all[alias] = id
+		viper.Set("aliases", all)
+		fmt.Printf("%s is an alias for %s now\n", alias, id)
+		return nil
+	},
+}
+
+var aliasRemoveCmd = &cobra.Command{
+	Use:     "remove <alias>",
+	Aliases: []string{"rm"},
+	Short:   "Removes an alias",
+	Args:    cobra.ExactArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) != 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return slices.Sorted(maps.Keys(aliases())), cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		alias := strings.ToLower(args[0])
+		all := aliases()
+		if _, ok := all[alias]; !ok {
+			return fmt.Errorf("no alias %q found", args[0])
+		}
+		// viper cannot unset a key, so the aliases are set again without the alias
+		delete(all, alias)
+		viper.Set("aliases", all)
+		return nil
+	},
+}
+
+var aliasListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "Lists the aliases",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		type entry struct {
+			Alias    string `json:"alias"`
+			DeviceID string `json:"deviceId"`
+			Name     string `json:"name"`
+		}
+		all := aliases()
+		entries := make([]entry, 0, len(all))
+		for _, alias := range slices.Sorted(maps.Keys(all)) {
+			e := entry{Alias: alias, DeviceID: all[alias]}
+			s, ok, err := loadStorage(e.DeviceID)
+			if err != nil {
+				return err
+			}
+			if ok {
+				e.Name = s.Name
+			}
+			entries = append(entries, e)
+		}
+		if outputFormat == "json" {
+			return printJSON(entries)
+		}
+		t := table.New().Headers("Alias", "Device ID", "Name")
+		for _, e := range entries {
+			t.Row(e.Alias, e.DeviceID, e.Name)
+		}
+		fmt.Println(t)
+		return nil
+	},
+}
+
+// completeDevices completes the aliases and the IDs of the paired devices, described by their names.
+func completeDevices(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var completions []string
+	all := aliases()
+	for _, alias := range slices.Sorted(maps.Keys(all)) {
+		completions = append(completions, fmt.Sprintf("%s\talias for %s", alias, all[alias]))
+	}
+	// completion cannot report errors, corrupt authorizations are reported by the commands
+	devices, _ := pairedDevices()
+	for _, d := range devices {
+		completions = append(completions, fmt.Sprintf("%s\t%s", d.Id, d.Name))
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	bleCmd.AddCommand(aliasCmd)
+	aliasCmd.AddCommand(aliasAddCmd, aliasRemoveCmd, aliasListCmd)
+}
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/nuki-io/nuki-cli/pkg/blecommands"
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+	"github.com/spf13/cobra"
+)
+
+var (
+	authListStart int
+	authListCount int
+	authListAll   bool
+
+	authUpdateName          string
+	authUpdateEnabled       bool
+	authUpdateRemoteAllowed bool
+	authUpdateTimeLimit     timeLimitFlags
+)
+
+// authCmd represents the auth command
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Manage the authorizations (users) of a device",
+	Long: `List, update and remove the authorizations stored on the device.
+Every app, bridge, fob and keypad paired with the device has its own authorization entry.`,
+}
+
+// authListCmd represents the auth list command
+var authListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List the authorizations stored on the device",
+	PreRunE: mustDeviceId,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withAuthenticatedFlow(func(ctx context.Context, flow *bleflows.Flow) error {
+			var entries []blecommands.AuthorizationEntry
+			var err error
+			if authListAll {
+				entries, err = flow.GetAllAuthorizationEntries(ctx)
+			} else {
+				entries, _, err = flow.GetAuthorizationEntries(ctx, authListStart, authListCount)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read authorization entries: %w", err)
+			}
+			if outputFormat == "json" {
+				return printJSON(entries)
+			}
+			t := table.New().Headers("Auth ID", "Type", "Name", "Enabled", "Remote", "Created", "Last Active", "Lock Count", "Time Limit")
+			for _, e := range entries {
+				t.Row(
+					fmt.Sprintf("%d", e.AuthId),
+					e.IdType.String(),
+					e.Name,
+					boolToIcon(e.Enabled),
+					boolToIcon(e.RemoteAllowed),
+					formatTime(e.CreatedAt),
+					formatTime(e.LastActiveAt),
+					fmt.Sprintf("%d", e.LockCount),
+					e.TimeLimit.String(),
+				)
+			}
+			fmt.Println(t)
+			return nil
+		})
+	},
+}
+
+// authUpdateCmd represents the auth update command
+var authUpdateCmd = &cobra.Command{
+	Use:   "update <auth-id>",
+	Short: "Update an authorization stored on the device",
+	Long: `Update name, state and access restrictions of an authorization.
+Only the given flags are changed, all other settings of the authorization are kept.`,
+	Example: `nukictl ble auth update 3 --enabled=false
+nukictl ble auth update 3 --time-limited --weekdays weekdays --from-time 08:00 --until-time 18:00`,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: mustDeviceId,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		authId, err := parseAuthId(args[0])
+		if err != nil {
+			return err
+		}
+		return withAuthenticatedFlow(func(ctx context.Context, flow *bleflows.Flow) error {
+			entry, err := flow.GetAuthorizationEntry(ctx, authId)
+			if err != nil {
+				return err
+			}
+			if cmd.Flags().Changed("name") {
+				entry.Name = authUpdateName
+			}
+			if cmd.Flags().Changed("enabled") {
+				entry.Enabled = authUpdateEnabled
+			}
+			if cmd.Flags().Changed("remote-allowed") {
+				entry.RemoteAllowed = authUpdateRemoteAllowed
+			}
+			if err := authUpdateTimeLimit.apply(cmd, &entry.TimeLimit); err != nil {
+				return err
+			}
+			if err := flow.UpdateAuthorizationEntry(ctx, entry); err != nil {
+				return fmt.Errorf("failed to update authorization entry: %w", err)
+			}
+			fmt.Printf("Updated authorization %d (%s)\n", entry.AuthId, entry.Name)
+			return nil
+		})
+	},
+}
+
+// authRemoveCmd represents the auth remove command
+var authRemoveCmd = &cobra.Command{
+	Use:     "remove <auth-id>",
+	Aliases: []string{"rm"},
+	Short:   "Remove an authorization from the device",
+	Args:    cobra.ExactArgs(1),
+	PreRunE: mustDeviceId,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		authId, err := parseAuthId(args[0])
+		if err != nil {
+			return err
+		}
+		return withAuthenticatedFlow(func(ctx context.Context, flow *bleflows.Flow) error {
+			if err := flow.RemoveAuthorizationEntry(ctx, authId); err != nil {
+				return fmt.Errorf("failed to remove authorization entry: %w", err)
+			}
+			fmt.Printf("Removed authorization %d\n", authId)
+			return nil
+		})
+	},
+}
+
+func parseAuthId(s string) (uint32, error) {
+	v, err := strconv.ParseUint(s, 0, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid authorization ID %q", s)
+	}
+	return uint32(v), nil
+}
+
+func init() {
+	bleCmd.AddCommand(authCmd)
+	authCmd.AddCommand(authListCmd, authUpdateCmd, authRemoveCmd)
+
+	authListCmd.Flags().IntVarP(&authListStart, "start", "s", 0, "Offset of the first authorization entry to read")
+	authListCmd.Flags().IntVarP(&authListCount, "count", "n", 20, "Number of authorization entries to read")
+	authListCmd.Flags().BoolVarP(&authListAll, "all", "a", false, "Page through all authorization entries, ignoring --start and --count")
+
+	authUpdateCmd.Flags().StringVar(&authUpdateName, "name", "", "New name of the authorization (max. 32 bytes)")
+	authUpdateCmd.Flags().BoolVar(&authUpdateEnabled, "enabled", true, "Enable or disable the authorization")
+	authUpdateCmd.Flags().BoolVar(&authUpdateRemoteAllowed, "remote-allowed", false, "Allow the authorization to be used remotely through a bridge")
+	authUpdateTimeLimit.register(authUpdateCmd)
+}
@@ -3,16 +3,31 @@ package cmd
 import (
 	"encoding/hex"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/nuki-io/nuki-cli/pkg/authstore"
 	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+	"github.com/nuki-io/nuki-cli/pkg/nukible"
 	"github.com/spf13/viper"
 )
 
 // viperAuthStore implements bleflows.AuthStore using viper.
 // Persistence is handled by cobra.OnFinalize → viper.WriteConfig in root.go.
-type viperAuthStore struct{}
+// If secrets is set, the keys and the PIN are kept there and the config file only holds the public parts of
+// the authorizations, which are enough to list the paired devices. See authStore.
+type viperAuthStore struct {
+	secrets bleflows.AuthStore
+}
+
+// authStorageVersion is the version of the authorizations written to the config file. Entries without a version
+// are version 1, which did not read the Nuki ID back.
+const authStorageVersion = 2
 
 type authorizeContextStorage struct {
+	Version       int
 	CliPublicKey  string
 	CliPrivateKey string
 	SlPublicKey   string
@@ -22,59 +37,212 @@ type authorizeContextStorage struct {
 	NukiId        string
 	Pin           string
 	Name          string
+	// Connection overrides the connection parameters for the device, see the connection command.
+	Connection *connectionStorage `yaml:",omitempty"`
+}
+
+type connectionStorage struct {
+	ConnectTimeout     string `yaml:",omitempty"`
+	ConnInterval       string `yaml:",omitempty"`
+	SupervisionTimeout string `yaml:",omitempty"`
+}
+
+func toConnectionStorage(p nukible.ConnectionParams) *connectionStorage {
+	s := &connectionStorage{}
+	if p.ConnectionTimeout != 0 {
+		s.ConnectTimeout = p.ConnectionTimeout.String()
+	}
+	if p.Interval != 0 {
+		s.ConnInterval = p.Interval.String()
+	}
+	if p.SupervisionTimeout != 0 {
+		s.SupervisionTimeout = p.SupervisionTimeout.String()
+	}
+	return s
+}
+
+// params returns the stored connection parameters, invalid durations are ignored.
+func (s *connectionStorage) params() nukible.ConnectionParams {
+	p := nukible.ConnectionParams{}
+	if v, err := time.ParseDuration(s.ConnectTimeout); err == nil {
+		p.ConnectionTimeout = v
+	}
+	if v, err := time.ParseDuration(s.ConnInterval); err == nil {
+		p.Interval = v
+	}
+	if v, err := time.ParseDuration(s.SupervisionTimeout); err == nil {
+		p.SupervisionTimeout = v
+	}
+	return p
 }
 
 func contextToStorage(ac *bleflows.AuthorizeContext) *authorizeContextStorage {
 	return &authorizeContextStorage{
+		Version:       authStorageVersion,
 		CliPublicKey:  fmt.Sprintf("%x", ac.CliPublicKey),
 		CliPrivateKey: fmt.Sprintf("%x", ac.CliPrivateKey),
 		SlPublicKey:   fmt.Sprintf("%x", ac.SlPublicKey),
 		SharedKey:     fmt.Sprintf("%x", ac.SharedKey),
 		AuthId:        fmt.Sprintf("%x", ac.AuthId),
 		AppId:         fmt.Sprintf("%x", ac.AppId),
-		NukiId:        fmt.Sprintf("%X", ac.NukiId),
+		NukiId:        fmt.Sprintf("%08X", ac.NukiId),
 		Pin:           ac.Pin,
 		Name:          ac.Name,
 	}
 }
 
-func storageToContext(s *authorizeContextStorage) *bleflows.AuthorizeContext {
-	ac := &bleflows.AuthorizeContext{}
-	if v, err := hex.DecodeString(s.CliPublicKey); err == nil {
-		ac.CliPublicKey = v
-	}
-	if v, err := hex.DecodeString(s.CliPrivateKey); err == nil {
-		ac.CliPrivateKey = v
-	}
-	if v, err := hex.DecodeString(s.SlPublicKey); err == nil {
-		ac.SlPublicKey = v
+// storageToContext parses a stored authorization. Fields that cannot be parsed are reported as error.
+func storageToContext(s *authorizeContextStorage) (*bleflows.AuthorizeContext, error) {
+	if s.Version > authStorageVersion {
+		return nil, fmt.Errorf("stored with version %d, the latest supported version is %d", s.Version, authStorageVersion)
 	}
-	if v, err := hex.DecodeString(s.SharedKey); err == nil {
-		ac.SharedKey = v
+	ac := &bleflows.AuthorizeContext{Pin: s.Pin, Name: s.Name}
+	var err error
+	for _, f := range []struct {
+		name  string
+		value string
+		dst   *[]byte
+	}{
+		{"clipublickey", s.CliPublicKey, &ac.CliPublicKey},
+		{"cliprivatekey", s.CliPrivateKey, &ac.CliPrivateKey},
+		{"slpublickey", s.SlPublicKey, &ac.SlPublicKey},
+		{"sharedkey", s.SharedKey, &ac.SharedKey},
+		{"authid", s.AuthId, &ac.AuthId},
+		{"appid", s.AppId, &ac.AppId},
+	} {
+		if *f.dst, err = hex.DecodeString(f.value); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", f.name, err)
+		}
 	}
-	if v, err := hex.DecodeString(s.AuthId); err == nil {
-		ac.AuthId = v
+	if ac.NukiId, err = parseNukiId(s.NukiId); err != nil {
+		return nil, fmt.Errorf("invalid nukiid: %w", err)
 	}
-	if v, err := hex.DecodeString(s.AppId); err == nil {
-		ac.AppId = v
+	return ac, nil
+}
+
+// parseNukiId parses a Nuki ID in hex, as shown by the Nuki app. An empty ID is 0.
+func parseNukiId(s string) (uint32, error) {
+	if s == "" {
+		return 0, nil
 	}
-	ac.Pin = s.Pin
-	ac.Name = s.Name
-	return ac
+	v, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(s), "0x"), 16, 32)
+	return uint32(v), err
 }
 
-func (viperAuthStore) Load(deviceId string) (*bleflows.AuthorizeContext, error) {
+// loadStorage returns the stored authorization of a device and whether there is one. An entry that cannot be
+// read is reported as error.
+func loadStorage(deviceId string) (*authorizeContextStorage, bool, error) {
 	cfgKey := fmt.Sprintf("authorizations.%s", deviceId)
 	if !viper.IsSet(cfgKey) {
-		return nil, fmt.Errorf("no authorization for device with id %s found", deviceId)
+		return nil, false, nil
 	}
 	s := &authorizeContextStorage{}
-	viper.UnmarshalKey(cfgKey, s)
-	return storageToContext(s), nil
+	if err := viper.UnmarshalKey(cfgKey, s); err != nil {
+		return nil, true, fmt.Errorf("corrupt authorization of device %s in the config file: %w", deviceId, err)
+	}
+	return s, true, nil
 }
 
-func (viperAuthStore) Store(deviceId string, ctx *bleflows.AuthorizeContext) error {
-	cfgKey := fmt.Sprintf("authorizations.%s", deviceId)
-	viper.Set(cfgKey, contextToStorage(ctx))
+// updateStorage changes the stored authorization of a device with fn.
+func updateStorage(deviceId string, fn func(s *authorizeContextStorage)) error {
+	s, ok, err := loadStorage(deviceId)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("no authorization for device with id %s found", deviceId)
+	}
+	fn(s)
+	viper.Set(fmt.Sprintf("authorizations.%s", deviceId), s)
+	return nil
+}
+
+// Load returns the authorization of a device, from the secret store if one is selected.
+func (v viperAuthStore) Load(deviceId string) (*bleflows.AuthorizeContext, error) {
+	if v.secrets != nil {
+		return v.secrets.Load(deviceId)
+	}
+	s, ok, err := loadStorage(deviceId)
+	if err != nil {
+		return nil, err
+	}
+	if !ok || s.SharedKey == "" {
+		return nil, fmt.Errorf("no authorization for device with id %s found", deviceId)
+	}
+	ac, err := storageToContext(s)
+	if err == nil {
+		err = authstore.Validate(ac)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("corrupt authorization of device %s in the config file: %w", deviceId, err)
+	}
+	return ac, nil
+}
+
+// Store stores the authorization of a device. Settings stored for the device, like its connection parameters, are kept.
+func (v viperAuthStore) Store(deviceId string, ctx *bleflows.AuthorizeContext) error {
+	if v.secrets != nil {
+		if err := v.secrets.Store(deviceId, ctx); err != nil {
+			return err
+		}
+	}
+	s := contextToStorage(ctx)
+	if v.secrets != nil {
+		s.stripSecrets()
+	}
+	// a corrupt entry is replaced completely
+	if old, ok, err := loadStorage(deviceId); ok && err == nil {
+		s.Connection = old.Connection
+	}
+	viper.Set(fmt.Sprintf("authorizations.%s", deviceId), s)
 	return nil
 }
+
+// List returns the IDs of the devices whose authorization is in the store.
+func (v viperAuthStore) List() ([]string, error) {
+	if v.secrets != nil {
+		return v.secrets.List()
+	}
+	var ids []string
+	for id := range viper.GetStringMap("authorizations") {
+		s, ok, err := loadStorage(id)
+		if err != nil {
+			return nil, err
+		}
+		if ok && s.SharedKey != "" {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+// Delete removes the authorization of a device from the secret store and the config file, including the settings
+// stored for the device.
+func (v viperAuthStore) Delete(deviceId string) error {
+	if v.secrets != nil {
+		if err := v.secrets.Delete(deviceId); err != nil {
+			return err
+		}
+	}
+	// viper cannot unset a key, so the authorizations are set again without the device
+	auths := viper.GetStringMap("authorizations")
+	delete(auths, strings.ToLower(deviceId))
+	viper.Set("authorizations", auths)
+	return nil
+}
+
+// deleteSecrets removes the keys and the PIN of a device from the store, the public parts stay in the config file.
+func (v viperAuthStore) deleteSecrets(deviceId string) error {
+	if v.secrets != nil {
+		return v.secrets.Delete(deviceId)
+	}
+	return updateStorage(deviceId, (*authorizeContextStorage).stripSecrets)
+}
+
+// stripSecrets removes the private key, the shared key and the PIN.
+func (s *authorizeContextStorage) stripSecrets() {
+	s.CliPrivateKey = ""
+	s.SharedKey = ""
+	s.Pin = ""
+}
@@ -0,0 +1,235 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+
+	"github.com/nuki-io/nuki-cli/pkg/authstore"
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"golang.org/x/term"
+)
+
+// passphraseEnv is the environment variable holding the passphrase of the file auth store.
+const passphraseEnv = "NUKICTL_AUTH_PASSPHRASE"
+
+// authStoreBackends are the values of --auth-store.
+var authStoreBackends = []string{"config", "file", "age", "keyring", "keyring-file"}
+
+var (
+	authStoreBackend  string
+	authStoreFile     string
+	authStoreIdentity string
+
+	migrateTo     string
+	migrateToFile string
+
+	// openedAuthStores caches the opened stores so that a passphrase is only asked once.
+	openedAuthStores = map[string]viperAuthStore{}
+)
+
+// addAuthStoreFlags adds the flags selecting where the keys of the authorizations are stored.
+// Flags that are not set fall back to authStore, authStoreFile and authStoreIdentity in the config file.
+func addAuthStoreFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&authStoreBackend, "auth-store", "", fmt.Sprintf("Where the keys of the authorizations are stored: %v (default config)", authStoreBackends))
+	fs.StringVar(&authStoreFile, "auth-store-file", "", "The file of the file, age or keyring-file auth store (default $HOME/.nukictl-auth.age or $HOME/.nukictl-keyring.json)")
+	fs.StringVar(&authStoreIdentity, "age-identity", "", "The age identity file to decrypt the age auth store with")
+}
+
+// selectedAuthStore returns the backend and the file set with flags or in the config file.
+func selectedAuthStore() (string, string) {
+	backend, file := authStoreBackend, authStoreFile
+	if backend == "" {
+		backend = viper.GetString("authStore")
+	}
+	if file == "" {
+		file = viper.GetString("authStoreFile")
+	}
+	if backend == "" {
+		backend = "config"
+	}
+	return backend, file
+}
+
+// authStore returns the auth store selected with --auth-store or in the config file.
+func authStore() (viperAuthStore, error) {
+	return openAuthStore(selectedAuthStore())
+}
+
+// openAuthStore opens the auth store of a backend. An empty file uses the default file of the backend.
+func openAuthStore(backend, file string) (viperAuthStore, error) {
+	if file == "" {
+		file = defaultAuthStoreFile(backend)
+	}
+	key := backend + ":" + file
+	if s, ok := openedAuthStores[key]; ok {
+		return s, nil
+	}
+	var secrets bleflows.AuthStore
+	switch backend {
+	case "config":
+	case "file":
+		_, err := os.Stat(file)
+		passphrase, err := readPassphrase(passphraseEnv, fmt.Sprintf("Passphrase for %s: ", file), errors.Is(err, os.ErrNotExist))
+		if err != nil {
+			return viperAuthStore{}, err
+		}
+		if secrets, err = authstore.NewPassphraseFileStore(file, passphrase); err != nil {
+			return viperAuthStore{}, err
+		}
+	case "age":
+		identity := authStoreIdentity
+		if identity == "" {
+			identity = viper.GetString("authStoreIdentity")
+		}
+		if identity == "" {
+			return viperAuthStore{}, fmt.Errorf("the age auth store requires an identity file, set it with --age-identity")
+		}
+		s, err := authstore.NewIdentityFileStore(file, identity)
+		if err != nil {
+			return viperAuthStore{}, err
+		}
+		secrets = s
+	case "keyring":
+		secrets = authstore.NewKeyringStore(authstore.SystemKeyring{})
+	case "keyring-file":
+		secrets = authstore.NewKeyringStore(authstore.NewFileKeyring(file))
+	default:
+		return viperAuthStore{}, fmt.Errorf("unknown auth store %q, must be one of %v", backend, authStoreBackends)
+	}
+	s := viperAuthStore{secrets: secrets}
+	openedAuthStores[key] = s
+	return s, nil
+}
+
+// defaultAuthStoreFile returns the file used by a backend if none is set.
+func defaultAuthStoreFile(backend string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	switch backend {
+	case "file", "age":
+		return path.Join(home, ".nukictl-auth.age")
+	case "keyring-file":
+		return path.Join(home, ".nukictl-keyring.json")
+	}
+	return ""
+}
+
+// readPassphrase returns the passphrase set in the environment variable env or asks for it on the terminal.
+// A new passphrase has to be entered twice.
+func readPassphrase(env, prompt string, isNew bool) (string, error) {
+	if p := os.Getenv(env); p != "" {
+		return p, nil
+	}
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("no passphrase given, set it in %s", env)
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	p, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	if len(p) == 0 {
+		return "", fmt.Errorf("the passphrase must not be empty")
+	}
+	if isNew {
+		fmt.Fprint(os.Stderr, "Repeat the passphrase: ")
+		repeated, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		if string(repeated) != string(p) {
+			return "", fmt.Errorf("the passphrases do not match")
+		}
+	}
+	return string(p), nil
+}
+
+// authStoreCmd represents the auth-store command
+var authStoreCmd = &cobra.Command{
+	Use:   "auth-store",
+	Short: "Manages where the keys of the authorizations are stored",
+	Long: `Manages where the keys of the authorizations are stored. The auth store is selected with --auth-store
+or authStore in the config file:
+
+  config        in the config file, unencrypted (default)
+  file          in a file encrypted with a passphrase, which is read from ` + passphraseEnv + ` or asked for
+  age           in a file encrypted for the age identity set with --age-identity
+  keyring       in the Secret Service, the macOS Keychain or the Windows Credential Manager
+  keyring-file  in a local file with the layout of the keyring, e.g. for systems without a Secret Service
+
+With any store other than config, the config file keeps the name and the IDs of the authorizations and
+the connection parameters of the devices, but not the keys and the security PIN.`,
+}
+
+var authStoreMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Moves the authorizations to another auth store",
+	Long: `Moves all authorizations from the auth store currently in use to the one set with --to. Each authorization is
+read back from the new store and compared before it is removed from the old one. Afterwards, the new store
+is set as authStore in the config file.`,
+	Example: `  nukictl ble auth-store migrate --to keyring
+  nukictl ble auth-store migrate --auth-store keyring --to file --to-file ~/nuki-auth.age`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !slices.Contains(authStoreBackends, migrateTo) {
+			return fmt.Errorf("unknown auth store %q, must be one of %v", migrateTo, authStoreBackends)
+		}
+		fromBackend, fromFile := selectedAuthStore()
+		if fromFile == "" {
+			fromFile = defaultAuthStoreFile(fromBackend)
+		}
+		toFile := migrateToFile
+		if toFile == "" {
+			toFile = defaultAuthStoreFile(migrateTo)
+		}
+		if fromBackend == migrateTo && fromFile == toFile {
+			return fmt.Errorf("the authorizations are already stored in %s", migrateTo)
+		}
+		from, err := openAuthStore(fromBackend, fromFile)
+		if err != nil {
+			return err
+		}
+		ids, err := from.List()
+		if err != nil {
+			return err
+		}
+		to, err := openAuthStore(migrateTo, toFile)
+		if err != nil {
+			return err
+		}
+		if err := authstore.Copy(from, to, ids); err != nil {
+			return err
+		}
+		// with config as the old store, storing in the new one already removed the keys from the config file
+		if fromBackend != "config" {
+			for _, id := range ids {
+				if err := from.deleteSecrets(id); err != nil {
+					return fmt.Errorf("authorization of device %s was copied, but could not be removed from %s: %w", id, fromBackend, err)
+				}
+			}
+		}
+		viper.Set("authStore", migrateTo)
+		viper.Set("authStoreFile", migrateToFile)
+		fmt.Printf("Moved %d authorizations from %s to %s\n", len(ids), fromBackend, migrateTo)
+		return nil
+	},
+}
+
+func init() {
+	bleCmd.AddCommand(authStoreCmd)
+	authStoreCmd.AddCommand(authStoreMigrateCmd)
+	authStoreMigrateCmd.Flags().StringVar(&migrateTo, "to", "", fmt.Sprintf("The auth store to move the authorizations to: %v", authStoreBackends))
+	authStoreMigrateCmd.Flags().StringVar(&migrateToFile, "to-file", "", "The file of the new auth store if it is file, age or keyring-file")
+	authStoreMigrateCmd.MarkFlagRequired("to")
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func testAuthorizeContext(seed byte, name string) *bleflows.AuthorizeContext {
+	return &bleflows.AuthorizeContext{
+		CliPublicKey:  bytes.Repeat([]byte{seed + 1}, 32),
+		CliPrivateKey: bytes.Repeat([]byte{seed + 2}, 32),
+		SlPublicKey:   bytes.Repeat([]byte{seed + 3}, 32),
+		SharedKey:     bytes.Repeat([]byte{seed + 4}, 32),
+		AuthId:        []byte{seed, 0, 0, 0},
+		AppId:         []byte{0x12, 0x34, 0x56, seed},
+		NukiId:        0x2A3B4C00 + uint32(seed),
+		Pin:           "123456",
+		Name:          name,
+	}
+}
+
+// resetConfig clears the config of viper before and after a test.
+func resetConfig(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+}
+
+func TestStorageRoundTrip(t *testing.T) {
+	ac := testAuthorizeContext(1, "Front Door")
+	s := contextToStorage(ac)
+	require.Equal(t, authStorageVersion, s.Version)
+	require.Equal(t, "2A3B4C01", s.NukiId)
+	got, err := storageToContext(s)
+	require.NoError(t, err)
+	require.Equal(t, ac, got)
+
+	// through the config file
+	resetConfig(t)
+	store := viperAuthStore{}
+	require.NoError(t, store.Store("54:D2:72:AA:BB:CC", ac))
+	got, err = store.Load("54:D2:72:AA:BB:CC")
+	require.NoError(t, err)
+	require.Equal(t, ac, got)
+}
+
+func TestStorageToContextErrors(t *testing.T) {
+	s := contextToStorage(testAuthorizeContext(1, "Front Door"))
+	s.Version = authStorageVersion + 1
+	_, err := storageToContext(s)
+	require.ErrorContains(t, err, "latest supported version")
+
+	s = contextToStorage(testAuthorizeContext(1, "Front Door"))
+	s.SharedKey = "xyz"
+	_, err = storageToContext(s)
+	require.ErrorContains(t, err, "invalid sharedkey")
+
+	s = contextToStorage(testAuthorizeContext(1, "Front Door"))
+	s.NukiId = "not hex"
+	_, err = storageToContext(s)
+	require.ErrorContains(t, err, "invalid nukiid")
+}
+
+func TestLoadCorruptStorage(t *testing.T) {
+	resetConfig(t)
+	viper.Set("authorizations.sim", map[string]any{"version": "two", "sharedkey": "00"})
+
+	_, ok, err := loadStorage("sim")
+	require.True(t, ok)
+	require.ErrorContains(t, err, "corrupt authorization of device sim")
+	_, err = viperAuthStore{}.Load("sim")
+	require.ErrorContains(t, err, "corrupt authorization of device sim")
+	_, err = viperAuthStore{}.List()
+	require.Error(t, err)
+
+	_, ok, err = loadStorage("other")
+	require.False(t, ok)
+	require.NoError(t, err)
+}
+
+func TestParseNukiId(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want uint32
+		err  bool
+	}{
+		{"", 0, false},
+		{"2A3B4C01", 0x2A3B4C01, false},
+		{"2a3b4c01", 0x2A3B4C01, false},
+		{"0x2A3B4C01", 0x2A3B4C01, false},
+		{"1", 1, false},
+		{"12345678A", 0, true},
+		{"door", 0, true},
+	} {
+		got, err := parseNukiId(tc.in)
+		if tc.err {
+			require.Error(t, err, tc.in)
+			continue
+		}
+		require.NoError(t, err, tc.in)
+		require.Equal(t, tc.want, got, tc.in)
+	}
+}
+
+func TestResolveDevice(t *testing.T) {
+	resetConfig(t)
+	store := viperAuthStore{}
+	require.NoError(t, store.Store("54:d2:72:aa:bb:01", testAuthorizeContext(1, "Front Door")))
+	require.NoError(t, store.Store("54:d2:72:aa:bb:02", testAuthorizeContext(2, "Back Door")))
+	require.NoError(t, store.Store("54:d2:72:aa:bb:03", testAuthorizeContext(3, "Back Door")))
+	viper.Set("aliases", map[string]string{"front": "54:d2:72:aa:bb:01"})
+
+	for in, want := range map[string]string{
+		"":                  "",
+		"54:d2:72:aa:bb:02": "54:d2:72:aa:bb:02",
+		"front":             "54:d2:72:aa:bb:01",
+		"FRONT":             "54:d2:72:aa:bb:01",
+		"front door":        "54:d2:72:aa:bb:01",
+		"2A3B4C02":          "54:d2:72:aa:bb:02",
+		"0x2a3b4c03":        "54:d2:72:aa:bb:03",
+		"54:d2:72:aa:bb:99": "54:d2:72:aa:bb:99",
+	} {
+		got, err := resolveDevice(in)
+		require.NoError(t, err, in)
+		require.Equal(t, want, got, in)
+	}
+
+	_, err := resolveDevice("Back Door")
+	require.ErrorContains(t, err, "matches the paired devices 54:d2:72:aa:bb:02, 54:d2:72:aa:bb:03")
+}
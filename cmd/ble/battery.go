@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+	"github.com/spf13/cobra"
+)
+
+// batteryCmd represents the battery command
+var batteryCmd = &cobra.Command{
+	Use:   "battery",
+	Short: "Gets the detailed battery report of the device",
+	Long: `Shows the battery voltage and critical state as well as the measurements taken during the last lock action,
+like its battery drain, the lowest voltage and the maximum motor current.`,
+	PreRunE: mustDeviceId,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withAuthenticatedFlow(func(ctx context.Context, flow *bleflows.Flow) error {
+			report, err := flow.GetBatteryReport(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to get battery report: %w", err)
+			}
+			if outputFormat == "json" {
+				return printJSON(report)
+			}
+			style := lipgloss.NewStyle().PaddingLeft(1).PaddingRight(1)
+			table := table.New().Headers("Property", "Value").StyleFunc(func(row, col int) lipgloss.Style { return style })
+			table.
+				Row("Battery Voltage", fmt.Sprintf("%d mV", report.BatteryVoltage)).
+				Row("Battery critical", fmt.Sprintf("%v", report.CriticalBatteryState)).
+				Row("Battery Resistance", fmt.Sprintf("%d mΩ", report.BatteryResistance)).
+				Row("Last Lock Action", report.LockAction.String()).
+				Row("Battery Drain", fmt.Sprintf("%d mWs", report.BatteryDrain)).
+				Row("Start Voltage", fmt.Sprintf("%d mV", report.StartVoltage)).
+				Row("Lowest Voltage", fmt.Sprintf("%d mV", report.LowestVoltage)).
+				Row("Lock Distance", fmt.Sprintf("%d°", report.LockDistance)).
+				Row("Start Temperature", fmt.Sprintf("%d °C", report.StartTemperature)).
+				Row("Max Turn Current", fmt.Sprintf("%d mA", report.MaxTurnCurrent))
+			fmt.Println(table.Render())
+			return nil
+		})
+	},
+}
+
+func init() {
+	bleCmd.AddCommand(batteryCmd)
+}
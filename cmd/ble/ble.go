@@ -11,7 +11,7 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	parentcmd "github.com/nuki-io/nuki-cli/cmd"
 	"github.com/nuki-io/nuki-cli/pkg/bleflows"
-	"github.com/nuki-io/nuki-cli/pkg/nukible"
+	"github.com/nuki-io/nuki-cli/pkg/nukisim"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -23,6 +23,10 @@ const bleTimeout = 30 * time.Second
 var (
 	deviceId     string
 	outputFormat string
+	simAddr      string
+
+	retryAttempts int
+	retryBackoff  time.Duration
 
 	emptyStyle  = lipgloss.NewStyle()
 	styleCenter = lipgloss.NewStyle().AlignHorizontal(lipgloss.Center)
@@ -32,17 +36,23 @@ var (
 
 // bleCmd represents the bleCmd command
 var bleCmd = &cobra.Command{
-	Use:              "ble",
-	Short:            "Command to interact with devices through BLE",
-	Long:             ``,
-	PersistentPreRun: preRun,
-	SilenceUsage:     true,
+	Use:               "ble",
+	Short:             "Command to interact with devices through BLE",
+	Long:              ``,
+	PersistentPreRunE: preRun,
+	SilenceUsage:      true,
 }
 
 func init() {
 	parentcmd.RootCmd.AddCommand(bleCmd)
-	bleCmd.PersistentFlags().StringVarP(&deviceId, "device-id", "d", "", "The device to use. If not set, the device set by set-context command is used. This is ignored for some commands.")
+	bleCmd.PersistentFlags().StringVarP(&deviceId, "device-id", "d", "", "The device to use, by its device ID, an alias or, if it is paired, its Nuki ID or name. If not set, the device set by set-context command is used. This is ignored for some commands.")
 	bleCmd.PersistentFlags().StringVar(&outputFormat, "format", "table", "Output format: table or json")
+	bleCmd.PersistentFlags().StringVar(&simAddr, "sim", "", "Connect to a simulator started with 'nukictl sim' at this address instead of using Bluetooth")
+	addConnectionFlags(bleCmd.PersistentFlags())
+	addAuthStoreFlags(bleCmd.PersistentFlags())
+	bleCmd.RegisterFlagCompletionFunc("device-id", completeDevices)
+	bleCmd.PersistentFlags().IntVar(&retryAttempts, "attempts", bleflows.DefaultRetryPolicy.Attempts, "How often to try a command on a device if the connection fails before the device was changed, 1 disables retries")
+	bleCmd.PersistentFlags().DurationVar(&retryBackoff, "retry-backoff", bleflows.DefaultRetryPolicy.Backoff, "The time to wait before retrying, it doubles with every further attempt")
 	// viper.BindPFlag("activeContext", bleCmd.PersistentFlags().Lookup("device-id"))
 }
 
@@ -53,12 +63,19 @@ func printJSON(v any) error {
 	return enc.Encode(v)
 }
 
-func preRun(cmd *cobra.Command, args []string) {
+func preRun(cmd *cobra.Command, args []string) error {
 	if deviceId == "" && viper.IsSet("activecontext") {
 		deviceId = viper.GetString("activecontext")
 	}
+	id, err := resolveDevice(deviceId)
+	if err != nil {
+		return err
+	}
+	deviceId = id
+	setRecordCommand(cmd, args)
 	// TODO: The following "should" work. Check why it doesn't.
 	// viper.BindPFlag("activeContext", cmd.PersistentFlags().Lookup("device-id"))
+	return nil
 }
 
 func mustDeviceId(cmd *cobra.Command, args []string) error {
@@ -70,19 +87,29 @@ func mustDeviceId(cmd *cobra.Command, args []string) error {
 
 // withAuthenticatedFlow creates a BLE adapter, establishes an authenticated flow,
 // and calls fn with a timeout-bounded context. The device is disconnected after fn returns.
+// Attempts failing because of the connection are retried on a new connection as set with --attempts,
+// unless the device may already have carried out a change. A recording only contains the last attempt.
 func withAuthenticatedFlow(fn func(ctx context.Context, flow *bleflows.Flow) error) error {
-	ble, err := nukible.NewNukiBle()
-	if err != nil {
-		return fmt.Errorf("failed to enable bluetooth: %w", err)
+	defer stopRecording()
+	policy := bleflows.DefaultRetryPolicy
+	policy.Attempts = retryAttempts
+	policy.Backoff = retryBackoff
+	policy.AttemptTimeout = bleTimeout
+	connect := func() (*bleflows.Flow, error) {
+		stopRecording()
+		return newAuthenticatedFlow()
 	}
-	if runtime.GOOS == "linux" {
-		if err = ble.ScanForDevice(deviceId, 10*time.Second); err != nil {
-			return fmt.Errorf("failed to scan for device: %w", err)
-		}
-	}
-	flow, err := bleflows.NewAuthenticatedFlow(ble, deviceId, viperAuthStore{})
+	return policy.Do(context.Background(), connect, fn)
+}
+
+// withUnauthenticatedFlow creates a BLE adapter, scans for the device (since it is not yet known),
+// establishes an unauthenticated flow, and calls fn with a timeout-bounded context.
+// The flow is disconnected after fn returns.
+func withUnauthenticatedFlow(fn func(ctx context.Context, flow *bleflows.Flow) error) error {
+	defer stopRecording()
+	flow, err := newUnauthenticatedFlow()
 	if err != nil {
-		return fmt.Errorf("failed to create BLE flow: %w", err)
+		return err
 	}
 	defer flow.DisconnectDevice()
 	ctx, cancel := context.WithTimeout(context.Background(), bleTimeout)
@@ -90,23 +117,60 @@ func withAuthenticatedFlow(fn func(ctx context.Context, flow *bleflows.Flow) err
 	return fn(ctx, flow)
 }
 
-// withUnauthenticatedFlow creates a BLE adapter, scans for the device (since it is not yet known),
-// establishes an unauthenticated flow, and calls fn with a timeout-bounded context.
-// The flow is disconnected after fn returns.
-func withUnauthenticatedFlow(fn func(ctx context.Context, flow *bleflows.Flow) error) error {
-	ble, err := nukible.NewNukiBle()
+// newAuthenticatedFlow connects to the device, either through Bluetooth or to the simulator set with --sim.
+func newAuthenticatedFlow() (*bleflows.Flow, error) {
+	transport, err := newTransport(runtime.GOOS == "linux")
 	if err != nil {
-		return fmt.Errorf("failed to enable bluetooth: %w", err)
+		return nil, err
 	}
-	if err = ble.ScanForDevice(deviceId, 10*time.Second); err != nil {
-		return fmt.Errorf("failed to scan for device: %w", err)
+	store, err := authStore()
+	if err != nil {
+		return nil, err
 	}
-	flow, err := bleflows.NewUnauthenticatedFlow(ble, deviceId, viperAuthStore{})
+	flow, err := bleflows.NewAuthenticatedFlow(transport, deviceId, store)
 	if err != nil {
-		return fmt.Errorf("failed to create BLE flow: %w", err)
+		return nil, fmt.Errorf("failed to create BLE flow: %w", err)
 	}
-	defer flow.DisconnectDevice()
-	ctx, cancel := context.WithTimeout(context.Background(), bleTimeout)
-	defer cancel()
-	return fn(ctx, flow)
+	useRecorderRandom(flow)
+	return flow, nil
+}
+
+// newUnauthenticatedFlow connects to the device, either through Bluetooth or to the simulator set with --sim.
+func newUnauthenticatedFlow() (*bleflows.Flow, error) {
+	transport, err := newTransport(true)
+	if err != nil {
+		return nil, err
+	}
+	store, err := authStore()
+	if err != nil {
+		return nil, err
+	}
+	flow, err := bleflows.NewUnauthenticatedFlow(transport, deviceId, store)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create BLE flow: %w", err)
+	}
+	// the random bytes of a pairing include the private key, which allows to derive the shared key
+	if recordAuth {
+		useRecorderRandom(flow)
+	}
+	return flow, nil
+}
+
+// newTransport returns the transport to the simulator set with --sim or to the device through Bluetooth.
+// If scan is set, the device is searched before, which is required on Linux to connect.
+// The transport is recorded if --record is set.
+func newTransport(scan bool) (bleflows.Transport, error) {
+	if simAddr != "" {
+		return startRecording(nukisim.NewRemoteDevice(simAddr))
+	}
+	ble, err := newNukiBle()
+	if err != nil {
+		return nil, fmt.Errorf("failed to enable bluetooth: %w", err)
+	}
+	if scan {
+		if _, err = ble.ScanForDevice(deviceId, 10*time.Second); err != nil {
+			return nil, fmt.Errorf("failed to scan for device: %w", err)
+		}
+	}
+	return startRecording(newDevice(ble, deviceId))
 }
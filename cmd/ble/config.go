@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/charmbracelet/lipgloss/table"
+	"github.com/nuki-io/nuki-cli/pkg/blecommands"
 	"github.com/nuki-io/nuki-cli/pkg/bleflows"
 	"github.com/spf13/cobra"
 )
@@ -20,6 +21,18 @@ var configCmd = &cobra.Command{
 			if err != nil {
 				return fmt.Errorf("failed to read config: %w", err)
 			}
+			flow.UpdateAuthCtxFromConfig(cfg)
+			if cfg.DeviceType == blecommands.DeviceTypeOpener {
+				openerCfg, err := flow.GetOpenerConfig(ctx)
+				if err != nil {
+					return fmt.Errorf("failed to read config: %w", err)
+				}
+				if outputFormat == "json" {
+					return printJSON(openerCfg)
+				}
+				printOpenerConfig(openerCfg)
+				return nil
+			}
 			if outputFormat == "json" {
 				return printJSON(cfg)
 			}
@@ -48,12 +61,11 @@ var configCmd = &cobra.Command{
 				[]string{"Firmware Version", cfg.FirmwareVersion},
 				[]string{"Hardware Revision", cfg.HardwareRevision},
 				[]string{"HomeKit Status", fmt.Sprintf("%d", cfg.HomeKitStatus)},
-				[]string{"Device Type", fmt.Sprintf("%d", cfg.DeviceType)},
+				[]string{"Device Type", cfg.DeviceType.String()},
 				[]string{"Capabilities", fmt.Sprintf("%d", cfg.Capabilities)},
 				[]string{"Matter Status", fmt.Sprintf("%d", cfg.MatterStatus)},
 			)
 			fmt.Println(t)
-			flow.UpdateAuthCtxFromConfig(cfg)
 			return nil
 		})
 	},
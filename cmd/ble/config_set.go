@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/nuki-io/nuki-cli/pkg/blecommands"
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+	"github.com/spf13/cobra"
+)
+
+// configField gets and sets a writable field of blecommands.Config as a string.
+type configField struct {
+	get func(c *blecommands.Config) string
+	set func(c *blecommands.Config, v string) error
+}
+
+func boolField(field func(c *blecommands.Config) *bool) configField {
+	return configField{
+		get: func(c *blecommands.Config) string { return strconv.FormatBool(*field(c)) },
+		set: func(c *blecommands.Config, v string) (err error) {
+			*field(c), err = strconv.ParseBool(v)
+			return
+		},
+	}
+}
+
+func uint8Field(field func(c *blecommands.Config) *uint8) configField {
+	return configField{
+		get: func(c *blecommands.Config) string { return strconv.FormatUint(uint64(*field(c)), 10) },
+		set: func(c *blecommands.Config, v string) error {
+			n, err := strconv.ParseUint(v, 0, 8)
+			*field(c) = uint8(n)
+			return err
+		},
+	}
+}
+
+func float32Field(field func(c *blecommands.Config) *float32) configField {
+	return configField{
+		get: func(c *blecommands.Config) string { return strconv.FormatFloat(float64(*field(c)), 'f', -1, 32) },
+		set: func(c *blecommands.Config, v string) error {
+			n, err := strconv.ParseFloat(v, 32)
+			*field(c) = float32(n)
+			return err
+		},
+	}
+}
+
+// configFields contains all fields of the device configuration that can be written with SetConfig.
+// The keys are the JSON names of the fields.
+var configFields = map[string]configField{
+	"name": {
+		get: func(c *blecommands.Config) string { return c.Name },
+		set: func(c *blecommands.Config, v string) error {
+			if len(v) > 32 {
+				return fmt.Errorf("name must not be longer than 32 bytes")
+			}
+			c.Name = v
+			return nil
+		},
+	},
+	"latitude":        float32Field(func(c *blecommands.Config) *float32 { return &c.Latitude }),
+	"longitude":       float32Field(func(c *blecommands.Config) *float32 { return &c.Longitude }),
+	"autoUnlatch":     boolField(func(c *blecommands.Config) *bool { return &c.AutoUnlatch }),
+	"pairingEnabled":  boolField(func(c *blecommands.Config) *bool { return &c.PairingEnabled }),
+	"buttonEnabled":   boolField(func(c *blecommands.Config) *bool { return &c.ButtonEnabled }),
+	"ledEnabled":      boolField(func(c *blecommands.Config) *bool { return &c.LedEnabled }),
+	"ledBrightness":   uint8Field(func(c *blecommands.Config) *uint8 { return &c.LedBrightness }),
+	"dstMode":         uint8Field(func(c *blecommands.Config) *uint8 { return &c.DstMode }),
+	"fobAction1":      uint8Field(func(c *blecommands.Config) *uint8 { return &c.FobAction1 }),
+	"fobAction2":      uint8Field(func(c *blecommands.Config) *uint8 { return &c.FobAction2 }),
+	"fobAction3":      uint8Field(func(c *blecommands.Config) *uint8 { return &c.FobAction3 }),
+	"singleLock":      boolField(func(c *blecommands.Config) *bool { return &c.SingleLock }),
+	"advertisingMode": uint8Field(func(c *blecommands.Config) *uint8 { return &c.AdvertisingMode }),
+	"timezoneOffset": {
+		get: func(c *blecommands.Config) string { return strconv.Itoa(int(c.TimezoneOffset)) },
+		set: func(c *blecommands.Config, v string) error {
+			n, err := strconv.ParseInt(v, 10, 16)
+			c.TimezoneOffset = int16(n)
+			return err
+		},
+	},
+	"timezoneId": {
+		get: func(c *blecommands.Config) string { return strconv.Itoa(int(c.TimezoneID)) },
+		set: func(c *blecommands.Config, v string) error {
+			if id, ok := blecommands.TimezoneIDByName(v); ok {
+				c.TimezoneID = id
+				return nil
+			}
+			n, err := strconv.ParseUint(v, 10, 16)
+			if err != nil {
+				return fmt.Errorf("unknown timezone %q", v)
+			}
+			c.TimezoneID = uint16(n)
+			return nil
+		},
+	},
+}
+
+// lookupConfigField finds a writable config field by its case-insensitive name.
+func lookupConfigField(key string) (string, configField, bool) {
+	for k, f := range configFields {
+		if strings.EqualFold(k, key) {
+			return k, f, true
+		}
+	}
+	return "", configField{}, false
+}
+
+func configFieldNames() []string {
+	names := make([]string, 0, len(configFields))
+	for k := range configFields {
+		names = append(names, k)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// configSetCmd represents the config set command
+var configSetCmd = &cobra.Command{
+	Use:   "set key=value...",
+	Short: "Changes the configuration of the device",
+	Long: fmt.Sprintf(`Reads the current configuration, applies the given changes and writes the result back to the device.
+The configuration is read again afterwards to verify that all changes have been applied.
+
+Writable keys: %s`, strings.Join(configFieldNames(), ", ")),
+	Example: `nukictl ble config set name="Front Door" ledBrightness=3 timezoneId=Europe/Berlin`,
+	Args:    cobra.MinimumNArgs(1),
+	PreRunE: mustDeviceId,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		changes := map[string]string{}
+		for _, arg := range args {
+			k, v, ok := strings.Cut(arg, "=")
+			if !ok {
+				return fmt.Errorf("invalid argument %q, expected key=value", arg)
+			}
+			key, _, ok := lookupConfigField(k)
+			if !ok {
+				return fmt.Errorf("unknown or read-only config key %q", k)
+			}
+			changes[key] = v
+		}
+		return withAuthenticatedFlow(func(ctx context.Context, flow *bleflows.Flow) error {
+			cfg, err := flow.GetConfig(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to read config: %w", err)
+			}
+			updated := *cfg
+			var changed []string
+			for key, v := range changes {
+				field := configFields[key]
+				if err := field.set(&updated, v); err != nil {
+					return fmt.Errorf("invalid value %q for %s: %w", v, key, err)
+				}
+				if field.get(&updated) != field.get(cfg) {
+					changed = append(changed, key)
+				}
+			}
+			if len(changed) == 0 {
+				fmt.Println("Configuration is already up to date")
+				return nil
+			}
+			slices.Sort(changed)
+			for _, key := range changed {
+				fmt.Printf("%s: %s -> %s\n", key, configFields[key].get(cfg), configFields[key].get(&updated))
+			}
+			if err := flow.SetConfig(ctx, &updated); err != nil {
+				return fmt.Errorf("failed to write config: %w", err)
+			}
+
+			verify, err := flow.GetConfig(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to read config for verification: %w", err)
+			}
+			for _, key := range changed {
+				field := configFields[key]
+				if field.get(verify) != field.get(&updated) {
+					return fmt.Errorf("config was written, but %s is %s instead of %s", key, field.get(verify), field.get(&updated))
+				}
+			}
+			flow.UpdateAuthCtxFromConfig(verify)
+			fmt.Println("Configuration updated")
+			return nil
+		})
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configSetCmd)
+}
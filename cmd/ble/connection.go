@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/nuki-io/nuki-cli/pkg/nukible"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+var (
+	bleAdapter            string
+	bleConnectTimeout     time.Duration
+	bleConnInterval       time.Duration
+	bleSupervisionTimeout time.Duration
+)
+
+// addConnectionFlags adds the flags selecting the adapter and the connection parameters.
+// Flags that are not set fall back to the ble section of the config file and then to the defaults.
+func addConnectionFlags(fs *pflag.FlagSet) {
+	d := nukible.DefaultConnectionParams
+	fs.StringVar(&bleAdapter, "adapter", "", "The Bluetooth adapter to use, e.g. hci1 (Linux only, default is the first adapter)")
+	fs.DurationVar(&bleConnectTimeout, "connect-timeout", 0, fmt.Sprintf("The time allowed to establish a connection (default %s)", d.ConnectionTimeout))
+	fs.DurationVar(&bleConnInterval, "conn-interval", 0, fmt.Sprintf("The BLE connection interval (default %s)", d.Interval))
+	fs.DurationVar(&bleSupervisionTimeout, "supervision-timeout", 0, fmt.Sprintf("The time without communication after which a connection is lost (default %s)", d.SupervisionTimeout))
+}
+
+// flagConnectionParams returns the connection parameters set with flags.
+func flagConnectionParams() nukible.ConnectionParams {
+	return nukible.ConnectionParams{
+		ConnectionTimeout:  bleConnectTimeout,
+		Interval:           bleConnInterval,
+		SupervisionTimeout: bleSupervisionTimeout,
+	}
+}
+
+// configConnectionParams returns the connection parameters set in the ble section of the config file.
+func configConnectionParams() nukible.ConnectionParams {
+	return nukible.ConnectionParams{
+		ConnectionTimeout:  viper.GetDuration("ble.connectTimeout"),
+		Interval:           viper.GetDuration("ble.connInterval"),
+		SupervisionTimeout: viper.GetDuration("ble.supervisionTimeout"),
+	}
+}
+
+// newNukiBle enables the Bluetooth adapter set with --adapter or in the config file.
+func newNukiBle() (*nukible.NukiBle, error) {
+	adapter := bleAdapter
+	if adapter == "" {
+		adapter = viper.GetString("ble.adapter")
+	}
+	return nukible.NewNukiBleWithOptions(nukible.Options{
+		Adapter:    adapter,
+		Connection: configConnectionParams().Merge(flagConnectionParams()),
+	})
+}
+
+// newDevice returns the device with the connection parameters stored for it, which are overridden by flags.
+func newDevice(ble *nukible.NukiBle, id string) *nukible.Device {
+	d := ble.NewDevice(id)
+	d.SetConnectionParams(deviceConnectionParams(id).Merge(flagConnectionParams()))
+	return d
+}
+
+// deviceConnectionParams returns the connection parameters stored with the authorization of a device. A corrupt
+// authorization is reported when it is loaded, it has no connection parameters here.
+func deviceConnectionParams(id string) nukible.ConnectionParams {
+	s, ok, err := loadStorage(id)
+	if !ok || err != nil || s.Connection == nil {
+		return nukible.ConnectionParams{}
+	}
+	return s.Connection.params()
+}
+
+// connectionCmd represents the connection command
+var connectionCmd = &cobra.Command{
+	Use:   "connection",
+	Short: "Shows the connection parameters used for the device",
+	Long: `Shows the Bluetooth adapter and the connection parameters used for the device and where they are set.
+
+Connection parameters are taken from the flags, then from the parameters stored for the device with
+"connection set", then from the ble section of the config file (adapter, connectTimeout, connInterval
+and supervisionTimeout) and finally from the defaults.`,
+	Example: `  nukictl ble -d 54FD3A2B --connect-timeout 10s --conn-interval 30ms connection set
+  nukictl ble -d 54FD3A2B connection`,
+	PreRunE: mustDeviceId,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		flags := flagConnectionParams()
+		device := deviceConnectionParams(deviceId)
+		config := configConnectionParams()
+		defaults := nukible.DefaultConnectionParams
+		source := func(f, d, c time.Duration) string {
+			switch {
+			case f != 0:
+				return "flag"
+			case d != 0:
+				return "device"
+			case c != 0:
+				return "config"
+			}
+			return "default"
+		}
+		p := defaults.Merge(config).Merge(device).Merge(flags)
+
+		type param struct {
+			Name   string `json:"name"`
+			Value  string `json:"value"`
+			Source string `json:"source"`
+		}
+		adapter := param{"Adapter", bleAdapter, "flag"}
+		if adapter.Value == "" {
+			adapter = param{"Adapter", viper.GetString("ble.adapter"), "config"}
+		}
+		if adapter.Value == "" {
+			adapter = param{"Adapter", "", "default"}
+		}
+		params := []param{
+			adapter,
+			{"Connection timeout", p.ConnectionTimeout.String(), source(flags.ConnectionTimeout, device.ConnectionTimeout, config.ConnectionTimeout)},
+			{"Interval", p.Interval.String(), source(flags.Interval, device.Interval, config.Interval)},
+			{"Supervision timeout", p.SupervisionTimeout.String(), source(flags.SupervisionTimeout, device.SupervisionTimeout, config.SupervisionTimeout)},
+		}
+		if outputFormat == "json" {
+			return printJSON(params)
+		}
+		t := table.New().Headers("Parameter", "Value", "Source")
+		for _, p := range params {
+			t.Row(p.Name, p.Value, p.Source)
+		}
+		fmt.Println(t)
+		return nil
+	},
+}
+
+var connectionSetCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Stores the connection parameters given as flags for the device",
+	Long: `Stores the connection parameters given with --connect-timeout, --conn-interval and --supervision-timeout
+with the authorization of the device. Parameters not given keep their stored value.`,
+	Example: `  nukictl ble -d 54FD3A2B --supervision-timeout 10s connection set`,
+	PreRunE: mustDeviceId,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		flags := flagConnectionParams()
+		if flags == (nukible.ConnectionParams{}) {
+			return fmt.Errorf("at least one of --connect-timeout, --conn-interval or --supervision-timeout must be set")
+		}
+		return updateStorage(deviceId, func(s *authorizeContextStorage) {
+			s.Connection = toConnectionStorage(deviceConnectionParams(deviceId).Merge(flags))
+		})
+	},
+}
+
+var connectionResetCmd = &cobra.Command{
+	Use:     "reset",
+	Short:   "Removes the connection parameters stored for the device",
+	PreRunE: mustDeviceId,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return updateStorage(deviceId, func(s *authorizeContextStorage) {
+			s.Connection = nil
+		})
+	},
+}
+
+func init() {
+	bleCmd.AddCommand(connectionCmd)
+	connectionCmd.AddCommand(connectionSetCmd)
+	connectionCmd.AddCommand(connectionResetCmd)
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// readDocument reads a YAML or JSON file into v. If path is "-", the document is read from stdin.
+// Since JSON is a subset of YAML, both are parsed with the YAML parser and then converted to JSON,
+// so that only the json struct tags of v are relevant.
+func readDocument(path string, v any) error {
+	var b []byte
+	var err error
+	if path == "-" {
+		b, err = io.ReadAll(os.Stdin)
+	} else {
+		b, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return err
+	}
+	var doc any
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	b, err = json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// fieldChange is a top level field whose value differs between two documents.
+type fieldChange struct {
+	Key  string `json:"key"`
+	From any    `json:"from"`
+	To   any    `json:"to"`
+}
+
+func (c fieldChange) String() string {
+	return fmt.Sprintf("%s: %v -> %v", c.Key, c.From, c.To)
+}
+
+// diffFields compares the JSON representations of from and to and returns all top level fields that differ,
+// sorted by key.
+func diffFields(from, to any) ([]fieldChange, error) {
+	fromFields, err := toFieldMap(from)
+	if err != nil {
+		return nil, err
+	}
+	toFields, err := toFieldMap(to)
+	if err != nil {
+		return nil, err
+	}
+	var changes []fieldChange
+	for _, k := range slices.Sorted(maps.Keys(toFields)) {
+		if !reflect.DeepEqual(fromFields[k], toFields[k]) {
+			changes = append(changes, fieldChange{Key: k, From: fromFields[k], To: toFields[k]})
+		}
+	}
+	return changes, nil
+}
+
+func toFieldMap(v any) (map[string]any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]any{}
+	return m, json.Unmarshal(b, &m)
+}
+
+// writeDocument writes v to stdout as YAML, or as JSON if the json output format is selected.
+// Like readDocument, only the json struct tags of v are relevant.
+func writeDocument(v any) error {
+	if outputFormat == "json" {
+		return printJSON(v)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return err
+	}
+	enc := yaml.NewEncoder(os.Stdout)
+	enc.SetIndent(2)
+	defer enc.Close()
+	return enc.Encode(fromJSONNumbers(doc))
+}
+
+// fromJSONNumbers replaces all json.Number values in a decoded JSON document by int64 or float64,
+// so that integers are not written in exponent notation.
+func fromJSONNumbers(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]any:
+		for k, e := range v {
+			v[k] = fromJSONNumbers(e)
+		}
+	case []any:
+		for i, e := range v {
+			v[i] = fromJSONNumbers(e)
+		}
+	}
+	return v
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	parentcmd "github.com/nuki-io/nuki-cli/cmd"
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+	"github.com/nuki-io/nuki-cli/pkg/nukiexporter"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exporterAddr            string
+	exporterInterval        time.Duration
+	exporterDeviceIntervals map[string]string
+)
+
+// exporterCmd represents the exporter command
+var exporterCmd = &cobra.Command{
+	Use:   "exporter [device-id...]",
+	Short: "Exports the states of the paired devices as Prometheus metrics",
+	Long: `Polls the states, battery reports and logs of the given devices, or of all paired devices if none are given,
+and serves them as Prometheus metrics on /metrics. A scrape returns the results of the last poll, it does not access the devices.
+
+Lock actions are counted from the log of the device, which requires the security PIN to be set for the device.`,
+	Example: `  nukictl exporter --listen :9732 --interval 10m
+  nukictl exporter --device-interval 54FD3A2B=1m`,
+	ValidArgsFunction: completeDevices,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		devices, err := devicesOrPaired(args)
+		if err != nil {
+			return err
+		}
+		store, err := authStore()
+		if err != nil {
+			return err
+		}
+		pool := bleflows.NewPool(newPoolDialer(), store)
+		defer pool.Close()
+		exporter := nukiexporter.New(pool, store, devices)
+		exporter.SetInterval(exporterInterval)
+		for id, v := range exporterDeviceIntervals {
+			interval, err := time.ParseDuration(v)
+			if err != nil {
+				return fmt.Errorf("invalid interval for device %s: %w", id, err)
+			}
+			exporter.SetDeviceInterval(id, interval)
+		}
+
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(exporter)
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+
+		l, err := net.Listen("tcp", exporterAddr)
+		if err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", exporterAddr, err)
+		}
+		httpServer := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+		go func() {
+			<-ctx.Done()
+			httpServer.Close()
+		}()
+		go exporter.Run(ctx)
+
+		slog.Info("Serving metrics", "address", l.Addr().String(), "devices", devices)
+		if err := httpServer.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	},
+}
+
+func init() {
+	parentcmd.RootCmd.AddCommand(exporterCmd)
+	exporterCmd.Flags().StringVarP(&exporterAddr, "listen", "l", ":9732", "The address to serve the metrics on")
+	exporterCmd.Flags().DurationVar(&exporterInterval, "interval", nukiexporter.DefaultInterval, "The time between two polls of a device")
+	exporterCmd.Flags().StringToStringVar(&exporterDeviceIntervals, "device-interval", nil, "The time between two polls of a specific device, e.g. 54FD3A2B=1m")
+	exporterCmd.Flags().StringVar(&simAddr, "sim", "", "Connect to a simulator started with 'nukictl sim' at this address instead of using Bluetooth")
+	addConnectionFlags(exporterCmd.Flags())
+	addAuthStoreFlags(exporterCmd.Flags())
+}
@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/nuki-io/nuki-cli/pkg/blecommands"
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+	"github.com/spf13/cobra"
+)
+
+var (
+	keypadListStart int
+	keypadListCount int
+	keypadListAll   bool
+
+	keypadCode      string
+	keypadName      string
+	keypadEnabled   bool
+	keypadTimeLimit timeLimitFlags
+)
+
+// keypadCmd represents the keypad command
+var keypadCmd = &cobra.Command{
+	Use:   "keypad",
+	Short: "Manage the keypad codes of a device",
+	Long: `List, add, update and remove the codes that can be entered on a paired keypad.
+Keypad codes must consist of exactly 6 digits, must not contain 0 and must not start with 12.`,
+}
+
+// keypadListCmd represents the keypad list command
+var keypadListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List the keypad codes stored on the device",
+	PreRunE: mustDeviceId,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withAuthenticatedFlow(func(ctx context.Context, flow *bleflows.Flow) error {
+			var codes []blecommands.KeypadCode
+			var err error
+			if keypadListAll {
+				codes, err = flow.GetAllKeypadCodes(ctx)
+			} else {
+				codes, _, err = flow.GetKeypadCodes(ctx, keypadListStart, keypadListCount)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read keypad codes: %w", err)
+			}
+			if outputFormat == "json" {
+				return printJSON(codes)
+			}
+			t := table.New().Headers("Code ID", "Code", "Name", "Enabled", "Created", "Last Active", "Lock Count", "Time Limit")
+			for _, c := range codes {
+				t.Row(
+					fmt.Sprintf("%d", c.CodeId),
+					fmt.Sprintf("%06d", c.Code),
+					c.Name,
+					boolToIcon(c.Enabled),
+					formatTime(c.CreatedAt),
+					formatTime(c.LastActiveAt),
+					fmt.Sprintf("%d", c.LockCount),
+					c.TimeLimit.String(),
+				)
+			}
+			fmt.Println(t)
+			return nil
+		})
+	},
+}
+
+// keypadAddCmd represents the keypad add command
+var keypadAddCmd = &cobra.Command{
+	Use:     "add",
+	Short:   "Add a new keypad code to the device",
+	Example: `nukictl ble keypad add --code 345678 --name "Cleaning" --time-limited --weekdays fri --from-time 09:00 --until-time 12:00`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := mustDeviceId(cmd, args); err != nil {
+			return err
+		}
+		_, err := parseKeypadCode(keypadCode)
+		return err
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		code, _ := parseKeypadCode(keypadCode)
+		kc := &blecommands.KeypadCode{Code: code, Name: keypadName, Enabled: true}
+		if err := keypadTimeLimit.apply(cmd, &kc.TimeLimit); err != nil {
+			return err
+		}
+		return withAuthenticatedFlow(func(ctx context.Context, flow *bleflows.Flow) error {
+			id, err := flow.AddKeypadCode(ctx, kc)
+			if err != nil {
+				return fmt.Errorf("failed to add keypad code: %w", err)
+			}
+			fmt.Printf("Added keypad code %q with ID %d\n", kc.Name, id)
+			return nil
+		})
+	},
+}
+
+// keypadUpdateCmd represents the keypad update command
+var keypadUpdateCmd = &cobra.Command{
+	Use:   "update <code-id>",
+	Short: "Update a keypad code stored on the device",
+	Long: `Update code, name, state and access restrictions of a keypad code.
+Only the given flags are changed, all other settings of the keypad code are kept.`,
+	Args: cobra.ExactArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := mustDeviceId(cmd, args); err != nil {
+			return err
+		}
+		if cmd.Flags().Changed("code") {
+			_, err := parseKeypadCode(keypadCode)
+			return err
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		codeId, err := parseKeypadCodeId(args[0])
+		if err != nil {
+			return err
+		}
+		return withAuthenticatedFlow(func(ctx context.Context, flow *bleflows.Flow) error {
+			kc, err := flow.GetKeypadCode(ctx, codeId)
+			if err != nil {
+				return err
+			}
+			if cmd.Flags().Changed("code") {
+				kc.Code, _ = parseKeypadCode(keypadCode)
+			}
+			if cmd.Flags().Changed("name") {
+				kc.Name = keypadName
+			}
+			if cmd.Flags().Changed("enabled") {
+				kc.Enabled = keypadEnabled
+			}
+			if err := keypadTimeLimit.apply(cmd, &kc.TimeLimit); err != nil {
+				return err
+			}
+			if err := flow.UpdateKeypadCode(ctx, kc); err != nil {
+				return fmt.Errorf("failed to update keypad code: %w", err)
+			}
+			fmt.Printf("Updated keypad code %d (%s)\n", kc.CodeId, kc.Name)
+			return nil
+		})
+	},
+}
+
+// keypadRemoveCmd represents the keypad remove command
+var keypadRemoveCmd = &cobra.Command{
+	Use:     "remove <code-id>",
+	Aliases: []string{"rm"},
+	Short:   "Remove a keypad code from the device",
+	Args:    cobra.ExactArgs(1),
+	PreRunE: mustDeviceId,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		codeId, err := parseKeypadCodeId(args[0])
+		if err != nil {
+			return err
+		}
+		return withAuthenticatedFlow(func(ctx context.Context, flow *bleflows.Flow) error {
+			if err := flow.RemoveKeypadCode(ctx, codeId); err != nil {
+				return fmt.Errorf("failed to remove keypad code: %w", err)
+			}
+			fmt.Printf("Removed keypad code %d\n", codeId)
+			return nil
+		})
+	},
+}
+
+// parseKeypadCode parses and validates a keypad code given on the command line.
+func parseKeypadCode(s string) (uint32, error) {
+	v, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid keypad code %q: must be numeric", s)
+	}
+	if err := blecommands.ValidateKeypadCode(uint32(v)); err != nil {
+		return 0, err
+	}
+	return uint32(v), nil
+}
+
+func parseKeypadCodeId(s string) (uint16, error) {
+	v, err := strconv.ParseUint(s, 0, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid keypad code ID %q", s)
+	}
+	return uint16(v), nil
+}
+
+func init() {
+	bleCmd.AddCommand(keypadCmd)
+	keypadCmd.AddCommand(keypadListCmd, keypadAddCmd, keypadUpdateCmd, keypadRemoveCmd)
+
+	keypadListCmd.Flags().IntVarP(&keypadListStart, "start", "s", 0, "Offset of the first keypad code to read")
+	keypadListCmd.Flags().IntVarP(&keypadListCount, "count", "n", 20, "Number of keypad codes to read")
+	keypadListCmd.Flags().BoolVarP(&keypadListAll, "all", "a", false, "Page through all keypad codes, ignoring --start and --count")
+
+	for _, c := range []*cobra.Command{keypadAddCmd, keypadUpdateCmd} {
+		c.Flags().StringVar(&keypadCode, "code", "", "The 6 digit keypad code")
+		c.Flags().StringVar(&keypadName, "name", "", "Name of the keypad code (max. 20 bytes)")
+		keypadTimeLimit.register(c)
+	}
+	keypadUpdateCmd.Flags().BoolVar(&keypadEnabled, "enabled", true, "Enable or disable the keypad code")
+	keypadAddCmd.MarkFlagRequired("code")
+	keypadAddCmd.MarkFlagRequired("name")
+}
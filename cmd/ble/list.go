@@ -5,7 +5,6 @@ import (
 
 	"github.com/charmbracelet/lipgloss/table"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 // listCmd represents the list command
@@ -14,25 +13,25 @@ var listCmd = &cobra.Command{
 	Aliases: []string{"ls"},
 	Short:   "List all authorized devices",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		auths := viper.GetStringMap("authorizations")
-
 		type entry struct {
 			Name     string `json:"name"`
 			DeviceID string `json:"deviceId"`
+			NukiID   string `json:"nukiId"`
 			AppID    string `json:"appId"`
 			AuthID   string `json:"authId"`
 		}
 
-		entries := make([]entry, 0, len(auths))
-		for k, v := range auths {
-			values, ok := v.(map[string]any)
-			if !ok {
-				return fmt.Errorf("malformed authorization entry for device %q", k)
+		devices, err := pairedDevices()
+		if err != nil {
+			return err
+		}
+		entries := make([]entry, 0, len(devices))
+		for _, d := range devices {
+			nukiId := ""
+			if v, err := parseNukiId(d.NukiId); err == nil && v != 0 {
+				nukiId = fmt.Sprintf("%08X", v)
 			}
-			name, _ := values["name"].(string)
-			appid, _ := values["appid"].(string)
-			authid, _ := values["authid"].(string)
-			entries = append(entries, entry{Name: name, DeviceID: k, AppID: appid, AuthID: authid})
+			entries = append(entries, entry{Name: d.Name, DeviceID: d.Id, NukiID: nukiId, AppID: d.AppId, AuthID: d.AuthId})
 		}
 
 		if outputFormat == "json" {
@@ -41,9 +40,9 @@ var listCmd = &cobra.Command{
 
 		rows := make([][]string, len(entries))
 		for i, e := range entries {
-			rows[i] = []string{e.Name, e.DeviceID, e.AppID, e.AuthID}
+			rows[i] = []string{e.Name, e.DeviceID, e.NukiID, e.AppID, e.AuthID}
 		}
-		t := table.New().Rows(rows...).Headers("Name", "Device ID", "App ID", "Auth ID")
+		t := table.New().Rows(rows...).Headers("Name", "Device ID", "Nuki ID", "App ID", "Auth ID")
 		fmt.Println(t)
 		return nil
 	},
@@ -52,3 +51,21 @@ var listCmd = &cobra.Command{
 func init() {
 	bleCmd.AddCommand(listCmd)
 }
+
+// devicesOrPaired returns the resolved ids, or the IDs of all paired devices if ids is empty.
+func devicesOrPaired(ids []string) ([]string, error) {
+	if len(ids) > 0 {
+		return resolveDevices(ids)
+	}
+	devices, err := pairedDevices()
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range devices {
+		ids = append(ids, d.Id)
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no paired devices found")
+	}
+	return ids, nil
+}
@@ -2,20 +2,25 @@ package cmd
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"slices"
 
 	"github.com/nuki-io/nuki-cli/pkg/blecommands"
 	"github.com/nuki-io/nuki-cli/pkg/bleflows"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 // lockCmd represents the lock command
 var lockCmd = &cobra.Command{
 	Use:     "lock",
 	Short:   "Lock a device via Bluetooth",
+	Long:    `Locks the device. On an Opener, this deactivates ring to open.`,
 	PreRunE: mustDeviceId,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return withAuthenticatedFlow(func(ctx context.Context, flow *bleflows.Flow) error {
-			return flow.PerformLockOperation(ctx, blecommands.Lock)
+			return performLockAction(ctx, flow, blecommands.Lock)
 		})
 	},
 }
@@ -23,3 +28,76 @@ var lockCmd = &cobra.Command{
 func init() {
 	bleCmd.AddCommand(lockCmd)
 }
+
+// performLockAction performs action and shows its progress. It fails if the lock does not end up in a state expected
+// for the action. With --format json, only the final states are printed.
+func performLockAction(ctx context.Context, flow *bleflows.Flow, action blecommands.Action) error {
+	progress := newLockProgressPrinter(action)
+	states, err := flow.PerformLockOperationWithProgress(ctx, action, progress.print)
+	progress.done()
+	if err != nil {
+		return err
+	}
+	// states are nil on an Opener
+	if states == nil {
+		return nil
+	}
+	if expected := blecommands.ExpectedLockStates(action); expected != nil && !slices.Contains(expected, states.LockState) {
+		return fmt.Errorf("%s completed, but the lock state is %s", action, states.LockState)
+	}
+	if outputFormat == "json" {
+		return printJSON(states)
+	}
+	return nil
+}
+
+// lockProgressPrinter prints the progress of a lock action. On a terminal, it updates a single line in place.
+type lockProgressPrinter struct {
+	action   blecommands.Action
+	enabled  bool
+	inPlace  bool
+	lastLine string
+}
+
+func newLockProgressPrinter(action blecommands.Action) *lockProgressPrinter {
+	return &lockProgressPrinter{
+		action:  action,
+		enabled: outputFormat != "json",
+		inPlace: term.IsTerminal(int(os.Stdout.Fd())),
+	}
+}
+
+func (p *lockProgressPrinter) print(progress bleflows.LockProgress) {
+	if !p.enabled {
+		return
+	}
+	var line string
+	switch progress.Type {
+	case bleflows.LockProgressAccepted:
+		line = fmt.Sprintf("%s: accepted", p.action)
+	case bleflows.LockProgressStates:
+		if progress.States != nil {
+			line = fmt.Sprintf("%s: %s", p.action, progress.States.LockState)
+		} else {
+			line = fmt.Sprintf("%s: %s", p.action, progress.OpenerStates.LockState)
+		}
+	case bleflows.LockProgressComplete:
+		line = fmt.Sprintf("%s: complete", p.action)
+		if progress.States != nil {
+			line = fmt.Sprintf("%s: %s, complete", p.action, progress.States.LockState)
+		}
+	}
+	if p.inPlace {
+		fmt.Printf("\r%-*s", len(p.lastLine), line)
+	} else {
+		fmt.Println(line)
+	}
+	p.lastLine = line
+}
+
+// done ends the line updated in place.
+func (p *lockProgressPrinter) done() {
+	if p.inPlace && p.lastLine != "" {
+		fmt.Println()
+	}
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	parentcmd "github.com/nuki-io/nuki-cli/cmd"
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+	"github.com/nuki-io/nuki-cli/pkg/nukimqtt"
+	"github.com/spf13/cobra"
+)
+
+var (
+	mqttBroker          string
+	mqttClientId        string
+	mqttUsername        string
+	mqttPassword        string
+	mqttTopic           string
+	mqttDiscoveryPrefix string
+	mqttInterval        time.Duration
+)
+
+// mqttCmd represents the mqtt command
+var mqttCmd = &cobra.Command{
+	Use:   "mqtt [device-id...]",
+	Short: "Bridges the paired devices to an MQTT broker",
+	Long: `Polls the states of the given devices, or of all paired devices if none are given, and publishes them
+as retained messages below <topic>/<device id>/: state (JSON), lock, door, battery, batteryCritical, rssi and availability.
+<topic>/availability is online while the bridge is running and set to offline by the broker if it dies.
+Lock actions published to <topic>/<device id>/command are performed on the device, e.g. lock, unlock, unlatch or lockngo.
+Retained commands are ignored.
+
+The states are only polled every --interval and after a lock action. The advertisements of the devices are not
+watched, so changes made with a keypad, the app or by hand are published with the next poll.
+
+Home Assistant discovery messages are published for every device, use --discovery-prefix "" to disable them.
+The password can also be set with the NUKICTL_MQTT_PASSWORD environment variable.`,
+	Example: `  nukictl mqtt --broker tcp://localhost:1883
+  mosquitto_pub -t nuki/54FD3A2B/command -m unlock`,
+	ValidArgsFunction: completeDevices,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		devices, err := devicesOrPaired(args)
+		if err != nil {
+			return err
+		}
+
+		if mqttPassword == "" {
+			mqttPassword = os.Getenv("NUKICTL_MQTT_PASSWORD")
+		}
+		opts := mqtt.NewClientOptions().
+			AddBroker(mqttBroker).
+			SetClientID(mqttClientId).
+			SetUsername(mqttUsername).
+			SetPassword(mqttPassword).
+			SetWill(nukimqtt.AvailabilityTopic(mqttTopic), "offline", 1, true)
+		client, err := nukimqtt.NewPahoClient(opts)
+		if err != nil {
+			return err
+		}
+		defer client.Disconnect()
+
+		store, err := authStore()
+		if err != nil {
+			return err
+		}
+		pool := bleflows.NewPool(newPoolDialer(), store)
+		defer pool.Close()
+		bridge := nukimqtt.New(client, pool, devices)
+		bridge.SetTopic(mqttTopic)
+		bridge.SetDiscoveryPrefix(mqttDiscoveryPrefix)
+		bridge.SetInterval(mqttInterval)
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+		slog.Info("Bridging devices to MQTT", "broker", mqttBroker, "devices", devices)
+		return bridge.Run(ctx)
+	},
+}
+
+func init() {
+	parentcmd.RootCmd.AddCommand(mqttCmd)
+	mqttCmd.Flags().StringVar(&mqttBroker, "broker", "tcp://localhost:1883", "The URL of the MQTT broker")
+	mqttCmd.Flags().StringVar(&mqttClientId, "client-id", "nukictl", "The MQTT client ID")
+	mqttCmd.Flags().StringVar(&mqttUsername, "username", "", "The MQTT user name")
+	mqttCmd.Flags().StringVar(&mqttPassword, "password", "", "The MQTT password")
+	mqttCmd.Flags().StringVar(&mqttTopic, "topic", nukimqtt.DefaultTopic, "The base topic")
+	mqttCmd.Flags().StringVar(&mqttDiscoveryPrefix, "discovery-prefix", nukimqtt.DefaultDiscoveryPrefix, "The Home Assistant discovery prefix, empty to disable discovery")
+	mqttCmd.Flags().DurationVar(&mqttInterval, "interval", nukimqtt.DefaultInterval, "The time between two polls of a device")
+	mqttCmd.Flags().StringVar(&simAddr, "sim", "", "Connect to a simulator started with 'nukictl sim' at this address instead of using Bluetooth")
+	addConnectionFlags(mqttCmd.Flags())
+	addAuthStoreFlags(mqttCmd.Flags())
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/nuki-io/nuki-cli/pkg/blecommands"
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+	"github.com/spf13/cobra"
+)
+
+// openerCmd represents the opener command
+var openerCmd = &cobra.Command{
+	Use:   "opener <action>",
+	Short: "Execute an action on a Nuki Opener",
+	Long: `Executes an action on a Nuki Opener. Valid actions are:
+
+  rto-on, activate-rto      activate ring to open
+  rto-off, deactivate-rto   deactivate ring to open
+  open, electric-strike-actuation
+  cm-on, activate-cm        activate continuous mode
+  cm-off, deactivate-cm     deactivate continuous mode
+  fob-action-1 .. fob-action-3`,
+	Example:   `nukictl ble opener rto-on`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"rto-on", "rto-off", "open", "cm-on", "cm-off"},
+	PreRunE:   mustDeviceId,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		action, err := blecommands.ParseOpenerAction(args[0])
+		if err != nil {
+			return err
+		}
+		return withAuthenticatedFlow(func(ctx context.Context, flow *bleflows.Flow) error {
+			deviceType, err := flow.DeviceType(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to read device type: %w", err)
+			}
+			if deviceType != blecommands.DeviceTypeOpener {
+				return fmt.Errorf("device is a %s, not an Opener", deviceType)
+			}
+			return flow.PerformOpenerAction(ctx, action)
+		})
+	},
+}
+
+func printOpenerStates(status *blecommands.OpenerStates) {
+	style := lipgloss.NewStyle().PaddingLeft(1).PaddingRight(1)
+	table := table.New().Headers("Property", "Value").StyleFunc(func(row, col int) lipgloss.Style { return style })
+	table.
+		Row("Nuki State", status.NukiState.String()).
+		Row("Lock State", status.LockState.String()).
+		Row("Trigger", status.Trigger.String()).
+		Row("Current Time", status.CurrentTime.String()).
+		Row("Timezone Offset", fmt.Sprintf("%v", status.TimezoneOffset)).
+		Row("Battery critical", fmt.Sprintf("%v", status.BatteryStateCritical)).
+		Row("Ring to Open active", fmt.Sprintf("%v", status.RingToOpenActive())).
+		Row("Ring to Open Timer", fmt.Sprintf("%d min", status.RingToOpenTimer)).
+		Row("Continuous Mode active", fmt.Sprintf("%v", status.ContinuousModeActive())).
+		Row("Doorbell Suppression", status.DoorbellSuppression.String()).
+		Row("Config Update Count", fmt.Sprintf("%v", status.ConfigUpdateCount)).
+		Row("Last Action", status.LastLockAction.String()).
+		Row("Last Action Trigger", fmt.Sprintf("%v", status.LastLockActionTrigger)).
+		Row("Last Action Completion Status", fmt.Sprintf("%v", status.LastLockActionCompletionStatus))
+	fmt.Println(table.Render())
+}
+
+func printOpenerConfig(cfg *blecommands.OpenerConfig) {
+	t := table.New().Rows(
+		[]string{"Nuki ID", fmt.Sprintf("%X", cfg.NukiID)},
+		[]string{"Name", cfg.Name},
+		[]string{"Latitude", fmt.Sprintf("%f", cfg.Latitude)},
+		[]string{"Longitude", fmt.Sprintf("%f", cfg.Longitude)},
+		[]string{"Capabilities", cfg.Capabilities.String()},
+		[]string{"Pairing enabled", fmt.Sprintf("%t", cfg.PairingEnabled)},
+		[]string{"Button enabled", fmt.Sprintf("%t", cfg.ButtonEnabled)},
+		[]string{"Led flash enabled", fmt.Sprintf("%t", cfg.LedFlashEnabled)},
+		[]string{"Current Time", cfg.CurrentTime.String()},
+		[]string{"Timezone Offset", fmt.Sprintf("%d", cfg.TimezoneOffset)},
+		[]string{"DST Mode", fmt.Sprintf("%d", cfg.DstMode)},
+		[]string{"Timezone", (&blecommands.Config{TimezoneID: cfg.TimezoneID}).GetTimezoneLocation().String()},
+		[]string{"Has Fob", fmt.Sprintf("%t", cfg.HasFob)},
+		[]string{"Fob Action 1", fmt.Sprintf("%d", cfg.FobAction1)},
+		[]string{"Fob Action 2", fmt.Sprintf("%d", cfg.FobAction2)},
+		[]string{"Fob Action 3", fmt.Sprintf("%d", cfg.FobAction3)},
+		[]string{"Operating Mode", fmt.Sprintf("%d", cfg.OperatingMode)},
+		[]string{"Advertising Mode", fmt.Sprintf("%d", cfg.AdvertisingMode)},
+		[]string{"Has Keypad", fmt.Sprintf("%t", cfg.HasKeypad)},
+		[]string{"Has Keypad2", fmt.Sprintf("%t", cfg.HasKeypad2)},
+		[]string{"Firmware Version", cfg.FirmwareVersion},
+		[]string{"Hardware Revision", cfg.HardwareRevision},
+	)
+	fmt.Println(t)
+}
+
+func init() {
+	bleCmd.AddCommand(openerCmd)
+}
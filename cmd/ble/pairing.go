@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/nuki-io/nuki-cli/pkg/authstore"
+	"github.com/spf13/cobra"
+)
+
+// pairingPassphraseEnv is the environment variable holding the passphrase of exported pairings.
+const pairingPassphraseEnv = "NUKICTL_PAIRING_PASSPHRASE"
+
+var (
+	pairingOut   string
+	pairingAll   bool
+	pairingForce bool
+)
+
+// pairingCmd represents the pairing command
+var pairingCmd = &cobra.Command{
+	Use:   "pairing",
+	Short: "Exports and imports pairings",
+	Long: `Exports pairings to a file encrypted with a passphrase and imports them on another machine, which can then
+use the devices without pairing them again. Both machines share the authorization on the device, removing it
+on the device removes it for both.
+
+The passphrase is read from ` + pairingPassphraseEnv + ` or asked for.`,
+}
+
+var pairingExportCmd = &cobra.Command{
+	Use:   "export [device-id...]",
+	Short: "Exports the pairings of devices to an encrypted file",
+	Long: `Exports the keys, IDs, name and security PIN of the authorizations of the given devices, the device set with
+--device-id or all paired devices with --all. The file is a versioned bundle with a checksum, encrypted with age.`,
+	Example: `  nukictl ble pairing export 54FD3A2B --out frontdoor.age
+  nukictl ble pairing export --all --out backup.age`,
+	ValidArgsFunction: completeDevices,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := authStore()
+		if err != nil {
+			return err
+		}
+		ids, err := resolveDevices(args)
+		if err != nil {
+			return err
+		}
+		switch {
+		case pairingAll:
+			if ids, err = store.List(); err != nil {
+				return err
+			}
+		case len(ids) == 0 && deviceId != "":
+			ids = []string{deviceId}
+		}
+		if len(ids) == 0 {
+			return fmt.Errorf("no devices to export, pass device IDs or --all")
+		}
+		pairings := make([]authstore.Pairing, 0, len(ids))
+		for _, id := range ids {
+			ctx, err := store.Load(id)
+			if err != nil {
+				return err
+			}
+			pairings = append(pairings, authstore.Pairing{DeviceId: id, Authorization: ctx})
+		}
+		bundle, err := authstore.NewBundle(pairings)
+		if err != nil {
+			return err
+		}
+		passphrase, err := readPassphrase(pairingPassphraseEnv, "Passphrase for the export: ", true)
+		if err != nil {
+			return err
+		}
+
+		var w io.Writer = os.Stdout
+		if pairingOut != "-" {
+			f, err := os.OpenFile(pairingOut, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			w = f
+		}
+		if err := authstore.WriteBundle(w, bundle, passphrase); err != nil {
+			return err
+		}
+		if pairingOut != "-" {
+			fmt.Printf("Exported %d pairings to %s\n", len(pairings), pairingOut)
+		}
+		return nil
+	},
+}
+
+var pairingImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Imports pairings from a file written by export",
+	Long: `Imports the pairings from a file written by export into the auth store. Devices that are already paired are
+only overwritten with --force.`,
+	Example: `  nukictl ble pairing import frontdoor.age`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		passphrase, err := readPassphrase(pairingPassphraseEnv, fmt.Sprintf("Passphrase for %s: ", args[0]), false)
+		if err != nil {
+			return err
+		}
+		bundle, err := authstore.ReadBundle(f, passphrase)
+		if err != nil {
+			return err
+		}
+		store, err := authStore()
+		if err != nil {
+			return err
+		}
+		if !pairingForce {
+			for _, p := range bundle.Pairings {
+				if _, err := store.Load(p.DeviceId); err == nil {
+					return fmt.Errorf("device %s is already paired, use --force to overwrite it", p.DeviceId)
+				}
+			}
+		}
+		for _, p := range bundle.Pairings {
+			if err := store.Store(p.DeviceId, p.Authorization); err != nil {
+				return err
+			}
+			fmt.Printf("Imported pairing of %s (%s)\n", p.DeviceId, p.Authorization.Name)
+		}
+		return nil
+	},
+}
+
+func init() {
+	bleCmd.AddCommand(pairingCmd)
+	pairingCmd.AddCommand(pairingExportCmd)
+	pairingCmd.AddCommand(pairingImportCmd)
+	pairingExportCmd.Flags().StringVarP(&pairingOut, "out", "o", "", "The file to write the pairings to, - for stdout")
+	pairingExportCmd.Flags().BoolVar(&pairingAll, "all", false, "Export all paired devices")
+	pairingExportCmd.MarkFlagRequired("out")
+	pairingImportCmd.Flags().BoolVar(&pairingForce, "force", false, "Overwrite the pairings of devices that are already paired")
+}
@@ -0,0 +1,396 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/nuki-io/nuki-cli/pkg/blecommands"
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+	"github.com/spf13/cobra"
+)
+
+var (
+	profileFile           string
+	profileDryRun         bool
+	profileDeviceSpecific bool
+)
+
+// deviceSpecificConfigKeys and deviceSpecificAdvancedConfigKeys are the settings that identify a device or
+// depend on how it is mounted. Profiles are meant to be applied to many devices, so these are only exported
+// and applied with --device-specific.
+var (
+	deviceSpecificConfigKeys         = []string{"name", "latitude", "longitude"}
+	deviceSpecificAdvancedConfigKeys = []string{
+		"unlockedPositionOffsetDegrees",
+		"lockedPositionOffsetDegrees",
+		"singleLockedPositionOffsetDegrees",
+		"unlockedToLockedTransitionOffsetDegrees",
+	}
+)
+
+// deviceProfile is the format of the file written by config export and read by config diff and config apply.
+// Sections that are missing in the file are left untouched on the device. An empty list removes all
+// schedule entries or keypad codes.
+type deviceProfile struct {
+	Config         map[string]any       `json:"config,omitempty"`
+	AdvancedConfig map[string]any       `json:"advancedConfig,omitempty"`
+	Schedule       *[]scheduleEntry     `json:"schedule,omitempty"`
+	KeypadCodes    *[]profileKeypadCode `json:"keypadCodes,omitempty"`
+}
+
+// profileKeypadCode is a keypad code as written in a profile. Codes are identified by their name.
+type profileKeypadCode struct {
+	Name      string                 `json:"name"`
+	Code      uint32                 `json:"code"`
+	Enabled   *bool                  `json:"enabled,omitempty"` // defaults to true
+	TimeLimit *blecommands.TimeLimit `json:"timeLimit,omitempty"`
+}
+
+func (p deviceProfile) keypadCodes() ([]blecommands.KeypadCode, error) {
+	codes := make([]blecommands.KeypadCode, 0, len(*p.KeypadCodes))
+	names := map[string]bool{}
+	for _, c := range *p.KeypadCodes {
+		if names[c.Name] {
+			return nil, fmt.Errorf("keypad code name %q is used more than once", c.Name)
+		}
+		names[c.Name] = true
+		if err := blecommands.ValidateKeypadCode(c.Code); err != nil {
+			return nil, fmt.Errorf("keypad code %q: %w", c.Name, err)
+		}
+		code := blecommands.KeypadCode{
+			Name:    c.Name,
+			Code:    c.Code,
+			Enabled: c.Enabled == nil || *c.Enabled,
+		}
+		if c.TimeLimit != nil {
+			code.TimeLimit = *c.TimeLimit
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// profilePlan contains all changes needed to make a device match a profile.
+type profilePlan struct {
+	Config         []fieldChange                `json:"config"`
+	AdvancedConfig []fieldChange                `json:"advancedConfig"`
+	Schedule       *bleflows.TimeControlChanges `json:"schedule,omitempty"`
+	KeypadCodes    *bleflows.KeypadCodeChanges  `json:"keypadCodes,omitempty"`
+
+	config         *blecommands.Config
+	advancedConfig *blecommands.AdvancedConfig
+}
+
+func (p *profilePlan) isEmpty() bool {
+	return len(p.Config) == 0 && len(p.AdvancedConfig) == 0 &&
+		(p.Schedule == nil || p.Schedule.IsEmpty()) &&
+		(p.KeypadCodes == nil || p.KeypadCodes.IsEmpty())
+}
+
+// configExportCmd represents the config export command
+var configExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Writes the configuration, advanced configuration, schedule and keypad codes of the device as a profile",
+	Long: `Writes a profile of the device to stdout, as YAML or as JSON with --format json.
+Only writable settings are included. The profile can be applied to other devices with "config apply".
+The name, the location and the calibration of the device are left out, unless --device-specific is set.
+Note that the profile contains the keypad codes in plain text.`,
+	Example: `nukictl ble config export > door.yaml`,
+	PreRunE: mustDeviceId,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withAuthenticatedFlow(func(ctx context.Context, flow *bleflows.Flow) error {
+			profile, err := exportProfile(ctx, flow)
+			if err != nil {
+				return err
+			}
+			return writeDocument(profile)
+		})
+	},
+}
+
+// configDiffCmd represents the config diff command
+var configDiffCmd = &cobra.Command{
+	Use:     "diff",
+	Short:   "Show the differences between a profile and the device",
+	Example: `nukictl ble config diff -f door.yaml`,
+	PreRunE: mustDeviceId,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runProfileFile(true)
+	},
+}
+
+// configApplyCmd represents the config apply command
+var configApplyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Make the device match a profile",
+	Long: `Compares the device with the profile and only sends commands for the settings, schedule entries
+and keypad codes that differ. Sections missing in the profile are left untouched.
+Profiles setting the name, the location or the calibration of the device are rejected, unless
+--device-specific is set.`,
+	Example: `nukictl ble config apply -f door.yaml --dry-run`,
+	PreRunE: mustDeviceId,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runProfileFile(profileDryRun)
+	},
+}
+
+func runProfileFile(dryRun bool) error {
+	profile := deviceProfile{}
+	if err := readDocument(profileFile, &profile); err != nil {
+		return err
+	}
+	return withAuthenticatedFlow(func(ctx context.Context, flow *bleflows.Flow) error {
+		plan, err := planProfile(ctx, flow, &profile)
+		if err != nil {
+			return err
+		}
+		if outputFormat == "json" {
+			if err := printJSON(plan); err != nil {
+				return err
+			}
+		} else {
+			printProfilePlan(plan)
+		}
+		if dryRun || plan.isEmpty() {
+			return nil
+		}
+		if err := applyProfilePlan(ctx, flow, plan); err != nil {
+			return err
+		}
+		fmt.Println("Profile applied")
+		return nil
+	})
+}
+
+// exportProfile reads all settings covered by a profile from the device.
+func exportProfile(ctx context.Context, flow *bleflows.Flow) (*deviceProfile, error) {
+	cfg, err := flow.GetConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	flow.UpdateAuthCtxFromConfig(cfg)
+	cfgFields, err := toFieldMap(cfg)
+	if err != nil {
+		return nil, err
+	}
+	profile := &deviceProfile{Config: map[string]any{}}
+	for k := range configFields {
+		if profileDeviceSpecific || !slices.Contains(deviceSpecificConfigKeys, k) {
+			profile.Config[k] = cfgFields[k]
+		}
+	}
+
+	advancedCfg, err := flow.GetAdvancedConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read advanced config: %w", err)
+	}
+	if profile.AdvancedConfig, err = toFieldMap(advancedCfg); err != nil {
+		return nil, err
+	}
+	delete(profile.AdvancedConfig, "totalDegrees")
+	if !profileDeviceSpecific {
+		for _, k := range deviceSpecificAdvancedConfigKeys {
+			delete(profile.AdvancedConfig, k)
+		}
+	}
+
+	entries, err := flow.GetTimeControlEntries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read time control entries: %w", err)
+	}
+	schedule := make([]scheduleEntry, 0, len(entries))
+	for _, e := range entries {
+		schedule = append(schedule, scheduleEntry{Weekdays: e.Weekdays, Time: e.Time, Action: e.Action, Enabled: &e.Enabled})
+	}
+	profile.Schedule = &schedule
+
+	if cfg.HasKeypad || cfg.HasKeypad2 {
+		codes, err := flow.GetAllKeypadCodes(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read keypad codes: %w", err)
+		}
+		keypadCodes := make([]profileKeypadCode, 0, len(codes))
+		for _, c := range codes {
+			code := profileKeypadCode{Name: c.Name, Code: c.Code, Enabled: &c.Enabled}
+			if c.TimeLimit.TimeLimited {
+				code.TimeLimit = &c.TimeLimit
+			}
+			keypadCodes = append(keypadCodes, code)
+		}
+		profile.KeypadCodes = &keypadCodes
+	}
+	return profile, nil
+}
+
+// planProfile validates the profile and compares it with the device.
+func planProfile(ctx context.Context, flow *bleflows.Flow, profile *deviceProfile) (*profilePlan, error) {
+	plan := &profilePlan{}
+	if !profileDeviceSpecific {
+		if err := rejectDeviceSpecific(profile); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(profile.Config) > 0 {
+		cfg, err := flow.GetConfig(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config: %w", err)
+		}
+		flow.UpdateAuthCtxFromConfig(cfg)
+		updated := *cfg
+		for _, key := range slices.Sorted(maps.Keys(profile.Config)) {
+			field, ok := configFields[key]
+			if !ok {
+				return nil, fmt.Errorf("unknown or read-only config key %q, writable keys: %s", key, strings.Join(configFieldNames(), ", "))
+			}
+			v := fmt.Sprint(profile.Config[key])
+			if err := field.set(&updated, v); err != nil {
+				return nil, fmt.Errorf("invalid value %q for config %s: %w", v, key, err)
+			}
+			if field.get(&updated) != field.get(cfg) {
+				plan.Config = append(plan.Config, fieldChange{Key: key, From: field.get(cfg), To: field.get(&updated)})
+			}
+		}
+		plan.config = &updated
+	}
+
+	if len(profile.AdvancedConfig) > 0 {
+		if _, ok := profile.AdvancedConfig["totalDegrees"]; ok {
+			return nil, fmt.Errorf("advanced config totalDegrees is read-only")
+		}
+		cfg, err := flow.GetAdvancedConfig(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read advanced config: %w", err)
+		}
+		updated := *cfg
+		b, err := json.Marshal(profile.AdvancedConfig)
+		if err != nil {
+			return nil, err
+		}
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&updated); err != nil {
+			return nil, fmt.Errorf("invalid advanced config: %w", err)
+		}
+		if plan.AdvancedConfig, err = diffFields(cfg, &updated); err != nil {
+			return nil, err
+		}
+		plan.advancedConfig = &updated
+	}
+
+	if profile.Schedule != nil {
+		current, err := flow.GetTimeControlEntries(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read time control entries: %w", err)
+		}
+		plan.Schedule = bleflows.DiffTimeControlEntries(current, scheduleDocument{Schedule: *profile.Schedule}.toEntries())
+	}
+
+	if profile.KeypadCodes != nil {
+		desired, err := profile.keypadCodes()
+		if err != nil {
+			return nil, err
+		}
+		current, err := flow.GetAllKeypadCodes(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read keypad codes: %w", err)
+		}
+		plan.KeypadCodes = bleflows.DiffKeypadCodes(current, desired)
+	}
+	return plan, nil
+}
+
+// rejectDeviceSpecific returns an error if profile contains settings specific to a device.
+func rejectDeviceSpecific(profile *deviceProfile) error {
+	for _, k := range deviceSpecificConfigKeys {
+		if _, ok := profile.Config[k]; ok {
+			return fmt.Errorf("config %s is specific to a device, use --device-specific to apply it", k)
+		}
+	}
+	for _, k := range deviceSpecificAdvancedConfigKeys {
+		if _, ok := profile.AdvancedConfig[k]; ok {
+			return fmt.Errorf("advanced config %s is specific to a device, use --device-specific to apply it", k)
+		}
+	}
+	return nil
+}
+
+func printProfilePlan(plan *profilePlan) {
+	if plan.isEmpty() {
+		fmt.Println("Device matches the profile")
+		return
+	}
+	for _, c := range plan.Config {
+		fmt.Printf("~ config %s\n", c)
+	}
+	for _, c := range plan.AdvancedConfig {
+		fmt.Printf("~ advanced config %s\n", c)
+	}
+	if plan.Schedule != nil && !plan.Schedule.IsEmpty() {
+		printTimeControlChanges(plan.Schedule)
+	}
+	if plan.KeypadCodes != nil {
+		for _, c := range plan.KeypadCodes.Remove {
+			fmt.Println(colorRed(fmt.Sprintf("- keypad code %q", c.Name)))
+		}
+		for _, u := range plan.KeypadCodes.Update {
+			var changed []string
+			if u.From.Code != u.To.Code {
+				changed = append(changed, "code changed")
+			}
+			if u.From.Enabled != u.To.Enabled {
+				changed = append(changed, fmt.Sprintf("enabled: %t -> %t", u.From.Enabled, u.To.Enabled))
+			}
+			if u.From.TimeLimit.String() != u.To.TimeLimit.String() {
+				changed = append(changed, fmt.Sprintf("time limit: %s -> %s", u.From.TimeLimit, u.To.TimeLimit))
+			}
+			fmt.Printf("~ keypad code %q: %s\n", u.To.Name, strings.Join(changed, ", "))
+		}
+		for _, c := range plan.KeypadCodes.Add {
+			fmt.Println(colorGreen(fmt.Sprintf("+ keypad code %q (%s)%s", c.Name, c.TimeLimit, disabledSuffix(c.Enabled))))
+		}
+	}
+}
+
+// applyProfilePlan sends all planned changes to the device. Unchanged sections are skipped entirely.
+func applyProfilePlan(ctx context.Context, flow *bleflows.Flow, plan *profilePlan) error {
+	if len(plan.Config) > 0 {
+		if err := flow.SetConfig(ctx, plan.config); err != nil {
+			return fmt.Errorf("failed to write config: %w", err)
+		}
+	}
+	if len(plan.AdvancedConfig) > 0 {
+		if err := flow.SetAdvancedConfig(ctx, plan.advancedConfig); err != nil {
+			return fmt.Errorf("failed to write advanced config: %w", err)
+		}
+	}
+	if plan.Schedule != nil && !plan.Schedule.IsEmpty() {
+		if err := flow.ApplyTimeControlChanges(ctx, plan.Schedule); err != nil {
+			return err
+		}
+	}
+	if plan.KeypadCodes != nil && !plan.KeypadCodes.IsEmpty() {
+		if err := flow.ApplyKeypadCodeChanges(ctx, plan.KeypadCodes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	configCmd.AddCommand(configExportCmd, configDiffCmd, configApplyCmd)
+
+	for _, c := range []*cobra.Command{configDiffCmd, configApplyCmd} {
+		c.Flags().StringVarP(&profileFile, "file", "f", "", "YAML or JSON profile, - for stdin")
+		c.MarkFlagRequired("file")
+	}
+	configApplyCmd.Flags().BoolVar(&profileDryRun, "dry-run", false, "Only show the changes, do not send them to the device")
+	for _, c := range []*cobra.Command{configExportCmd, configDiffCmd, configApplyCmd} {
+		c.Flags().BoolVar(&profileDeviceSpecific, "device-specific", false, "Include the name, the location and the calibration of the device")
+	}
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+	"github.com/nuki-io/nuki-cli/pkg/blerecord"
+	"github.com/spf13/cobra"
+)
+
+var (
+	recordFile string
+	recordAuth bool
+
+	// recordCommand is the command line recorded in the session header, flags are left out since they may contain PINs.
+	recordCommand string
+	recorder      *blerecord.Recorder
+	recordOut     *os.File
+)
+
+func init() {
+	bleCmd.PersistentFlags().StringVar(&recordFile, "record", "", "Record the messages exchanged with the device to this file (JSON Lines). The decoded messages include keypad codes and settings, the security PIN is only included with --record-auth")
+	bleCmd.PersistentFlags().BoolVar(&recordAuth, "record-auth", false, "Include the authorization in the recording, which is needed to replay it. Anyone with the recording can control the device!")
+}
+
+// setRecordCommand remembers the command for the session header of a recording.
+func setRecordCommand(cmd *cobra.Command, args []string) {
+	recordCommand = strings.Join(append([]string{cmd.CommandPath()}, args...), " ")
+}
+
+// startRecording wraps transport in a recorder if --record is set.
+func startRecording(transport bleflows.Transport) (bleflows.Transport, error) {
+	if recordFile == "" {
+		return transport, nil
+	}
+	// the recording contains keypad codes and other settings of the device, and with --record-auth the shared key
+	f, err := os.OpenFile(recordFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recording: %w", err)
+	}
+	header := blerecord.Header{Started: time.Now(), DeviceID: deviceId, Command: recordCommand}
+	var auth *bleflows.AuthorizeContext
+	if store, err := authStore(); err == nil {
+		auth, _ = store.Load(deviceId)
+	}
+	if auth != nil && recordAuth {
+		header.Authorization = blerecord.NewAuthorization(auth)
+	}
+	rec, err := blerecord.NewRecorder(transport, f, header)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if auth != nil {
+		rec.SetAuthorization(auth)
+	}
+	recorder = rec
+	recordOut = f
+	return rec, nil
+}
+
+// useRecorderRandom makes flow read its random bytes through the recorder, so that the recording can be replayed.
+func useRecorderRandom(flow *bleflows.Flow) {
+	if recorder != nil {
+		flow.SetRandom(recorder.Random())
+	}
+}
+
+// stopRecording closes the recording started by startRecording.
+func stopRecording() {
+	if recordOut == nil {
+		return
+	}
+	if err := recordOut.Close(); err != nil {
+		slog.Warn("Failed to close recording", "error", err)
+	}
+	recorder = nil
+	recordOut = nil
+}
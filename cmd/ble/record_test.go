@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"context"
+	"encoding/hex"
+	"net"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+	"github.com/nuki-io/nuki-cli/pkg/blerecord"
+	"github.com/nuki-io/nuki-cli/pkg/nukisim"
+	"github.com/stretchr/testify/require"
+)
+
+// recordPairing pairs with a simulator served over TCP, like 'ble authorize --sim --record', and returns the recording.
+func recordPairing(t *testing.T, withAuth bool) (string, *bleflows.AuthorizeContext) {
+	t.Helper()
+	resetConfig(t)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+	go nukisim.New(nukisim.State{}).Serve(l)
+
+	file := path.Join(t.TempDir(), "pairing.jsonl")
+	simAddr, deviceId, recordFile, recordAuth = l.Addr().String(), "sim", file, withAuth
+	t.Cleanup(func() { simAddr, deviceId, recordFile, recordAuth = "", "", "", false })
+
+	err = withUnauthenticatedFlow(func(ctx context.Context, flow *bleflows.Flow) error {
+		return flow.Authorize(ctx, nukisim.DefaultPin)
+	})
+	require.NoError(t, err)
+	auth, err := viperAuthStore{}.Load("sim")
+	require.NoError(t, err)
+	b, err := os.ReadFile(file)
+	require.NoError(t, err)
+	return string(b), auth
+}
+
+func TestRecordPairingWithoutAuth(t *testing.T) {
+	recording, auth := recordPairing(t, false)
+	require.NotContains(t, recording, hex.EncodeToString(auth.CliPrivateKey))
+	require.NotContains(t, recording, hex.EncodeToString(auth.SharedKey))
+
+	s, err := blerecord.ReadSession(strings.NewReader(recording))
+	require.NoError(t, err)
+	require.NotEmpty(t, s.Events)
+	for _, e := range s.Events {
+		require.NotEqual(t, blerecord.EventRandom, e.Type)
+	}
+}
+
+func TestRecordPairingWithAuth(t *testing.T) {
+	recording, auth := recordPairing(t, true)
+	require.Contains(t, recording, hex.EncodeToString(auth.CliPrivateKey))
+}
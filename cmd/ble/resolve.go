@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// pairedDevice is a device with an authorization in the config file.
+type pairedDevice struct {
+	Id string
+	*authorizeContextStorage
+}
+
+// pairedDevices returns the devices with an authorization in the config file, sorted by ID. The config file lists
+// them for every auth store, see viperAuthStore.
+func pairedDevices() ([]pairedDevice, error) {
+	ids := make([]string, 0)
+	for id := range viper.GetStringMap("authorizations") {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	devices := make([]pairedDevice, 0, len(ids))
+	for _, id := range ids {
+		s, ok, err := loadStorage(id)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			devices = append(devices, pairedDevice{id, s})
+		}
+	}
+	return devices, nil
+}
+
+// resolveDevice returns the device ID of the device addressed by id, which is either its device ID, an alias or, if
+// the device is paired, its Nuki ID or its name. IDs matching nothing are returned unchanged, e.g. for devices that
+// are not paired yet.
+func resolveDevice(id string) (string, error) {
+	if id == "" {
+		return "", nil
+	}
+	if _, ok, err := loadStorage(id); ok {
+		return id, err
+	}
+	if target, ok := aliases()[strings.ToLower(id)]; ok {
+		return target, nil
+	}
+	devices, err := pairedDevices()
+	if err != nil {
+		return "", err
+	}
+	var matches []string
+	for _, d := range devices {
+		if strings.EqualFold(d.Name, id) || nukiIdMatches(d.NukiId, id) {
+			matches = append(matches, d.Id)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return id, nil
+	case 1:
+		return matches[0], nil
+	}
+	return "", fmt.Errorf("%q matches the paired devices %s, use the device ID instead", id, strings.Join(matches, ", "))
+}
+
+// nukiIdMatches reports whether id is the stored Nuki ID, both in hex.
+func nukiIdMatches(stored, id string) bool {
+	s, err := parseNukiId(stored)
+	if err != nil || s == 0 {
+		return false
+	}
+	v, err := parseNukiId(id)
+	return err == nil && v == s
+}
+
+// resolveDevices resolves each of ids, see resolveDevice.
+func resolveDevices(ids []string) ([]string, error) {
+	resolved := make([]string, len(ids))
+	for i, id := range ids {
+		r, err := resolveDevice(id)
+		if err != nil {
+			return nil, err
+		}
+		resolved[i] = r
+	}
+	return resolved, nil
+}
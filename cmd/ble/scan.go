@@ -1,32 +1,116 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
 	"time"
 
-	c "github.com/nuki-io/nuki-cli/cmd"
+	"github.com/charmbracelet/lipgloss/table"
 	"github.com/nuki-io/nuki-cli/pkg/nukible"
 	"github.com/spf13/cobra"
 )
 
+var (
+	scanDuration   time.Duration
+	scanContinuous bool
+	scanMinRSSI    int16
+	scanUnpaired   bool
+)
+
 // scanCmd represents the scan command
 var scanCmd = &cobra.Command{
 	Use:   "scan",
 	Short: "Scan for Nuki devices using Bluetooth",
-	Long:  `Scans for Nuki devices using your local default Bluetooth adapter.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		ble, err := nukible.NewNukiBle()
+	Long: `Scans for Nuki devices using your local default Bluetooth adapter and lists them, strongest signal first.
+
+The device type is inferred from the advertisement, Smart Lock generations cannot be told apart.
+With --continuous the scan runs until interrupted and every device is printed as soon as it is found
+or enters or leaves pairing mode.`,
+	Example: `  nukictl ble scan --duration 30s --unpaired
+  nukictl ble scan --continuous --min-rssi -70 --format json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if simAddr != "" {
+			return fmt.Errorf("the simulator does not send advertisements")
+		}
+		ble, err := newNukiBle()
 		if err != nil {
-			c.Logger.Error("Failed to enable bluetooth device", "error", err.Error())
-			return
+			return fmt.Errorf("failed to enable bluetooth: %w", err)
+		}
+		if scanContinuous {
+			return scanContinuously(ble)
 		}
-		err = ble.Scan(10 * time.Second)
+		devices, err := ble.ScanWithOptions(nukible.ScanOptions{Timeout: scanDuration, MinRSSI: scanMinRSSI, IsPaired: isPaired})
 		if err != nil {
-			c.Logger.Error("Failed to scan", "error", err.Error())
-			return
+			return fmt.Errorf("failed to scan: %w", err)
 		}
+		if scanUnpaired {
+			devices = slices.DeleteFunc(devices, func(d nukible.FoundDevice) bool { return d.Paired })
+		}
+		if outputFormat == "json" {
+			return printJSON(devices)
+		}
+		t := table.New().Headers("Address", "Name", "Type", "RSSI", "Pairing Mode", "Paired")
+		for _, d := range devices {
+			t.Row(d.Address, d.Name, string(d.DeviceType), fmt.Sprintf("%d dBm", d.RSSI), boolToIcon(d.PairingMode), boolToIcon(d.Paired))
+		}
+		fmt.Println(t)
+		return nil
 	},
 }
 
 func init() {
 	bleCmd.AddCommand(scanCmd)
+	scanCmd.Flags().DurationVar(&scanDuration, "duration", 10*time.Second, "How long to scan")
+	scanCmd.Flags().BoolVar(&scanContinuous, "continuous", false, "Scan until interrupted and print devices as they are found")
+	scanCmd.Flags().Int16Var(&scanMinRSSI, "min-rssi", 0, "Ignore devices with a weaker signal in dBm, e.g. -70")
+	scanCmd.Flags().BoolVar(&scanUnpaired, "unpaired", false, "Only list devices that are not paired yet")
+}
+
+// scanContinuously prints the devices found until interrupted. A device is printed again if it enters or leaves pairing mode.
+func scanContinuously(ble *nukible.NukiBle) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	ch, err := ble.Watch(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to scan: %w", err)
+	}
+	seen := map[string]nukible.FoundDevice{}
+	for adv := range ch {
+		if scanMinRSSI != 0 && adv.RSSI < scanMinRSSI {
+			continue
+		}
+		last, ok := seen[adv.Address]
+		if ok && last.PairingMode == adv.PairingMode {
+			continue
+		}
+		d := nukible.FoundDevice{
+			Address:     adv.Address,
+			Name:        adv.Name,
+			RSSI:        adv.RSSI,
+			PairingMode: adv.PairingMode,
+			DeviceType:  adv.DeviceType,
+			Paired:      last.Paired,
+		}
+		if !ok {
+			d.Paired = isPaired(adv.Address)
+		}
+		if d.Name == "" {
+			d.Name = last.Name
+		}
+		seen[adv.Address] = d
+		if scanUnpaired && d.Paired {
+			continue
+		}
+		if outputFormat == "json" {
+			printJSON(d)
+			continue
+		}
+		fmt.Printf("%s  %-17s  %-20s  %-14s  RSSI %4d dBm  pairing mode: %-5v  paired: %v\n",
+			adv.Time.Format(time.TimeOnly), d.Address, d.Name, d.DeviceType, d.RSSI, d.PairingMode, d.Paired)
+	}
+	return nil
 }
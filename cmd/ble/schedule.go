@@ -0,0 +1,297 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/nuki-io/nuki-cli/pkg/blecommands"
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+	"github.com/spf13/cobra"
+)
+
+var (
+	scheduleWeekdays string
+	scheduleTime     string
+	scheduleAction   string
+	scheduleEnabled  bool
+	scheduleFile     string
+	scheduleDryRun   bool
+)
+
+// scheduleEntry is a time control entry as written in a schedule file.
+type scheduleEntry struct {
+	Weekdays blecommands.Weekdays  `json:"weekdays"`
+	Time     blecommands.TimeOfDay `json:"time"`
+	Action   blecommands.Action    `json:"action"`
+	Enabled  *bool                 `json:"enabled,omitempty"` // defaults to true
+}
+
+// scheduleDocument is the format of the file given to schedule diff and schedule apply.
+type scheduleDocument struct {
+	Schedule []scheduleEntry `json:"schedule"`
+}
+
+func (s scheduleDocument) toEntries() []blecommands.TimeControlEntry {
+	entries := make([]blecommands.TimeControlEntry, 0, len(s.Schedule))
+	for _, e := range s.Schedule {
+		entries = append(entries, blecommands.TimeControlEntry{
+			Enabled:  e.Enabled == nil || *e.Enabled,
+			Weekdays: e.Weekdays,
+			Time:     e.Time,
+			Action:   e.Action,
+		})
+	}
+	return entries
+}
+
+// scheduleCmd represents the schedule command
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Manage scheduled lock actions (time control) of a device",
+	Long: `List, add, update and remove lock actions the device executes automatically at a given time.
+A whole schedule can also be defined in a YAML or JSON file and compared against or applied to the device:
+
+  schedule:
+    - weekdays: mon,tue,wed,thu,fri
+      time: "07:30"
+      action: unlock
+    - weekdays: all
+      time: "22:00"
+      action: lock
+      enabled: false`,
+}
+
+// scheduleListCmd represents the schedule list command
+var scheduleListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List the scheduled lock actions stored on the device",
+	PreRunE: mustDeviceId,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withAuthenticatedFlow(func(ctx context.Context, flow *bleflows.Flow) error {
+			entries, err := flow.GetTimeControlEntries(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to read time control entries: %w", err)
+			}
+			if outputFormat == "json" {
+				return printJSON(entries)
+			}
+			t := table.New().Headers("Entry ID", "Enabled", "Weekdays", "Time", "Action")
+			for _, e := range entries {
+				t.Row(fmt.Sprintf("%d", e.EntryId), boolToIcon(e.Enabled), e.Weekdays.String(), e.Time.String(), e.Action.String())
+			}
+			fmt.Println(t)
+			return nil
+		})
+	},
+}
+
+// scheduleAddCmd represents the schedule add command
+var scheduleAddCmd = &cobra.Command{
+	Use:     "add",
+	Short:   "Add a scheduled lock action to the device",
+	Example: `nukictl ble schedule add --weekdays weekdays --time 07:30 --action unlock`,
+	PreRunE: mustDeviceId,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		entry := &blecommands.TimeControlEntry{Enabled: true}
+		if err := applyScheduleFlags(cmd, entry); err != nil {
+			return err
+		}
+		return withAuthenticatedFlow(func(ctx context.Context, flow *bleflows.Flow) error {
+			id, err := flow.AddTimeControlEntry(ctx, entry)
+			if err != nil {
+				return fmt.Errorf("failed to add time control entry: %w", err)
+			}
+			fmt.Printf("Added scheduled action %s with ID %d\n", entry, id)
+			return nil
+		})
+	},
+}
+
+// scheduleUpdateCmd represents the schedule update command
+var scheduleUpdateCmd = &cobra.Command{
+	Use:     "update <entry-id>",
+	Short:   "Update a scheduled lock action stored on the device",
+	Long:    `Only the given flags are changed, all other settings of the entry are kept.`,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: mustDeviceId,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		entryId, err := parseEntryId(args[0])
+		if err != nil {
+			return err
+		}
+		return withAuthenticatedFlow(func(ctx context.Context, flow *bleflows.Flow) error {
+			entries, err := flow.GetTimeControlEntries(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to read time control entries: %w", err)
+			}
+			var entry *blecommands.TimeControlEntry
+			for i := range entries {
+				if entries[i].EntryId == entryId {
+					entry = &entries[i]
+				}
+			}
+			if entry == nil {
+				return fmt.Errorf("no time control entry with ID %d found", entryId)
+			}
+			if err := applyScheduleFlags(cmd, entry); err != nil {
+				return err
+			}
+			if err := flow.UpdateTimeControlEntry(ctx, entry); err != nil {
+				return fmt.Errorf("failed to update time control entry: %w", err)
+			}
+			fmt.Printf("Updated scheduled action %d: %s\n", entry.EntryId, entry)
+			return nil
+		})
+	},
+}
+
+// scheduleRemoveCmd represents the schedule remove command
+var scheduleRemoveCmd = &cobra.Command{
+	Use:     "remove <entry-id>",
+	Aliases: []string{"rm"},
+	Short:   "Remove a scheduled lock action from the device",
+	Args:    cobra.ExactArgs(1),
+	PreRunE: mustDeviceId,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		entryId, err := parseEntryId(args[0])
+		if err != nil {
+			return err
+		}
+		return withAuthenticatedFlow(func(ctx context.Context, flow *bleflows.Flow) error {
+			if err := flow.RemoveTimeControlEntry(ctx, entryId); err != nil {
+				return fmt.Errorf("failed to remove time control entry: %w", err)
+			}
+			fmt.Printf("Removed scheduled action %d\n", entryId)
+			return nil
+		})
+	},
+}
+
+// scheduleDiffCmd represents the schedule diff command
+var scheduleDiffCmd = &cobra.Command{
+	Use:     "diff",
+	Short:   "Show the differences between a schedule file and the schedule stored on the device",
+	PreRunE: mustDeviceId,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runScheduleFile(true)
+	},
+}
+
+// scheduleApplyCmd represents the schedule apply command
+var scheduleApplyCmd = &cobra.Command{
+	Use:     "apply",
+	Short:   "Make the schedule stored on the device match a schedule file",
+	Long:    `Only the entries that differ from the file are added, updated or removed.`,
+	Example: `nukictl ble schedule apply -f schedule.yaml --dry-run`,
+	PreRunE: mustDeviceId,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runScheduleFile(scheduleDryRun)
+	},
+}
+
+func runScheduleFile(dryRun bool) error {
+	doc := scheduleDocument{}
+	if err := readDocument(scheduleFile, &doc); err != nil {
+		return err
+	}
+	return withAuthenticatedFlow(func(ctx context.Context, flow *bleflows.Flow) error {
+		current, err := flow.GetTimeControlEntries(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to read time control entries: %w", err)
+		}
+		changes := bleflows.DiffTimeControlEntries(current, doc.toEntries())
+		if outputFormat == "json" {
+			if err := printJSON(changes); err != nil {
+				return err
+			}
+		} else {
+			printTimeControlChanges(changes)
+		}
+		if dryRun || changes.IsEmpty() {
+			return nil
+		}
+		if err := flow.ApplyTimeControlChanges(ctx, changes); err != nil {
+			return err
+		}
+		fmt.Println("Schedule applied")
+		return nil
+	})
+}
+
+func printTimeControlChanges(changes *bleflows.TimeControlChanges) {
+	if changes.IsEmpty() {
+		fmt.Println("Schedule is up to date")
+		return
+	}
+	for _, e := range changes.Remove {
+		fmt.Println(colorRed(fmt.Sprintf("- [%d] %s%s", e.EntryId, e.String(), disabledSuffix(e.Enabled))))
+	}
+	for _, u := range changes.Update {
+		fmt.Printf("~ [%d] %s%s -> %s%s\n", u.From.EntryId, u.From.String(), disabledSuffix(u.From.Enabled), u.To.String(), disabledSuffix(u.To.Enabled))
+	}
+	for _, e := range changes.Add {
+		fmt.Println(colorGreen(fmt.Sprintf("+ %s%s", e.String(), disabledSuffix(e.Enabled))))
+	}
+}
+
+func disabledSuffix(enabled bool) string {
+	if enabled {
+		return ""
+	}
+	return " (disabled)"
+}
+
+func applyScheduleFlags(cmd *cobra.Command, entry *blecommands.TimeControlEntry) error {
+	var err error
+	if cmd.Flags().Changed("weekdays") {
+		if entry.Weekdays, err = blecommands.ParseWeekdays(scheduleWeekdays); err != nil {
+			return err
+		}
+	}
+	if cmd.Flags().Changed("time") {
+		if entry.Time, err = blecommands.ParseTimeOfDay(scheduleTime); err != nil {
+			return err
+		}
+	}
+	if cmd.Flags().Changed("action") {
+		if entry.Action, err = blecommands.ParseAction(scheduleAction); err != nil {
+			return err
+		}
+	}
+	if cmd.Flags().Changed("enabled") {
+		entry.Enabled = scheduleEnabled
+	}
+	return nil
+}
+
+func parseEntryId(s string) (uint8, error) {
+	v, err := strconv.ParseUint(s, 0, 8)
+	if err != nil {
+		return 0, fmt.Errorf("invalid entry ID %q", s)
+	}
+	return uint8(v), nil
+}
+
+func init() {
+	bleCmd.AddCommand(scheduleCmd)
+	scheduleCmd.AddCommand(scheduleListCmd, scheduleAddCmd, scheduleUpdateCmd, scheduleRemoveCmd, scheduleDiffCmd, scheduleApplyCmd)
+
+	for _, c := range []*cobra.Command{scheduleAddCmd, scheduleUpdateCmd} {
+		c.Flags().StringVar(&scheduleWeekdays, "weekdays", "", "Weekdays the action is executed on, e.g. mon,tue,wed or all, weekdays, weekend")
+		c.Flags().StringVar(&scheduleTime, "time", "", "Time of day the action is executed at (HH:MM)")
+		c.Flags().StringVar(&scheduleAction, "action", "", "The lock action to execute, e.g. unlock, lock, unlatch, lock-and-go")
+	}
+	scheduleAddCmd.MarkFlagRequired("weekdays")
+	scheduleAddCmd.MarkFlagRequired("time")
+	scheduleAddCmd.MarkFlagRequired("action")
+	scheduleUpdateCmd.Flags().BoolVar(&scheduleEnabled, "enabled", true, "Enable or disable the scheduled action")
+
+	for _, c := range []*cobra.Command{scheduleDiffCmd, scheduleApplyCmd} {
+		c.Flags().StringVarP(&scheduleFile, "file", "f", "", "YAML or JSON file with the desired schedule")
+		c.MarkFlagRequired("file")
+	}
+	scheduleApplyCmd.Flags().BoolVar(&scheduleDryRun, "dry-run", false, "Only show the changes, do not send them to the device")
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"runtime"
+	"sync"
+	"time"
+
+	parentcmd "github.com/nuki-io/nuki-cli/cmd"
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+	"github.com/nuki-io/nuki-cli/pkg/nukible"
+	"github.com/nuki-io/nuki-cli/pkg/nukiserver"
+	"github.com/nuki-io/nuki-cli/pkg/nukisim"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveAddr  string
+	serveToken string
+)
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serves an HTTP API to control the paired devices",
+	Long: `Serves a local HTTP API to read the state, config, logs and battery report of all paired devices and to
+lock, unlock, unlatch or lock'n'go them. The Bluetooth adapter and the device connections are kept open between requests,
+requests to the same device are handled one after another.
+
+All requests must send the token as bearer token. If no token is set with --token or the NUKICTL_SERVE_TOKEN
+environment variable, a random token is generated and logged at startup.
+
+The {id} of a request is the device ID, an alias, or the name or Nuki ID of a paired device.
+
+  GET  /devices/{id}/state
+  GET  /devices/{id}/config
+  GET  /devices/{id}/logs?start=0&count=20
+  GET  /devices/{id}/battery
+  POST /devices/{id}/lock
+  POST /devices/{id}/unlock
+  POST /devices/{id}/unlatch
+  POST /devices/{id}/lockngo`,
+	Example: `  nukictl serve --listen localhost:8080 --token secret
+  curl -H "Authorization: Bearer secret" localhost:8080/devices/54FD3A2B/state
+  curl -X POST -H "Authorization: Bearer secret" localhost:8080/devices/54FD3A2B/unlock`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		token := serveToken
+		if token == "" {
+			token = os.Getenv("NUKICTL_SERVE_TOKEN")
+		}
+		if token == "" {
+			b := make([]byte, 16)
+			if _, err := rand.Read(b); err != nil {
+				return err
+			}
+			token = hex.EncodeToString(b)
+			slog.Info("Generated API token", "token", token)
+		}
+
+		store, err := authStore()
+		if err != nil {
+			return err
+		}
+		server := nukiserver.New(newPoolDialer(), store, token)
+		server.SetResolver(resolveDevice)
+		defer server.Close()
+
+		l, err := net.Listen("tcp", serveAddr)
+		if err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", serveAddr, err)
+		}
+		httpServer := &http.Server{Handler: server.Handler(), ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			sigs := make(chan os.Signal, 1)
+			signal.Notify(sigs, os.Interrupt)
+			<-sigs
+			httpServer.Close()
+		}()
+
+		slog.Info("Serving API", "address", l.Addr().String())
+		if err := httpServer.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	},
+}
+
+func init() {
+	parentcmd.RootCmd.AddCommand(serveCmd)
+	serveCmd.Flags().StringVarP(&serveAddr, "listen", "l", "localhost:8080", "The address to listen on")
+	serveCmd.Flags().StringVar(&serveToken, "token", "", "The token clients must send as bearer token")
+	serveCmd.Flags().StringVar(&simAddr, "sim", "", "Connect to a simulator started with 'nukictl sim' at this address instead of using Bluetooth")
+	addConnectionFlags(serveCmd.Flags())
+	addAuthStoreFlags(serveCmd.Flags())
+}
+
+// newPoolDialer returns a dialer that connects to the simulator set with --sim or through Bluetooth.
+// The Bluetooth adapter is enabled on the first connection and kept enabled.
+func newPoolDialer() bleflows.Dialer {
+	var (
+		mu  sync.Mutex
+		ble *nukible.NukiBle
+	)
+	return func(id string) (bleflows.Transport, error) {
+		if simAddr != "" {
+			return nukisim.NewRemoteDevice(simAddr), nil
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if ble == nil {
+			var err error
+			if ble, err = newNukiBle(); err != nil {
+				return nil, fmt.Errorf("failed to enable bluetooth: %w", err)
+			}
+		}
+		if runtime.GOOS == "linux" {
+			if _, err := ble.ScanForDevice(id, 10*time.Second); err != nil {
+				return nil, fmt.Errorf("failed to scan for device: %w", err)
+			}
+		}
+		return newDevice(ble, id), nil
+	}
+}
@@ -12,9 +12,22 @@ var setContextCmd = &cobra.Command{
 	Short: "Sets the active device used for device specific commands.",
 	Long: `Instead of always specifying the device-id, you can set the active device with this command.
 This is useful for commands that require a device-id, but you don't want to specify it every time.
-The device-id is stored in the config file and used for all commands that require a device-id.`,
-	Example: `nukictl ble set-context 1234567890abcdef`,
-	PreRunE: mustDeviceId,
+The device-id is stored in the config file and used for all commands that require a device-id.
+The device can be given as argument or with --device-id, by its device ID, an alias, its Nuki ID or its name.`,
+	Example: `nukictl ble set-context 1234567890abcdef
+nukictl ble set-context frontdoor`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeDevices,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 1 {
+			id, err := resolveDevice(args[0])
+			if err != nil {
+				return err
+			}
+			deviceId = id
+		}
+		return mustDeviceId(cmd, args)
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		viper.Set("activeContext", deviceId)
 		err := viper.WriteConfig()
@@ -6,6 +6,7 @@ import (
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/lipgloss/table"
+	"github.com/nuki-io/nuki-cli/pkg/blecommands"
 	"github.com/nuki-io/nuki-cli/pkg/bleflows"
 	"github.com/spf13/cobra"
 )
@@ -17,6 +18,21 @@ var stateCmd = &cobra.Command{
 	PreRunE: mustDeviceId,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return withAuthenticatedFlow(func(ctx context.Context, flow *bleflows.Flow) error {
+			deviceType, err := flow.DeviceType(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to read device type: %w", err)
+			}
+			if deviceType == blecommands.DeviceTypeOpener {
+				status, err := flow.GetOpenerStatus(ctx)
+				if err != nil {
+					return fmt.Errorf("failed to get status: %w", err)
+				}
+				if outputFormat == "json" {
+					return printJSON(status)
+				}
+				printOpenerStates(status)
+				return nil
+			}
 			status, err := flow.GetStatus(ctx)
 			if err != nil {
 				return fmt.Errorf("failed to get status: %w", err)
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nuki-io/nuki-cli/pkg/blecommands"
+	"github.com/spf13/cobra"
+)
+
+// timeLimitFlags holds the flags shared by all commands that edit a blecommands.TimeLimit.
+type timeLimitFlags struct {
+	timeLimited  bool
+	allowedFrom  string
+	allowedUntil string
+	weekdays     string
+	fromTime     string
+	untilTime    string
+}
+
+func (f *timeLimitFlags) register(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&f.timeLimited, "time-limited", false, "Restrict access to the configured dates, weekdays and times")
+	cmd.Flags().StringVar(&f.allowedFrom, "allowed-from", "", "First day access is allowed (YYYY-MM-DD or RFC3339). Empty for no restriction.")
+	cmd.Flags().StringVar(&f.allowedUntil, "allowed-until", "", "Last day access is allowed (YYYY-MM-DD or RFC3339). Empty for no restriction.")
+	cmd.Flags().StringVar(&f.weekdays, "weekdays", "", "Weekdays access is allowed on, e.g. mon,tue,wed or all, weekdays, weekend")
+	cmd.Flags().StringVar(&f.fromTime, "from-time", "", "Time of day from which access is allowed (HH:MM)")
+	cmd.Flags().StringVar(&f.untilTime, "until-time", "", "Time of day until which access is allowed (HH:MM)")
+}
+
+// apply sets all fields of l whose flag was explicitly set on cmd.
+func (f *timeLimitFlags) apply(cmd *cobra.Command, l *blecommands.TimeLimit) error {
+	var err error
+	if cmd.Flags().Changed("time-limited") {
+		l.TimeLimited = f.timeLimited
+	}
+	if cmd.Flags().Changed("allowed-from") {
+		if l.AllowedFromDate, err = parseDate(f.allowedFrom); err != nil {
+			return fmt.Errorf("invalid --allowed-from: %w", err)
+		}
+	}
+	if cmd.Flags().Changed("allowed-until") {
+		if l.AllowedUntilDate, err = parseDate(f.allowedUntil); err != nil {
+			return fmt.Errorf("invalid --allowed-until: %w", err)
+		}
+	}
+	if cmd.Flags().Changed("weekdays") {
+		if l.AllowedWeekdays, err = blecommands.ParseWeekdays(f.weekdays); err != nil {
+			return fmt.Errorf("invalid --weekdays: %w", err)
+		}
+	}
+	if cmd.Flags().Changed("from-time") {
+		if l.AllowedFromTime, err = blecommands.ParseTimeOfDay(f.fromTime); err != nil {
+			return fmt.Errorf("invalid --from-time: %w", err)
+		}
+	}
+	if cmd.Flags().Changed("until-time") {
+		if l.AllowedUntilTime, err = blecommands.ParseTimeOfDay(f.untilTime); err != nil {
+			return fmt.Errorf("invalid --until-time: %w", err)
+		}
+	}
+	return nil
+}
+
+// parseDate parses a date in local time. An empty string results in the zero time, i.e. no restriction.
+func parseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation(time.DateOnly, s, time.Local)
+}
+
+// formatTime renders t in local time, or "-" if t is not set.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format(time.DateTime)
+}
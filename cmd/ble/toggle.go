@@ -3,7 +3,6 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"time"
 
 	"github.com/nuki-io/nuki-cli/pkg/blecommands"
 	"github.com/nuki-io/nuki-cli/pkg/bleflows"
@@ -14,35 +13,59 @@ var repeats int
 
 // toggleCmd represents the toggle command
 var toggleCmd = &cobra.Command{
-	Use:     "toggle",
-	Short:   "Toggles the current lock state",
-	Long:    `Depending on the lock's current state, this command either locks or unlocks.`,
+	Use:   "toggle",
+	Short: "Toggles the current lock state",
+	Long: `Depending on the lock's current state, this command either locks or unlocks.
+On an Opener, ring to open is activated or deactivated instead.`,
 	PreRunE: mustDeviceId,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		// done counts the finished toggles, so that a retry of withAuthenticatedFlow only performs the remaining ones
+		done := 0
 		return withAuthenticatedFlow(func(ctx context.Context, flow *bleflows.Flow) error {
+			deviceType, err := flow.DeviceType(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to read device type: %w", err)
+			}
+			if deviceType == blecommands.DeviceTypeOpener {
+				return toggleOpener(ctx, flow, &done)
+			}
 			status, err := flow.GetStatus(ctx)
 			if err != nil {
 				return fmt.Errorf("failed to get status: %w", err)
 			}
-			var startAt int
-			if status.LockState == blecommands.LockStateLocked {
-				startAt = 0
-			} else {
-				startAt = 1
-			}
-			for i := range repeats {
-				if i%2 == startAt {
-					err = flow.PerformLockOperation(ctx, blecommands.Unlock)
-				} else {
-					err = flow.PerformLockOperation(ctx, blecommands.Lock)
+			locked := status.LockState == blecommands.LockStateLocked
+			for ; done < repeats; done++ {
+				action := blecommands.Lock
+				if locked {
+					action = blecommands.Unlock
 				}
-				// TODO: although we received the StatusComplete at this point, we apparently need to wait a bit longer
-				time.Sleep(500 * time.Millisecond)
+				if err := performLockAction(ctx, flow, action); err != nil {
+					return err
+				}
+				locked = !locked
 			}
-			return err
+			return nil
 		})
 	},
+}
 
+func toggleOpener(ctx context.Context, flow *bleflows.Flow, done *int) error {
+	status, err := flow.GetOpenerStatus(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get status: %w", err)
+	}
+	rtoActive := status.RingToOpenActive()
+	for ; *done < repeats; *done++ {
+		action := blecommands.OpenerActionActivateRTO
+		if rtoActive {
+			action = blecommands.OpenerActionDeactivateRTO
+		}
+		if err := flow.PerformOpenerAction(ctx, action); err != nil {
+			return err
+		}
+		rtoActive = !rtoActive
+	}
+	return nil
 }
 
 func init() {
@@ -12,10 +12,11 @@ import (
 var unlockCmd = &cobra.Command{
 	Use:     "unlock",
 	Short:   "Unlock a device via Bluetooth",
+	Long:    `Unlocks the device. On an Opener, this activates ring to open. Use "opener open" to actuate the electric strike.`,
 	PreRunE: mustDeviceId,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return withAuthenticatedFlow(func(ctx context.Context, flow *bleflows.Flow) error {
-			return flow.PerformLockOperation(ctx, blecommands.Unlock)
+			return performLockAction(ctx, flow, blecommands.Unlock)
 		})
 	},
 }
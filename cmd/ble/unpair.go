@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var unpairLocalOnly bool
+
+// unpairCmd represents the unpair command
+var unpairCmd = &cobra.Command{
+	Use:   "unpair",
+	Short: "Removes the authorization of this CLI from the device and the auth store",
+	Long: `Removes the authorization of this CLI from the device, then deletes it from the auth store together with the
+settings stored for the device. If the device is the active one set with set-context, the active device is unset.
+
+Use --local-only if the device is not reachable anymore, e.g. because it was reset. The authorization then
+stays on the device until it is removed with another app.`,
+	Example: `  nukictl ble -d 54FD3A2B unpair
+  nukictl ble -d 54FD3A2B unpair --local-only`,
+	PreRunE: mustDeviceId,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := authStore()
+		if err != nil {
+			return err
+		}
+		auth, err := store.Load(deviceId)
+		if err != nil {
+			return fmt.Errorf("device %s is not paired: %w", deviceId, err)
+		}
+		if !unpairLocalOnly {
+			if len(auth.AuthId) != 4 {
+				return fmt.Errorf("the authorization of device %s has no valid auth ID, use --local-only to delete it", deviceId)
+			}
+			authId := binary.LittleEndian.Uint32(auth.AuthId)
+			err := withAuthenticatedFlow(func(ctx context.Context, flow *bleflows.Flow) error {
+				return flow.RemoveAuthorizationEntry(ctx, authId)
+			})
+			if err != nil {
+				return fmt.Errorf("failed to remove the authorization from the device, use --local-only to delete it anyway: %w", err)
+			}
+			fmt.Printf("Removed authorization %d from device %s\n", authId, deviceId)
+		}
+		if err := store.Delete(deviceId); err != nil {
+			return err
+		}
+		if strings.EqualFold(viper.GetString("activecontext"), deviceId) {
+			viper.Set("activeContext", "")
+		}
+		fmt.Printf("Deleted the authorization of device %s\n", deviceId)
+		return nil
+	},
+}
+
+func init() {
+	bleCmd.AddCommand(unpairCmd)
+	unpairCmd.Flags().BoolVar(&unpairLocalOnly, "local-only", false, "Only delete the authorization from the auth store, without removing it from the device")
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/nuki-io/nuki-cli/pkg/blecommands"
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+	"github.com/nuki-io/nuki-cli/pkg/nukible"
+	"github.com/spf13/cobra"
+)
+
+// watchCmd represents the watch command
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Watches the advertisements of Nuki devices",
+	Long: `Listens to the advertisements of the Nuki devices in range without connecting to them and prints when a device
+appears, enters or leaves pairing mode or reports a state change.
+
+A device sets the state changed flag of its advertisement when its state changed since it was last read.
+Only then the states of a paired device are read, which saves the battery of the device compared to polling.
+If --device-id is given, only that device is watched. The device set with set-context does not restrict the watch.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if simAddr != "" {
+			return fmt.Errorf("the simulator does not send advertisements")
+		}
+		ble, err := newNukiBle()
+		if err != nil {
+			return fmt.Errorf("failed to enable bluetooth: %w", err)
+		}
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+
+		// only an explicit --device-id filters, not the device set with set-context
+		filter := ""
+		if cmd.Flags().Changed("device-id") {
+			filter = deviceId
+		}
+		seen := map[string]nukible.Advertisement{}
+		for ctx.Err() == nil {
+			changed, err := watchUntilStateChange(ctx, ble, filter, seen)
+			if err != nil {
+				return err
+			}
+			if changed != "" {
+				printWatchedStates(ble, changed)
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	bleCmd.AddCommand(watchCmd)
+}
+
+// watchUntilStateChange prints the changes of the advertisements of all devices or only of the device filter until a
+// paired device reports a state change, whose address is returned. The scan is stopped before, since devices cannot be connected reliably while scanning.
+func watchUntilStateChange(ctx context.Context, ble *nukible.NukiBle, filter string, seen map[string]nukible.Advertisement) (string, error) {
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+	ch, err := ble.Watch(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to scan: %w", err)
+	}
+	changed := ""
+	for adv := range ch {
+		if filter != "" && !strings.EqualFold(adv.Address, filter) {
+			continue
+		}
+		last, ok := seen[adv.Address]
+		seen[adv.Address] = adv
+		if ok && last.StateChanged == adv.StateChanged && last.PairingMode == adv.PairingMode {
+			continue
+		}
+		printAdvertisement(adv)
+		if changed == "" && adv.StateChanged && isPaired(adv.Address) {
+			changed = adv.Address
+			stop()
+		}
+	}
+	return changed, nil
+}
+
+func isPaired(id string) bool {
+	store, err := authStore()
+	if err != nil {
+		return false
+	}
+	_, err = store.Load(id)
+	return err == nil
+}
+
+func printAdvertisement(adv nukible.Advertisement) {
+	if outputFormat == "json" {
+		printJSON(adv)
+		return
+	}
+	fmt.Printf("%s  %-17s  %-20s  RSSI %4d dBm  state changed: %-5v  pairing mode: %v\n",
+		adv.Time.Format(time.TimeOnly), adv.Address, adv.Name, adv.RSSI, adv.StateChanged, adv.PairingMode)
+}
+
+// printWatchedStates reads and prints the states of a device, which resets its state changed flag.
+func printWatchedStates(ble *nukible.NukiBle, id string) {
+	ctx, cancel := context.WithTimeout(context.Background(), bleTimeout)
+	defer cancel()
+	states, err := readStates(ctx, ble, id)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read states of %s: %s\n", id, err)
+		return
+	}
+	if outputFormat == "json" {
+		printJSON(struct {
+			Address string                       `json:"address"`
+			States  *blecommands.KeyturnerStates `json:"states"`
+		}{id, states})
+		return
+	}
+	fmt.Printf("%s  %-17s  lock state: %s, door sensor: %v, battery: %d%%\n",
+		time.Now().Format(time.TimeOnly), id, states.LockState, states.DoorSensorState, states.BatteryPercentage)
+}
+
+func readStates(ctx context.Context, ble *nukible.NukiBle, id string) (*blecommands.KeyturnerStates, error) {
+	store, err := authStore()
+	if err != nil {
+		return nil, err
+	}
+	flow, err := bleflows.NewAuthenticatedFlow(newDevice(ble, id), id, store)
+	if err != nil {
+		return nil, err
+	}
+	defer flow.DisconnectDevice()
+	return flow.GetStatus(ctx)
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/nuki-io/nuki-cli/pkg/blecommands"
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+)
+
+// Exit codes of nukictl. Errors reported by a device are told apart, so that scripts can react to them.
+const (
+	ExitError      = 1 // any other error
+	ExitConnection = 3 // the device could not be connected or did not answer
+	ExitAuth       = 4 // the device rejected the authorization or the security PIN
+	ExitPairing    = 5 // pairing failed
+	ExitMotor      = 6 // the motor, the clutch or the calibration failed
+	ExitBusy       = 7 // the device was busy, trying again later may succeed
+	ExitDevice     = 8 // the device reported another error
+)
+
+// exitCode returns the exit code for err.
+func exitCode(err error) int {
+	var de *blecommands.DeviceError
+	switch {
+	case blecommands.IsAuthError(err):
+		return ExitAuth
+	case blecommands.IsPairingError(err):
+		return ExitPairing
+	case blecommands.IsMotorError(err):
+		return ExitMotor
+	case blecommands.IsTransientError(err):
+		return ExitBusy
+	case errors.As(err, &de):
+		return ExitDevice
+	case errors.Is(err, bleflows.ErrConnect), errors.Is(err, bleflows.ErrDiscovery), errors.Is(err, context.DeadlineExceeded):
+		return ExitConnection
+	}
+	return ExitError
+}
+
+// errorHint explains err and how to resolve it, if it was reported by the device or is a connection failure.
+func errorHint(err error) string {
+	var de *blecommands.DeviceError
+	if errors.As(err, &de) {
+		switch exitCode(err) {
+		case ExitAuth:
+			return de.Description + ". Check the security PIN or pair the device again."
+		case ExitPairing:
+			return de.Description + ". Press the button of the device for 5 seconds to enable pairing mode and try again."
+		case ExitMotor:
+			return de.Description + ". Check that the door and the lock move freely, the lock may need to be calibrated."
+		case ExitBusy:
+			return de.Description + ". Try again in a moment."
+		}
+		return de.Description + "."
+	}
+	if exitCode(err) == ExitConnection {
+		return "The device could not be reached. Make sure it is in range and Bluetooth is enabled."
+	}
+	return ""
+}
+
+// printError prints err with a hint how to resolve it.
+func printError(err error) {
+	fmt.Fprintln(os.Stderr, "Error:", err)
+	if hint := errorHint(err); hint != "" {
+		fmt.Fprintln(os.Stderr, hint)
+	}
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nuki-io/nuki-cli/pkg/blecommands"
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+	"github.com/nuki-io/nuki-cli/pkg/nukisim/nukisimtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExitCodeRevokedAuthorization(t *testing.T) {
+	sim, store := nukisimtest.Paired(t)
+	flow, err := bleflows.NewAuthenticatedFlow(sim.Connect(), nukisimtest.PairedDeviceId, store)
+	require.NoError(t, err)
+	require.NoError(t, flow.RemoveAuthorizationEntry(context.Background(), 1))
+	require.NoError(t, flow.DisconnectDevice())
+
+	flow, err = bleflows.NewAuthenticatedFlow(sim.Connect(), nukisimtest.PairedDeviceId, store)
+	require.NoError(t, err)
+	defer flow.DisconnectDevice()
+	_, err = flow.GetStatus(context.Background())
+	require.True(t, blecommands.IsAuthError(err))
+	require.Equal(t, ExitAuth, exitCode(err))
+	require.Contains(t, errorHint(err), "pair the device again")
+}
+
+func TestExitCode(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want int
+	}{
+		{blecommands.NewDeviceError(blecommands.ErrBadPin.Code, blecommands.CommandRequestLogEntries), ExitAuth},
+		{blecommands.NewDeviceError(blecommands.ErrNotPairing.Code, blecommands.CommandPublicKey), ExitPairing},
+		{blecommands.NewDeviceError(blecommands.ErrMotorBlocked.Code, blecommands.CommandLockAction), ExitMotor},
+		{blecommands.NewDeviceError(blecommands.ErrBusy.Code, blecommands.CommandLockAction), ExitBusy},
+		{blecommands.NewDeviceError(blecommands.ErrAutoUnlockTooRecent.Code, blecommands.CommandLockAction), ExitDevice},
+		{context.DeadlineExceeded, ExitConnection},
+		{bleflows.ErrConnect, ExitConnection},
+	} {
+		require.Equal(t, tc.want, exitCode(tc.err), tc.err.Error())
+	}
+}
@@ -25,16 +25,20 @@ var RootCmd = &cobra.Command{
 	Use:              "nukictl",
 	Short:            "Command line tool to manage and control Nuki devices and online services.",
 	TraverseChildren: true,
+	// errors are printed by Execute with a hint
+	SilenceErrors: true,
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
+// On errors, it exits with the exit code for the error, see the Exit constants.
 func Execute(l *slog.LevelVar) {
 	level = l
 	err := RootCmd.Execute()
 
 	if err != nil {
-		os.Exit(1)
+		printError(err)
+		os.Exit(exitCode(err))
 	}
 }
 
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/nuki-io/nuki-cli/cmd"
+	"github.com/nuki-io/nuki-cli/pkg/nukisim"
+	"github.com/spf13/cobra"
+)
+
+var (
+	listenAddr     string
+	stateFile      string
+	simName        string
+	simPin         string
+	actionDuration time.Duration
+	pairingEnabled bool
+)
+
+// simCmd represents the sim command
+var simCmd = &cobra.Command{
+	Use:   "sim",
+	Short: "Runs a simulated Nuki Smart Lock",
+	Long: `Runs a simulated Nuki Smart Lock that speaks the Nuki BLE protocol through TCP instead of Bluetooth.
+Any ble command can be pointed at the simulator with the --sim flag, the device ID can be chosen freely.
+
+The pairing keys and authorizations of the simulator are kept in memory, unless a state file is given.
+
+The simulator does not implement authorization entries, keypad codes, time control entries and the advanced
+configuration. It answers them with the error ERROR_UNKNOWN, so the commands auth list/update, keypad,
+schedule, advanced-config and profile fail against it. It always simulates a Smart Lock, never an Opener.`,
+	Example: `  nukictl sim --state sim.json
+  nukictl ble --sim localhost:7655 -d sim authorize --pin 123456
+  nukictl ble --sim localhost:7655 -d sim unlock`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		state, err := loadState()
+		if err != nil {
+			return err
+		}
+		if simName != "" {
+			state.Name = simName
+		}
+		if simPin != "" {
+			state.Pin = simPin
+		}
+		sim := nukisim.New(state)
+		sim.SetActionDuration(actionDuration)
+		sim.SetPairingEnabled(pairingEnabled)
+		if stateFile != "" {
+			sim.OnStateChange(func(s nukisim.State) {
+				if err := saveState(s); err != nil {
+					slog.Error("Failed to save simulator state", "error", err)
+				}
+			})
+			if err := saveState(sim.State()); err != nil {
+				return err
+			}
+		}
+
+		l, err := net.Listen("tcp", listenAddr)
+		if err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", listenAddr, err)
+		}
+		go func() {
+			sigs := make(chan os.Signal, 1)
+			signal.Notify(sigs, os.Interrupt)
+			<-sigs
+			l.Close()
+		}()
+
+		state = sim.State()
+		slog.Info("Simulator listening", "address", l.Addr().String(), "name", state.Name, "nukiId", fmt.Sprintf("%X", state.NukiID), "pin", state.Pin)
+		if err := sim.Serve(l); err != nil && !errors.Is(err, net.ErrClosed) {
+			return err
+		}
+		return nil
+	},
+}
+
+func init() {
+	cmd.RootCmd.AddCommand(simCmd)
+	simCmd.Flags().StringVarP(&listenAddr, "listen", "l", "localhost:7655", "The address to listen on")
+	simCmd.Flags().StringVar(&stateFile, "state", "", "File to load the simulator state from and save it to")
+	simCmd.Flags().StringVar(&simName, "name", "", "The name of the simulated lock")
+	simCmd.Flags().StringVar(&simPin, "pin", "", fmt.Sprintf("The security PIN of the simulated lock (default %s)", nukisim.DefaultPin))
+	simCmd.Flags().DurationVar(&actionDuration, "action-duration", nukisim.DefaultActionDuration, "The time a lock action takes")
+	simCmd.Flags().BoolVar(&pairingEnabled, "pairing", true, "Whether the simulated lock accepts new pairings")
+}
+
+func loadState() (nukisim.State, error) {
+	state := nukisim.State{}
+	if stateFile == "" {
+		return state, nil
+	}
+	b, err := os.ReadFile(stateFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return state, fmt.Errorf("failed to read simulator state: %w", err)
+	}
+	if err := json.Unmarshal(b, &state); err != nil {
+		return state, fmt.Errorf("failed to parse simulator state %s: %w", stateFile, err)
+	}
+	return state, nil
+}
+
+func saveState(state nukisim.State) error {
+	b, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(stateFile, b, 0o600); err != nil {
+		return fmt.Errorf("failed to write simulator state: %w", err)
+	}
+	return nil
+}
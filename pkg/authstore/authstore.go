@@ -0,0 +1,66 @@
+// Package authstore contains stores for the authorizations of paired devices that keep the keys and the security PIN
+// out of the config file: an encrypted file and the keyring of the operating system.
+package authstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+)
+
+// ErrNotFound is returned when no authorization is stored for a device.
+var ErrNotFound = errors.New("no authorization found")
+
+func notFound(deviceId string) error {
+	return fmt.Errorf("%w for device with id %s", ErrNotFound, deviceId)
+}
+
+func marshal(ctx *bleflows.AuthorizeContext) ([]byte, error) {
+	return json.Marshal(ctx)
+}
+
+func unmarshal(b []byte) (*bleflows.AuthorizeContext, error) {
+	ctx := &bleflows.AuthorizeContext{}
+	if err := json.Unmarshal(b, ctx); err != nil {
+		return nil, fmt.Errorf("malformed authorization: %w", err)
+	}
+	return ctx, nil
+}
+
+// Copy copies the authorizations of the given devices from one store to another and verifies that they read back
+// unchanged. The authorizations are not deleted from the source.
+func Copy(from, to bleflows.AuthStore, deviceIds []string) error {
+	for _, id := range deviceIds {
+		ctx, err := from.Load(id)
+		if err != nil {
+			return fmt.Errorf("failed to load authorization of %s: %w", id, err)
+		}
+		if err := to.Store(id, ctx); err != nil {
+			return fmt.Errorf("failed to store authorization of %s: %w", id, err)
+		}
+		stored, err := to.Load(id)
+		if err != nil {
+			return fmt.Errorf("failed to verify authorization of %s: %w", id, err)
+		}
+		if !Equal(ctx, stored) {
+			return fmt.Errorf("failed to verify authorization of %s: stored authorization differs", id)
+		}
+	}
+	return nil
+}
+
+// Equal reports whether two authorizations are the same. Missing and empty keys are equal.
+func Equal(a, b *bleflows.AuthorizeContext) bool {
+	return bytes.Equal(a.CliPublicKey, b.CliPublicKey) &&
+		bytes.Equal(a.CliPrivateKey, b.CliPrivateKey) &&
+		bytes.Equal(a.SlPublicKey, b.SlPublicKey) &&
+		bytes.Equal(a.SharedKey, b.SharedKey) &&
+		bytes.Equal(a.AuthId, b.AuthId) &&
+		bytes.Equal(a.AppId, b.AppId) &&
+		a.NukiId == b.NukiId &&
+		a.Pin == b.Pin &&
+		a.Name == b.Name
+}
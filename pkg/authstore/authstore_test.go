@@ -0,0 +1,102 @@
+package authstore_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/nuki-io/nuki-cli/pkg/authstore"
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+	"github.com/stretchr/testify/require"
+)
+
+func testAuthorization(name string) *bleflows.AuthorizeContext {
+	return &bleflows.AuthorizeContext{
+		CliPublicKey:  []byte{1, 2, 3},
+		CliPrivateKey: []byte{4, 5, 6},
+		SlPublicKey:   []byte{7, 8, 9},
+		SharedKey:     []byte{10, 11, 12},
+		AuthId:        []byte{2, 0, 0, 0},
+		AppId:         []byte{0xde, 0xad, 0xbe, 0xef},
+		NukiId:        0x2a3b4c5d,
+		Pin:           "123456",
+		Name:          name,
+	}
+}
+
+// testStore stores, lists, loads and deletes authorizations in s.
+func testStore(t *testing.T, s bleflows.AuthStore) {
+	t.Helper()
+	_, err := s.Load("54FD3A2B")
+	require.ErrorIs(t, err, authstore.ErrNotFound)
+
+	require.NoError(t, s.Store("54FD3A2B", testAuthorization("Front Door")))
+	require.NoError(t, s.Store("11aa22bb", testAuthorization("Back Door")))
+	ids, err := s.List()
+	require.NoError(t, err)
+	require.Equal(t, []string{"11aa22bb", "54fd3a2b"}, ids)
+
+	ctx, err := s.Load("54fd3a2b")
+	require.NoError(t, err)
+	require.Equal(t, testAuthorization("Front Door"), ctx)
+
+	require.NoError(t, s.Delete("54FD3A2B"))
+	require.NoError(t, s.Delete("54FD3A2B"))
+	ids, err = s.List()
+	require.NoError(t, err)
+	require.Equal(t, []string{"11aa22bb"}, ids)
+}
+
+func TestFileStore(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "auth.age")
+	testStore(t, authstore.NewFileStore(path, identity, identity.Recipient()))
+
+	// a new store reads the file written before
+	ctx, err := authstore.NewFileStore(path, identity, identity.Recipient()).Load("11aa22bb")
+	require.NoError(t, err)
+	require.Equal(t, "Back Door", ctx.Name)
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NotContains(t, string(b), "Back Door")
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	other, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	_, err = authstore.NewFileStore(path, other, other.Recipient()).Load("11aa22bb")
+	require.ErrorContains(t, err, "failed to decrypt auth store")
+}
+
+func TestPassphraseFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "auth.age")
+	s, err := authstore.NewPassphraseFileStore(path, "correct horse")
+	require.NoError(t, err)
+	require.NoError(t, s.Store("54fd3a2b", testAuthorization("Front Door")))
+
+	s, err = authstore.NewPassphraseFileStore(path, "wrong")
+	require.NoError(t, err)
+	_, err = s.Load("54fd3a2b")
+	require.Error(t, err)
+}
+
+func TestKeyringStore(t *testing.T) {
+	testStore(t, authstore.NewKeyringStore(authstore.NewFileKeyring(filepath.Join(t.TempDir(), "keyring.json"))))
+}
+
+func TestCopy(t *testing.T) {
+	from := authstore.NewKeyringStore(authstore.NewFileKeyring(filepath.Join(t.TempDir(), "from.json")))
+	to := authstore.NewKeyringStore(authstore.NewFileKeyring(filepath.Join(t.TempDir(), "to.json")))
+	require.NoError(t, from.Store("54fd3a2b", testAuthorization("Front Door")))
+
+	require.NoError(t, authstore.Copy(from, to, []string{"54fd3a2b"}))
+	ctx, err := to.Load("54fd3a2b")
+	require.NoError(t, err)
+	require.True(t, authstore.Equal(testAuthorization("Front Door"), ctx))
+
+	require.ErrorIs(t, authstore.Copy(from, to, []string{"11aa22bb"}), authstore.ErrNotFound)
+}
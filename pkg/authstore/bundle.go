@@ -0,0 +1,155 @@
+package authstore
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+)
+
+// BundleVersion is the version of the bundles written by WriteBundle.
+const BundleVersion = 1
+
+var (
+	// ErrBundleVersion is returned for bundles written by a newer version.
+	ErrBundleVersion = errors.New("unsupported bundle version")
+	// ErrBundleIntegrity is returned if the checksum of a bundle does not match its pairings.
+	ErrBundleIntegrity = errors.New("bundle checksum mismatch")
+)
+
+// Bundle holds exported pairings, so that another machine can use them without pairing the devices again.
+type Bundle struct {
+	Version  int       `json:"version"`
+	Created  time.Time `json:"created"`
+	Pairings []Pairing `json:"pairings"`
+	// Checksum is the hex encoded SHA-256 of the JSON encoded pairings.
+	Checksum string `json:"checksum"`
+}
+
+// Pairing is the authorization of a device.
+type Pairing struct {
+	DeviceId      string                     `json:"deviceId"`
+	Authorization *bleflows.AuthorizeContext `json:"authorization"`
+}
+
+// NewBundle returns a bundle of the given pairings.
+func NewBundle(pairings []Pairing) (*Bundle, error) {
+	b := &Bundle{Version: BundleVersion, Created: time.Now().UTC(), Pairings: pairings}
+	sum, err := b.checksum()
+	if err != nil {
+		return nil, err
+	}
+	b.Checksum = sum
+	return b, nil
+}
+
+func (b *Bundle) checksum() (string, error) {
+	p, err := json.Marshal(b.Pairings)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(p)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Verify checks the version and the checksum of the bundle and that each pairing has the keys and IDs required to
+// connect to the device.
+func (b *Bundle) Verify() error {
+	if b.Version < 1 || b.Version > BundleVersion {
+		return fmt.Errorf("%w %d, the latest supported version is %d", ErrBundleVersion, b.Version, BundleVersion)
+	}
+	sum, err := b.checksum()
+	if err != nil {
+		return err
+	}
+	if sum != b.Checksum {
+		return ErrBundleIntegrity
+	}
+	for i, p := range b.Pairings {
+		if p.DeviceId == "" {
+			return fmt.Errorf("pairing %d has no device ID", i+1)
+		}
+		if err := Validate(p.Authorization); err != nil {
+			return fmt.Errorf("pairing of %s: %w", p.DeviceId, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks that an authorization has the keys and IDs required to connect to the device.
+func Validate(ctx *bleflows.AuthorizeContext) error {
+	switch {
+	case ctx == nil:
+		return fmt.Errorf("missing authorization")
+	case len(ctx.SharedKey) != 32:
+		return fmt.Errorf("invalid shared key: expected 32 bytes, got %d", len(ctx.SharedKey))
+	case len(ctx.AuthId) != 4:
+		return fmt.Errorf("invalid auth ID: expected 4 bytes, got %d", len(ctx.AuthId))
+	case len(ctx.AppId) != 4:
+		return fmt.Errorf("invalid app ID: expected 4 bytes, got %d", len(ctx.AppId))
+	}
+	return nil
+}
+
+// WriteBundle encrypts the bundle with a key derived from passphrase and writes it to w, ASCII armored.
+func WriteBundle(w io.Writer, b *Bundle, passphrase string) error {
+	recipient, err := age.NewScryptRecipient(passphrase)
+	if err != nil {
+		return err
+	}
+	p, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+	a := armor.NewWriter(w)
+	e, err := age.Encrypt(a, recipient)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt bundle: %w", err)
+	}
+	if _, err := e.Write(p); err != nil {
+		return fmt.Errorf("failed to encrypt bundle: %w", err)
+	}
+	if err := e.Close(); err != nil {
+		return fmt.Errorf("failed to encrypt bundle: %w", err)
+	}
+	return a.Close()
+}
+
+// ReadBundle decrypts a bundle written by WriteBundle and verifies it. Bundles without armor are accepted as well.
+func ReadBundle(r io.Reader, passphrase string) (*Bundle, error) {
+	identity, err := age.NewScryptIdentity(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(r)
+	if start, _ := br.Peek(len(armor.Header)); bytes.Equal(start, []byte(armor.Header)) {
+		r = armor.NewReader(br)
+	} else {
+		r = br
+	}
+	d, err := age.Decrypt(r, identity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt bundle: %w", err)
+	}
+	p, err := io.ReadAll(d)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt bundle: %w", err)
+	}
+	b := &Bundle{}
+	if err := json.Unmarshal(p, b); err != nil {
+		return nil, fmt.Errorf("malformed bundle: %w", err)
+	}
+	if err := b.Verify(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
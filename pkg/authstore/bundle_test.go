@@ -0,0 +1,54 @@
+package authstore_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/nuki-io/nuki-cli/pkg/authstore"
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+	"github.com/stretchr/testify/require"
+)
+
+func validAuthorization() *bleflows.AuthorizeContext {
+	ctx := testAuthorization("Front Door")
+	ctx.SharedKey = bytes.Repeat([]byte{0xab}, 32)
+	return ctx
+}
+
+func TestBundle(t *testing.T) {
+	b, err := authstore.NewBundle([]authstore.Pairing{{DeviceId: "54fd3a2b", Authorization: validAuthorization()}})
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, authstore.WriteBundle(&buf, b, "correct horse"))
+	require.True(t, strings.HasPrefix(buf.String(), "-----BEGIN AGE ENCRYPTED FILE-----"))
+	require.NotContains(t, buf.String(), "Front Door")
+
+	read, err := authstore.ReadBundle(bytes.NewReader(buf.Bytes()), "correct horse")
+	require.NoError(t, err)
+	require.Equal(t, authstore.BundleVersion, read.Version)
+	require.Len(t, read.Pairings, 1)
+	require.Equal(t, "54fd3a2b", read.Pairings[0].DeviceId)
+	require.Equal(t, validAuthorization(), read.Pairings[0].Authorization)
+
+	_, err = authstore.ReadBundle(bytes.NewReader(buf.Bytes()), "wrong")
+	require.ErrorContains(t, err, "failed to decrypt bundle")
+}
+
+func TestBundleVerify(t *testing.T) {
+	b, err := authstore.NewBundle([]authstore.Pairing{{DeviceId: "54fd3a2b", Authorization: validAuthorization()}})
+	require.NoError(t, err)
+	require.NoError(t, b.Verify())
+
+	b.Pairings[0].Authorization.Pin = "654321"
+	require.ErrorIs(t, b.Verify(), authstore.ErrBundleIntegrity)
+
+	b.Version = authstore.BundleVersion + 1
+	require.ErrorIs(t, b.Verify(), authstore.ErrBundleVersion)
+
+	invalid := validAuthorization()
+	invalid.SharedKey = invalid.SharedKey[:16]
+	b, err = authstore.NewBundle([]authstore.Pairing{{DeviceId: "54fd3a2b", Authorization: invalid}})
+	require.NoError(t, err)
+	require.ErrorContains(t, b.Verify(), "pairing of 54fd3a2b: invalid shared key")
+}
@@ -0,0 +1,182 @@
+package authstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"filippo.io/age"
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+)
+
+var _ bleflows.AuthStore = &FileStore{}
+
+// FileStore keeps all authorizations in a single file encrypted with age. The file is decrypted once and
+// written again on every change. Device IDs are case-insensitive, like in the config file.
+type FileStore struct {
+	path      string
+	identity  age.Identity
+	recipient age.Recipient
+
+	mu sync.Mutex
+	// entries are nil until the file was read
+	entries map[string]*bleflows.AuthorizeContext
+}
+
+// NewFileStore returns a store in the file at path, which is encrypted to recipient and decrypted with identity.
+func NewFileStore(path string, identity age.Identity, recipient age.Recipient) *FileStore {
+	return &FileStore{path: path, identity: identity, recipient: recipient}
+}
+
+// NewPassphraseFileStore returns a store in the file at path, which is encrypted with a key derived from passphrase.
+func NewPassphraseFileStore(path, passphrase string) (*FileStore, error) {
+	identity, err := age.NewScryptIdentity(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	recipient, err := age.NewScryptRecipient(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return NewFileStore(path, identity, recipient), nil
+}
+
+// NewIdentityFileStore returns a store in the file at path, which is encrypted to the X25519 identity in the
+// age key file at identityPath, as created by age-keygen.
+func NewIdentityFileStore(path, identityPath string) (*FileStore, error) {
+	f, err := os.Open(identityPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open identity: %w", err)
+	}
+	defer f.Close()
+	identities, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse identity %s: %w", identityPath, err)
+	}
+	identity, ok := identities[0].(*age.X25519Identity)
+	if !ok {
+		return nil, fmt.Errorf("identity %s is not an X25519 identity", identityPath)
+	}
+	return NewFileStore(path, identity, identity.Recipient()), nil
+}
+
+func (s *FileStore) Load(deviceId string) (*bleflows.AuthorizeContext, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.read(); err != nil {
+		return nil, err
+	}
+	ctx, ok := s.entries[strings.ToLower(deviceId)]
+	if !ok {
+		return nil, notFound(deviceId)
+	}
+	c := *ctx
+	return &c, nil
+}
+
+func (s *FileStore) Store(deviceId string, ctx *bleflows.AuthorizeContext) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.read(); err != nil {
+		return err
+	}
+	c := *ctx
+	s.entries[strings.ToLower(deviceId)] = &c
+	return s.write()
+}
+
+func (s *FileStore) List() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.read(); err != nil {
+		return nil, err
+	}
+	return slices.Sorted(maps.Keys(s.entries)), nil
+}
+
+func (s *FileStore) Delete(deviceId string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.read(); err != nil {
+		return err
+	}
+	if _, ok := s.entries[strings.ToLower(deviceId)]; !ok {
+		return nil
+	}
+	delete(s.entries, strings.ToLower(deviceId))
+	return s.write()
+}
+
+// read decrypts the file, unless it was read before. A missing file is an empty store.
+func (s *FileStore) read() error {
+	if s.entries != nil {
+		return nil
+	}
+	f, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		s.entries = map[string]*bleflows.AuthorizeContext{}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open auth store: %w", err)
+	}
+	defer f.Close()
+	r, err := age.Decrypt(f, s.identity)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt auth store %s: %w", s.path, err)
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt auth store %s: %w", s.path, err)
+	}
+	entries := map[string]*bleflows.AuthorizeContext{}
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return fmt.Errorf("malformed auth store %s: %w", s.path, err)
+	}
+	s.entries = entries
+	return nil
+}
+
+// write encrypts the entries to a temporary file, which replaces the file, so that it is never left half written.
+func (s *FileStore) write() error {
+	b, err := json.Marshal(s.entries)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, s.recipient)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt auth store: %w", err)
+	}
+	if _, err := w.Write(b); err != nil {
+		return fmt.Errorf("failed to encrypt auth store: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to encrypt auth store: %w", err)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write auth store: %w", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write auth store: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write auth store: %w", err)
+	}
+	if err := os.Rename(f.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write auth store: %w", err)
+	}
+	return nil
+}
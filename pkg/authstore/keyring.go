@@ -0,0 +1,223 @@
+package authstore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+	"github.com/zalando/go-keyring"
+)
+
+// DefaultService is the service the authorizations are stored under in the keyring.
+const DefaultService = "nukictl"
+
+const (
+	deviceUserPrefix = "device:"
+	// indexUser holds the IDs of the stored devices, since keyrings cannot list their secrets
+	indexUser = "devices"
+)
+
+// Keyring stores secrets by service and user name, like the keyring of the operating system.
+// Get returns ErrNotFound for missing secrets.
+type Keyring interface {
+	Get(service, user string) (string, error)
+	Set(service, user, secret string) error
+	Delete(service, user string) error
+}
+
+// SystemKeyring is the keyring of the operating system: the Secret Service on Linux, the Keychain on macOS
+// and the Credential Manager on Windows.
+type SystemKeyring struct{}
+
+func (SystemKeyring) Get(service, user string) (string, error) {
+	secret, err := keyring.Get(service, user)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", ErrNotFound
+	}
+	return secret, err
+}
+
+func (SystemKeyring) Set(service, user, secret string) error {
+	return keyring.Set(service, user, secret)
+}
+
+func (SystemKeyring) Delete(service, user string) error {
+	err := keyring.Delete(service, user)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// FileKeyring is a stand-in for the keyring of the operating system on machines without one, e.g. servers without
+// a Secret Service. The secrets are kept unencrypted in a file only readable by the user.
+type FileKeyring struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileKeyring returns a keyring in the file at path.
+func NewFileKeyring(path string) *FileKeyring {
+	return &FileKeyring{path: path}
+}
+
+func (k *FileKeyring) Get(service, user string) (string, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	secrets, err := k.read()
+	if err != nil {
+		return "", err
+	}
+	secret, ok := secrets[service][user]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return secret, nil
+}
+
+func (k *FileKeyring) Set(service, user, secret string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	secrets, err := k.read()
+	if err != nil {
+		return err
+	}
+	if secrets[service] == nil {
+		secrets[service] = map[string]string{}
+	}
+	secrets[service][user] = secret
+	return k.write(secrets)
+}
+
+func (k *FileKeyring) Delete(service, user string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	secrets, err := k.read()
+	if err != nil {
+		return err
+	}
+	delete(secrets[service], user)
+	return k.write(secrets)
+}
+
+func (k *FileKeyring) read() (map[string]map[string]string, error) {
+	secrets := map[string]map[string]string{}
+	b, err := os.ReadFile(k.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return secrets, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keyring: %w", err)
+	}
+	if err := json.Unmarshal(b, &secrets); err != nil {
+		return nil, fmt.Errorf("malformed keyring %s: %w", k.path, err)
+	}
+	return secrets, nil
+}
+
+func (k *FileKeyring) write(secrets map[string]map[string]string) error {
+	b, err := json.MarshalIndent(secrets, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(k.path, b, 0o600); err != nil {
+		return fmt.Errorf("failed to write keyring: %w", err)
+	}
+	return nil
+}
+
+var _ bleflows.AuthStore = &KeyringStore{}
+
+// KeyringStore keeps every authorization as a secret in a keyring.
+// Device IDs are case-insensitive, like in the config file.
+type KeyringStore struct {
+	keyring Keyring
+	service string
+	// mu serializes the updates of the index
+	mu sync.Mutex
+}
+
+// NewKeyringStore returns a store in keyring under DefaultService.
+func NewKeyringStore(keyring Keyring) *KeyringStore {
+	return &KeyringStore{keyring: keyring, service: DefaultService}
+}
+
+func (s *KeyringStore) Load(deviceId string) (*bleflows.AuthorizeContext, error) {
+	secret, err := s.keyring.Get(s.service, deviceUserPrefix+strings.ToLower(deviceId))
+	if errors.Is(err, ErrNotFound) {
+		return nil, notFound(deviceId)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read authorization from keyring: %w", err)
+	}
+	return unmarshal([]byte(secret))
+}
+
+func (s *KeyringStore) Store(deviceId string, ctx *bleflows.AuthorizeContext) error {
+	b, err := marshal(ctx)
+	if err != nil {
+		return err
+	}
+	if err := s.keyring.Set(s.service, deviceUserPrefix+strings.ToLower(deviceId), string(b)); err != nil {
+		return fmt.Errorf("failed to store authorization in keyring: %w", err)
+	}
+	return s.updateIndex(func(ids []string) []string {
+		if !slices.Contains(ids, strings.ToLower(deviceId)) {
+			ids = append(ids, strings.ToLower(deviceId))
+		}
+		return ids
+	})
+}
+
+func (s *KeyringStore) List() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.index()
+}
+
+func (s *KeyringStore) Delete(deviceId string) error {
+	if err := s.keyring.Delete(s.service, deviceUserPrefix+strings.ToLower(deviceId)); err != nil {
+		return fmt.Errorf("failed to delete authorization from keyring: %w", err)
+	}
+	return s.updateIndex(func(ids []string) []string {
+		return slices.DeleteFunc(ids, func(id string) bool { return strings.EqualFold(id, deviceId) })
+	})
+}
+
+func (s *KeyringStore) index() ([]string, error) {
+	secret, err := s.keyring.Get(s.service, indexUser)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keyring: %w", err)
+	}
+	var ids []string
+	if err := json.Unmarshal([]byte(secret), &ids); err != nil {
+		return nil, fmt.Errorf("malformed device index in keyring: %w", err)
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+func (s *KeyringStore) updateIndex(fn func(ids []string) []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids, err := s.index()
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(fn(ids))
+	if err != nil {
+		return err
+	}
+	if err := s.keyring.Set(s.service, indexUser, string(b)); err != nil {
+		return fmt.Errorf("failed to store device index in keyring: %w", err)
+	}
+	return nil
+}
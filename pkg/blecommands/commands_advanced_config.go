@@ -0,0 +1,195 @@
+package blecommands
+
+import (
+	"encoding/binary"
+	"fmt"
+	"slices"
+)
+
+//go:generate stringer -type=ButtonPressAction -trimprefix=ButtonPressAction
+type ButtonPressAction uint8
+
+const (
+	ButtonPressActionNoAction    ButtonPressAction = 0x00
+	ButtonPressActionIntelligent ButtonPressAction = 0x01
+	ButtonPressActionUnlock      ButtonPressAction = 0x02
+	ButtonPressActionLock        ButtonPressAction = 0x03
+	ButtonPressActionUnlatch     ButtonPressAction = 0x04
+	ButtonPressActionLockNGo     ButtonPressAction = 0x05
+	ButtonPressActionShowStatus  ButtonPressAction = 0x06
+)
+
+func (a ButtonPressAction) MarshalText() ([]byte, error) { return []byte(a.String()), nil }
+func (a *ButtonPressAction) UnmarshalText(b []byte) error {
+	for v := ButtonPressActionNoAction; v <= ButtonPressActionShowStatus; v++ {
+		if v.String() == string(b) {
+			*a = v
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid button press action %q", b)
+}
+
+//go:generate stringer -type=BatteryType -trimprefix=BatteryType
+type BatteryType uint8
+
+const (
+	BatteryTypeAlkali       BatteryType = 0x00
+	BatteryTypeAccumulators BatteryType = 0x01
+	BatteryTypeLithium      BatteryType = 0x02
+)
+
+func (t BatteryType) MarshalText() ([]byte, error) { return []byte(t.String()), nil }
+func (t *BatteryType) UnmarshalText(b []byte) error {
+	for v := BatteryTypeAlkali; v <= BatteryTypeLithium; v++ {
+		if v.String() == string(b) {
+			*t = v
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid battery type %q", b)
+}
+
+var _ Response = &AdvancedConfig{}
+
+// AdvancedConfig Command 0x0037
+type AdvancedConfig struct {
+	TotalDegrees                            uint16            `json:"totalDegrees"`
+	UnlockedPositionOffsetDegrees           int16             `json:"unlockedPositionOffsetDegrees"`
+	LockedPositionOffsetDegrees             int16             `json:"lockedPositionOffsetDegrees"`
+	SingleLockedPositionOffsetDegrees       int16             `json:"singleLockedPositionOffsetDegrees"`
+	UnlockedToLockedTransitionOffsetDegrees int16             `json:"unlockedToLockedTransitionOffsetDegrees"`
+	LockNGoTimeout                          uint8             `json:"lockNGoTimeout"`
+	SingleButtonPressAction                 ButtonPressAction `json:"singleButtonPressAction"`
+	DoubleButtonPressAction                 ButtonPressAction `json:"doubleButtonPressAction"`
+	DetachedCylinder                        bool              `json:"detachedCylinder"`
+	BatteryType                             BatteryType       `json:"batteryType"`
+	AutomaticBatteryTypeDetection           bool              `json:"automaticBatteryTypeDetection"`
+	UnlatchDuration                         uint8             `json:"unlatchDuration"`
+	AutoLockTimeout                         uint16            `json:"autoLockTimeout"`
+	AutoUnlockDisabled                      bool              `json:"autoUnlockDisabled"`
+	NightmodeEnabled                        bool              `json:"nightmodeEnabled"`
+	NightmodeStartTime                      TimeOfDay         `json:"nightmodeStartTime"`
+	NightmodeEndTime                        TimeOfDay         `json:"nightmodeEndTime"`
+	NightmodeAutoLockEnabled                bool              `json:"nightmodeAutoLockEnabled"`
+	NightmodeAutoUnlockDisabled             bool              `json:"nightmodeAutoUnlockDisabled"`
+	NightmodeImmediateLockOnStart           bool              `json:"nightmodeImmediateLockOnStart"`
+	AutoLockEnabled                         bool              `json:"autoLockEnabled"`
+	ImmediateAutoLockEnabled                bool              `json:"immediateAutoLockEnabled"`
+	AutoUpdateEnabled                       bool              `json:"autoUpdateEnabled"`
+	MotorSpeed                              uint8             `json:"motorSpeed"`
+	EnableSlowSpeedDuringNightmode          bool              `json:"enableSlowSpeedDuringNightmode"`
+
+	// hasMotorSpeed is set if the device reported the motor speed settings, which only newer firmwares do.
+	// It determines whether they are sent back with SetAdvancedConfig.
+	hasMotorSpeed bool
+}
+
+func (c *AdvancedConfig) GetCommandCode() CommandCode {
+	return CommandAdvancedConfig
+}
+
+func (c *AdvancedConfig) FromMessage(b []byte) error {
+	if len(b) < 31 {
+		return fmt.Errorf("advanced config length must be at least 31 bytes, got: %d", len(b))
+	}
+	c.TotalDegrees = binary.LittleEndian.Uint16(b[0:2])
+	c.UnlockedPositionOffsetDegrees = int16(binary.LittleEndian.Uint16(b[2:4]))
+	c.LockedPositionOffsetDegrees = int16(binary.LittleEndian.Uint16(b[4:6]))
+	c.SingleLockedPositionOffsetDegrees = int16(binary.LittleEndian.Uint16(b[6:8]))
+	c.UnlockedToLockedTransitionOffsetDegrees = int16(binary.LittleEndian.Uint16(b[8:10]))
+	c.LockNGoTimeout = b[10]
+	c.SingleButtonPressAction = ButtonPressAction(b[11])
+	c.DoubleButtonPressAction = ButtonPressAction(b[12])
+	c.DetachedCylinder = byteToBool(b[13])
+	c.BatteryType = BatteryType(b[14])
+	c.AutomaticBatteryTypeDetection = byteToBool(b[15])
+	c.UnlatchDuration = b[16]
+	c.AutoLockTimeout = binary.LittleEndian.Uint16(b[17:19])
+	c.AutoUnlockDisabled = byteToBool(b[19])
+	c.NightmodeEnabled = byteToBool(b[20])
+	c.NightmodeStartTime = newTimeOfDay(b[21:23])
+	c.NightmodeEndTime = newTimeOfDay(b[23:25])
+	c.NightmodeAutoLockEnabled = byteToBool(b[25])
+	c.NightmodeAutoUnlockDisabled = byteToBool(b[26])
+	c.NightmodeImmediateLockOnStart = byteToBool(b[27])
+	c.AutoLockEnabled = byteToBool(b[28])
+	c.ImmediateAutoLockEnabled = byteToBool(b[29])
+	c.AutoUpdateEnabled = byteToBool(b[30])
+	c.hasMotorSpeed = len(b) >= 33
+	if c.hasMotorSpeed {
+		c.MotorSpeed = b[31]
+		c.EnableSlowSpeedDuringNightmode = byteToBool(b[32])
+	}
+	return nil
+}
+
+var _ Request = &RequestAdvancedConfig{}
+
+// RequestAdvancedConfig Command 0x0036
+type RequestAdvancedConfig struct {
+	Nonce []byte
+}
+
+func (c *RequestAdvancedConfig) GetCommandCode() CommandCode {
+	return CommandRequestAdvancedConfig
+}
+
+func (c *RequestAdvancedConfig) GetPayload() []byte {
+	return c.Nonce
+}
+
+var _ Request = &SetAdvancedConfig{}
+
+// SetAdvancedConfig Command 0x0035
+// All fields of Config except TotalDegrees are written.
+type SetAdvancedConfig struct {
+	Config      *AdvancedConfig
+	Nonce       []byte
+	SecurityPin Pin
+}
+
+func (c *SetAdvancedConfig) GetCommandCode() CommandCode {
+	return CommandSetAdvancedConfig
+}
+
+func (c *SetAdvancedConfig) GetPayload() []byte {
+	cfg := c.Config
+	var motorSpeed []byte
+	if cfg.hasMotorSpeed {
+		motorSpeed = []byte{cfg.MotorSpeed, boolToByte(cfg.EnableSlowSpeedDuringNightmode)}
+	}
+	return slices.Concat(
+		binary.LittleEndian.AppendUint16(nil, uint16(cfg.UnlockedPositionOffsetDegrees)),
+		binary.LittleEndian.AppendUint16(nil, uint16(cfg.LockedPositionOffsetDegrees)),
+		binary.LittleEndian.AppendUint16(nil, uint16(cfg.SingleLockedPositionOffsetDegrees)),
+		binary.LittleEndian.AppendUint16(nil, uint16(cfg.UnlockedToLockedTransitionOffsetDegrees)),
+		[]byte{
+			cfg.LockNGoTimeout,
+			byte(cfg.SingleButtonPressAction),
+			byte(cfg.DoubleButtonPressAction),
+			boolToByte(cfg.DetachedCylinder),
+			byte(cfg.BatteryType),
+			boolToByte(cfg.AutomaticBatteryTypeDetection),
+			cfg.UnlatchDuration,
+		},
+		binary.LittleEndian.AppendUint16(nil, cfg.AutoLockTimeout),
+		[]byte{
+			boolToByte(cfg.AutoUnlockDisabled),
+			boolToByte(cfg.NightmodeEnabled),
+		},
+		cfg.NightmodeStartTime.bytes(),
+		cfg.NightmodeEndTime.bytes(),
+		[]byte{
+			boolToByte(cfg.NightmodeAutoLockEnabled),
+			boolToByte(cfg.NightmodeAutoUnlockDisabled),
+			boolToByte(cfg.NightmodeImmediateLockOnStart),
+			boolToByte(cfg.AutoLockEnabled),
+			boolToByte(cfg.ImmediateAutoLockEnabled),
+			boolToByte(cfg.AutoUpdateEnabled),
+		},
+		motorSpeed,
+		c.Nonce,
+		c.SecurityPin.GetPinBytes(),
+	)
+}
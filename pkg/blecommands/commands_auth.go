@@ -45,8 +45,11 @@ func (c *AuthorizationAuthenticator) GetPayload() []byte {
 	return c.Authenticator
 }
 
+//go:generate stringer -type=AuthorizationType -trimprefix=AuthorizationType
 type AuthorizationType uint8
 
+func (t AuthorizationType) MarshalText() ([]byte, error) { return []byte(t.String()), nil }
+
 const (
 	AuthorizationTypeApp    AuthorizationType = 0x00 // App
 	AuthorizationTypeBridge AuthorizationType = 0x01 // Bridge
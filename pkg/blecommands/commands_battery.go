@@ -0,0 +1,44 @@
+package blecommands
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+var _ Response = &BatteryReport{}
+
+// BatteryReport Command 0x0011
+// Requested with RequestData. Voltages are given in mV, the drain of the last lock action in mWs.
+type BatteryReport struct {
+	BatteryDrain         uint16 `json:"batteryDrain"`
+	BatteryVoltage       uint16 `json:"batteryVoltage"`
+	CriticalBatteryState bool   `json:"criticalBatteryState"`
+	LockAction           Action `json:"lockAction"`
+	StartVoltage         uint16 `json:"startVoltage"`
+	LowestVoltage        uint16 `json:"lowestVoltage"`
+	LockDistance         uint16 `json:"lockDistance"`      // degrees
+	StartTemperature     int8   `json:"startTemperature"`  // °C
+	MaxTurnCurrent       uint16 `json:"maxTurnCurrent"`    // mA
+	BatteryResistance    uint16 `json:"batteryResistance"` // mOhm
+}
+
+func (c *BatteryReport) GetCommandCode() CommandCode {
+	return CommandBatteryReport
+}
+
+func (c *BatteryReport) FromMessage(b []byte) error {
+	if len(b) < 17 {
+		return fmt.Errorf("battery report length must be at least 17 bytes, got: %d", len(b))
+	}
+	c.BatteryDrain = binary.LittleEndian.Uint16(b[0:2])
+	c.BatteryVoltage = binary.LittleEndian.Uint16(b[2:4])
+	c.CriticalBatteryState = byteToBool(b[4])
+	c.LockAction = Action(b[5])
+	c.StartVoltage = binary.LittleEndian.Uint16(b[6:8])
+	c.LowestVoltage = binary.LittleEndian.Uint16(b[8:10])
+	c.LockDistance = binary.LittleEndian.Uint16(b[10:12])
+	c.StartTemperature = int8(b[12])
+	c.MaxTurnCurrent = binary.LittleEndian.Uint16(b[13:15])
+	c.BatteryResistance = binary.LittleEndian.Uint16(b[15:17])
+	return nil
+}
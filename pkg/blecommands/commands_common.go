@@ -82,7 +82,7 @@ func (c *ErrorReport) FromMessage(b []byte) error {
 		return fmt.Errorf("error report length must be exactly 3 bytes, got: %d", len(b))
 	}
 	c.ErrorCode = b[0]
-	c.Error = errorCodeNames[c.ErrorCode]
+	c.Error = errorCodeName(c.ErrorCode)
 	c.CommandIdentifier = CommandCode(binary.LittleEndian.Uint16(b[1:]))
 	return nil
 }
@@ -5,40 +5,54 @@ import (
 	"encoding/binary"
 	"fmt"
 	"math"
+	"slices"
 	"time"
 )
 
+//go:generate stringer -type=DeviceType -trimprefix=DeviceType
+type DeviceType uint8
+
+func (t DeviceType) MarshalText() ([]byte, error) { return []byte(t.String()), nil }
+
+const (
+	DeviceTypeSmartLock      DeviceType = 0x00 // Smart Lock 1.0 and 2.0
+	DeviceTypeOpener         DeviceType = 0x02
+	DeviceTypeSmartDoor      DeviceType = 0x03
+	DeviceTypeSmartLock3     DeviceType = 0x04 // Smart Lock 3.0 and newer
+	DeviceTypeSmartLockUltra DeviceType = 0x05
+)
+
 var _ Response = &Config{}
 
 // Config Command 0x0015
 type Config struct {
-	NukiID           uint32    `json:"nukiId"`
-	Name             string    `json:"name"`
-	Latitude         float32   `json:"latitude"`
-	Longitude        float32   `json:"longitude"`
-	AutoUnlatch      bool      `json:"autoUnlatch"`
-	PairingEnabled   bool      `json:"pairingEnabled"`
-	ButtonEnabled    bool      `json:"buttonEnabled"`
-	LedEnabled       bool      `json:"ledEnabled"`
-	LedBrightness    uint8     `json:"ledBrightness"`
-	CurrentTime      time.Time `json:"currentTime"`
-	TimezoneOffset   int16     `json:"timezoneOffset"`
-	DstMode          uint8     `json:"dstMode"`
-	HasFob           bool      `json:"hasFob"`
-	FobAction1       uint8     `json:"fobAction1"`
-	FobAction2       uint8     `json:"fobAction2"`
-	FobAction3       uint8     `json:"fobAction3"`
-	SingleLock       bool      `json:"singleLock"`
-	AdvertisingMode  uint8     `json:"advertisingMode"`
-	HasKeypad        bool      `json:"hasKeypad"`
-	FirmwareVersion  string    `json:"firmwareVersion"`
-	HardwareRevision string    `json:"hardwareRevision"`
-	HomeKitStatus    uint8     `json:"homeKitStatus"`
-	TimezoneID       uint16    `json:"timezoneId"`
-	DeviceType       uint8     `json:"deviceType"`
-	Capabilities     uint8     `json:"capabilities"`
-	HasKeypad2       bool      `json:"hasKeypad2"`
-	MatterStatus     uint8     `json:"matterStatus"`
+	NukiID           uint32     `json:"nukiId"`
+	Name             string     `json:"name"`
+	Latitude         float32    `json:"latitude"`
+	Longitude        float32    `json:"longitude"`
+	AutoUnlatch      bool       `json:"autoUnlatch"`
+	PairingEnabled   bool       `json:"pairingEnabled"`
+	ButtonEnabled    bool       `json:"buttonEnabled"`
+	LedEnabled       bool       `json:"ledEnabled"`
+	LedBrightness    uint8      `json:"ledBrightness"`
+	CurrentTime      time.Time  `json:"currentTime"`
+	TimezoneOffset   int16      `json:"timezoneOffset"`
+	DstMode          uint8      `json:"dstMode"`
+	HasFob           bool       `json:"hasFob"`
+	FobAction1       uint8      `json:"fobAction1"`
+	FobAction2       uint8      `json:"fobAction2"`
+	FobAction3       uint8      `json:"fobAction3"`
+	SingleLock       bool       `json:"singleLock"`
+	AdvertisingMode  uint8      `json:"advertisingMode"`
+	HasKeypad        bool       `json:"hasKeypad"`
+	FirmwareVersion  string     `json:"firmwareVersion"`
+	HardwareRevision string     `json:"hardwareRevision"`
+	HomeKitStatus    uint8      `json:"homeKitStatus"`
+	TimezoneID       uint16     `json:"timezoneId"`
+	DeviceType       DeviceType `json:"deviceType"`
+	Capabilities     uint8      `json:"capabilities"`
+	HasKeypad2       bool       `json:"hasKeypad2"`
+	MatterStatus     uint8      `json:"matterStatus"`
 }
 
 func (c *Config) GetTimezoneLocation() *time.Location {
@@ -82,7 +96,7 @@ func (c *Config) FromMessage(b []byte) error {
 
 	c.HomeKitStatus = b[71]
 	// timezoneID is set above, next to the current time
-	c.DeviceType = b[74]
+	c.DeviceType = DeviceType(b[74])
 	c.Capabilities = b[75]
 	if len(b) > 76 { // MatterStatus is optional? TODO: verify
 		c.HasKeypad2 = byteToBool(b[76])
@@ -110,3 +124,94 @@ func (c *RequestConfig) GetCommandCode() CommandCode {
 func (c *RequestConfig) GetPayload() []byte {
 	return c.Nonce
 }
+
+var _ Request = &SetConfig{}
+
+// SetConfig Command 0x0013
+type SetConfig struct {
+	Name            string
+	Latitude        float32
+	Longitude       float32
+	AutoUnlatch     bool
+	PairingEnabled  bool
+	ButtonEnabled   bool
+	LedEnabled      bool
+	LedBrightness   uint8
+	TimezoneOffset  int16
+	DstMode         uint8
+	FobAction1      uint8
+	FobAction2      uint8
+	FobAction3      uint8
+	SingleLock      bool
+	AdvertisingMode uint8
+	TimezoneID      uint16
+	Nonce           []byte
+	SecurityPin     Pin
+}
+
+// NewSetConfig creates a SetConfig request with all writable fields taken from cfg.
+func NewSetConfig(cfg *Config, nonce []byte, pin Pin) *SetConfig {
+	return &SetConfig{
+		Name:            cfg.Name,
+		Latitude:        cfg.Latitude,
+		Longitude:       cfg.Longitude,
+		AutoUnlatch:     cfg.AutoUnlatch,
+		PairingEnabled:  cfg.PairingEnabled,
+		ButtonEnabled:   cfg.ButtonEnabled,
+		LedEnabled:      cfg.LedEnabled,
+		LedBrightness:   cfg.LedBrightness,
+		TimezoneOffset:  cfg.TimezoneOffset,
+		DstMode:         cfg.DstMode,
+		FobAction1:      cfg.FobAction1,
+		FobAction2:      cfg.FobAction2,
+		FobAction3:      cfg.FobAction3,
+		SingleLock:      cfg.SingleLock,
+		AdvertisingMode: cfg.AdvertisingMode,
+		TimezoneID:      cfg.TimezoneID,
+		Nonce:           nonce,
+		SecurityPin:     pin,
+	}
+}
+
+func (c *SetConfig) GetCommandCode() CommandCode {
+	return CommandSetConfig
+}
+
+func (c *SetConfig) GetPayload() []byte {
+	name := [32]byte{}
+	copy(name[:], c.Name)
+	return slices.Concat(
+		name[:],
+		binary.LittleEndian.AppendUint32(nil, math.Float32bits(c.Latitude)),
+		binary.LittleEndian.AppendUint32(nil, math.Float32bits(c.Longitude)),
+		[]byte{
+			boolToByte(c.AutoUnlatch),
+			boolToByte(c.PairingEnabled),
+			boolToByte(c.ButtonEnabled),
+			boolToByte(c.LedEnabled),
+			c.LedBrightness,
+		},
+		binary.LittleEndian.AppendUint16(nil, uint16(c.TimezoneOffset)),
+		[]byte{
+			c.DstMode,
+			c.FobAction1,
+			c.FobAction2,
+			c.FobAction3,
+			boolToByte(c.SingleLock),
+			c.AdvertisingMode,
+		},
+		binary.LittleEndian.AppendUint16(nil, c.TimezoneID),
+		c.Nonce,
+		c.SecurityPin.GetPinBytes(),
+	)
+}
+
+// TimezoneIDByName returns the ID the lock uses for the given IANA timezone name.
+func TimezoneIDByName(name string) (uint16, bool) {
+	for id, n := range timezoneMap {
+		if n == name {
+			return id, true
+		}
+	}
+	return 0, false
+}
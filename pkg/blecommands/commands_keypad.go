@@ -0,0 +1,188 @@
+package blecommands
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ValidateKeypadCode checks the rules the lock enforces for keypad codes:
+// exactly 6 digits, no digit 0 and the code must not start with "12".
+func ValidateKeypadCode(code uint32) error {
+	s := strconv.FormatUint(uint64(code), 10)
+	if len(s) != 6 {
+		return fmt.Errorf("keypad code %s must have exactly 6 digits", s)
+	}
+	if strings.ContainsRune(s, '0') {
+		return fmt.Errorf("keypad code %s must not contain the digit 0", s)
+	}
+	if strings.HasPrefix(s, "12") {
+		return fmt.Errorf("keypad code %s must not start with 12", s)
+	}
+	return nil
+}
+
+func keypadCodeName(name string) []byte {
+	b := [20]byte{}
+	copy(b[:], name)
+	return b[:]
+}
+
+// AddKeypadCode (0x0041)
+
+var _ Request = &AddKeypadCode{}
+
+type AddKeypadCode struct {
+	Code        uint32
+	Name        string
+	TimeLimit   TimeLimit
+	Nonce       []byte
+	SecurityPin Pin
+}
+
+func (c *AddKeypadCode) GetCommandCode() CommandCode { return CommandAddKeypadCode }
+func (c *AddKeypadCode) GetPayload() []byte {
+	return slices.Concat(
+		binary.LittleEndian.AppendUint32(nil, c.Code),
+		keypadCodeName(c.Name),
+		c.TimeLimit.bytes(),
+		c.Nonce,
+		c.SecurityPin.GetPinBytes(),
+	)
+}
+
+// KeypadCodeID (0x0042)
+
+var _ Response = &KeypadCodeID{}
+
+type KeypadCodeID struct {
+	CodeId uint16 `json:"codeId"`
+}
+
+func (c *KeypadCodeID) GetCommandCode() CommandCode { return CommandKeypadCodeID }
+func (c *KeypadCodeID) FromMessage(b []byte) error {
+	if len(b) < 2 {
+		return fmt.Errorf("keypad code ID length must be at least 2 bytes, got: %d", len(b))
+	}
+	c.CodeId = binary.LittleEndian.Uint16(b[0:2])
+	return nil
+}
+
+// RequestKeypadCodes (0x0043)
+
+var _ Request = &RequestKeypadCodes{}
+
+type RequestKeypadCodes struct {
+	Offset      uint16
+	Count       uint16
+	Nonce       []byte
+	SecurityPin Pin
+}
+
+func (c *RequestKeypadCodes) GetCommandCode() CommandCode { return CommandRequestKeypadCodes }
+func (c *RequestKeypadCodes) GetPayload() []byte {
+	return slices.Concat(
+		binary.LittleEndian.AppendUint16(nil, c.Offset),
+		binary.LittleEndian.AppendUint16(nil, c.Count),
+		c.Nonce,
+		c.SecurityPin.GetPinBytes(),
+	)
+}
+
+// KeypadCodeCount (0x0044)
+
+var _ Response = &KeypadCodeCount{}
+
+type KeypadCodeCount struct {
+	Count uint16 `json:"count"`
+}
+
+func (c *KeypadCodeCount) GetCommandCode() CommandCode { return CommandKeypadCodeCount }
+func (c *KeypadCodeCount) FromMessage(b []byte) error {
+	if len(b) != 2 {
+		return fmt.Errorf("keypad code count length must be exactly 2 bytes, got: %d", len(b))
+	}
+	c.Count = binary.LittleEndian.Uint16(b)
+	return nil
+}
+
+// KeypadCode (0x0045)
+
+var _ Response = &KeypadCode{}
+
+type KeypadCode struct {
+	CodeId       uint16    `json:"codeId"`
+	Enabled      bool      `json:"enabled"`
+	Code         uint32    `json:"code"`
+	Name         string    `json:"name"`
+	CreatedAt    time.Time `json:"createdAt"`
+	LastActiveAt time.Time `json:"lastActiveAt"`
+	LockCount    uint16    `json:"lockCount"`
+	TimeLimit    TimeLimit `json:"timeLimit"`
+}
+
+func (c *KeypadCode) GetCommandCode() CommandCode { return CommandKeypadCode }
+func (c *KeypadCode) FromMessage(b []byte) error {
+	if len(b) < 43+timeLimitLength {
+		return fmt.Errorf("keypad code length must be at least %d bytes, got: %d", 43+timeLimitLength, len(b))
+	}
+	c.CodeId = binary.LittleEndian.Uint16(b[0:2])
+	c.Code = binary.LittleEndian.Uint32(b[2:6])
+	c.Name = string(bytes.Trim(b[6:26], "\x00"))
+	c.Enabled = byteToBool(b[26])
+	c.CreatedAt = fromNukiTime(b[27:34], time.UTC)
+	c.LastActiveAt = fromNukiTime(b[34:41], time.UTC)
+	c.LockCount = binary.LittleEndian.Uint16(b[41:43])
+	c.TimeLimit = newTimeLimit(b[43 : 43+timeLimitLength])
+	return nil
+}
+
+// UpdateKeypadCode (0x0046)
+
+var _ Request = &UpdateKeypadCode{}
+
+type UpdateKeypadCode struct {
+	CodeId      uint16
+	Code        uint32
+	Name        string
+	Enabled     bool
+	TimeLimit   TimeLimit
+	Nonce       []byte
+	SecurityPin Pin
+}
+
+func (c *UpdateKeypadCode) GetCommandCode() CommandCode { return CommandUpdateKeypadCode }
+func (c *UpdateKeypadCode) GetPayload() []byte {
+	return slices.Concat(
+		binary.LittleEndian.AppendUint16(nil, c.CodeId),
+		binary.LittleEndian.AppendUint32(nil, c.Code),
+		keypadCodeName(c.Name),
+		[]byte{boolToByte(c.Enabled)},
+		c.TimeLimit.bytes(),
+		c.Nonce,
+		c.SecurityPin.GetPinBytes(),
+	)
+}
+
+// RemoveKeypadCode (0x0047)
+
+var _ Request = &RemoveKeypadCode{}
+
+type RemoveKeypadCode struct {
+	CodeId      uint16
+	Nonce       []byte
+	SecurityPin Pin
+}
+
+func (c *RemoveKeypadCode) GetCommandCode() CommandCode { return CommandRemoveKeypadCode }
+func (c *RemoveKeypadCode) GetPayload() []byte {
+	return slices.Concat(
+		binary.LittleEndian.AppendUint16(nil, c.CodeId),
+		c.Nonce,
+		c.SecurityPin.GetPinBytes(),
+	)
+}
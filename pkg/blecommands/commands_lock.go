@@ -12,6 +12,25 @@ import (
 type Action uint8
 
 func (a Action) MarshalText() ([]byte, error) { return []byte(a.String()), nil }
+func (a *Action) UnmarshalText(b []byte) error {
+	v, err := ParseAction(string(b))
+	if err != nil {
+		return err
+	}
+	*a = v
+	return nil
+}
+
+// ParseAction parses the name of an action case-insensitively, ignoring dashes and underscores, e.g. "lock-and-go".
+func ParseAction(s string) (Action, error) {
+	normalize := strings.NewReplacer("-", "", "_", "", " ", "")
+	for _, a := range []Action{Unlock, Lock, Unlatch, LockAndGo, LockAndGoUnlatch, FullLock, FobAction1, FobAction2, FobAction3} {
+		if strings.EqualFold(normalize.Replace(s), a.String()) {
+			return a, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid action %q", s)
+}
 
 const (
 	Unlock Action = 0x01
@@ -27,6 +46,22 @@ const (
 	FobAction3 Action = 0x83
 )
 
+// ExpectedLockStates returns the lock states a Smart Lock may report once it completed action.
+// It returns nil for the fob actions, whose result depends on the configuration of the lock.
+func ExpectedLockStates(action Action) []LockState {
+	switch action {
+	case Unlock:
+		return []LockState{LockStateUnlocked}
+	case Lock, FullLock:
+		return []LockState{LockStateLocked}
+	case Unlatch:
+		return []LockState{LockStateUnlatched, LockStateUnlocked}
+	case LockAndGo, LockAndGoUnlatch:
+		return []LockState{LockStateUnlockedLockNGo, LockStateUnlatched, LockStateUnlocked, LockStateLocked}
+	}
+	return nil
+}
+
 //go:generate stringer -type=LockState -trimprefix=LockState
 type LockState uint8
 
@@ -77,6 +112,7 @@ const (
 	NukiStateUninitialized   NukiState = 0x00
 	NukiStatePairingMode     NukiState = 0x01
 	NukiStateDoorMode        NukiState = 0x02
+	NukiStateContinuousMode  NukiState = 0x03 // Opener only
 	NukiStateMaintenanceMode NukiState = 0x04
 )
 
@@ -324,8 +360,8 @@ func (c *KeyturnerStates) GetCommandCode() CommandCode {
 	return CommandKeyturnerStates
 }
 func (c *KeyturnerStates) FromMessage(b []byte) error {
-	if len(b) < 26 && len(b) > 27 { // TODO: which one is the correct length?
-		return fmt.Errorf("keyturner states length must be between 26 and 27 bytes, got: %d", len(b))
+	if len(b) < 26 {
+		return fmt.Errorf("keyturner states length must be at least 26 bytes, got: %d", len(b))
 	}
 	c.NukiState = NukiState(b[0])
 	c.LockState = LockState(b[1])
@@ -348,6 +384,8 @@ func (c *KeyturnerStates) FromMessage(b []byte) error {
 	c.WifiConnectionStrength = newConnectionStrength(b[23])
 	c.WifiConnectionStatus = newWifiConnectionStatus(b[24])
 	c.MqttConnectionStatus = newMqttConnectionStatus(b[25])
-	c.ThreadConnectionStatus = newThreadConnectionStatus(b[26])
+	if len(b) > 26 { // not sent by older firmwares
+		c.ThreadConnectionStatus = newThreadConnectionStatus(b[26])
+	}
 	return nil
 }
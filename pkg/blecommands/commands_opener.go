@@ -0,0 +1,286 @@
+package blecommands
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// The Nuki Opener shares the command set and the encryption with the Smart Lock, but uses its own
+// actions and lock states and a different layout for the keyturner states and config responses.
+// The handler decodes these responses as OpenerStates and OpenerConfig once SetDeviceType(DeviceTypeOpener) is called.
+
+//go:generate stringer -type=OpenerAction -trimprefix=OpenerAction
+type OpenerAction uint8
+
+const (
+	OpenerActionActivateRTO             OpenerAction = 0x01
+	OpenerActionDeactivateRTO           OpenerAction = 0x02
+	OpenerActionElectricStrikeActuation OpenerAction = 0x03
+	OpenerActionActivateCM              OpenerAction = 0x04
+	OpenerActionDeactivateCM            OpenerAction = 0x05
+
+	OpenerActionFobAction1 OpenerAction = 0x81
+	OpenerActionFobAction2 OpenerAction = 0x82
+	OpenerActionFobAction3 OpenerAction = 0x83
+)
+
+// openerActionAliases are the short names accepted by ParseOpenerAction in addition to the action names.
+var openerActionAliases = map[string]OpenerAction{
+	"rtoon":  OpenerActionActivateRTO,
+	"rtooff": OpenerActionDeactivateRTO,
+	"open":   OpenerActionElectricStrikeActuation,
+	"cmon":   OpenerActionActivateCM,
+	"cmoff":  OpenerActionDeactivateCM,
+}
+
+func (a OpenerAction) MarshalText() ([]byte, error) { return []byte(a.String()), nil }
+func (a *OpenerAction) UnmarshalText(b []byte) error {
+	v, err := ParseOpenerAction(string(b))
+	if err != nil {
+		return err
+	}
+	*a = v
+	return nil
+}
+
+// ParseOpenerAction parses the name of an Opener action case-insensitively, ignoring dashes and underscores,
+// e.g. "activate-rto". The short forms rto-on, rto-off, open, cm-on and cm-off are accepted as well.
+func ParseOpenerAction(s string) (OpenerAction, error) {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", "_", "", " ", "").Replace(s))
+	if a, ok := openerActionAliases[normalized]; ok {
+		return a, nil
+	}
+	for _, a := range []OpenerAction{
+		OpenerActionActivateRTO, OpenerActionDeactivateRTO, OpenerActionElectricStrikeActuation,
+		OpenerActionActivateCM, OpenerActionDeactivateCM,
+		OpenerActionFobAction1, OpenerActionFobAction2, OpenerActionFobAction3,
+	} {
+		if strings.EqualFold(normalized, a.String()) {
+			return a, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid opener action %q", s)
+}
+
+//go:generate stringer -type=OpenerLockState -trimprefix=OpenerLockState
+type OpenerLockState uint8
+
+func (s OpenerLockState) MarshalText() ([]byte, error) { return []byte(s.String()), nil }
+
+const (
+	OpenerLockStateUntrained OpenerLockState = 0x00
+	OpenerLockStateOnline    OpenerLockState = 0x01
+	OpenerLockStateRTOActive OpenerLockState = 0x03
+	OpenerLockStateOpen      OpenerLockState = 0x05
+	OpenerLockStateOpening   OpenerLockState = 0x07
+	OpenerLockStateBootRun   OpenerLockState = 0xFD
+	OpenerLockStateUndefined OpenerLockState = 0xFF
+)
+
+// DoorbellSuppression tells in which situations the Opener keeps the doorbell from ringing.
+type DoorbellSuppression struct {
+	ContinuousMode bool `json:"continuousMode"` // Bit 0: while continuous mode is active
+	RingToOpen     bool `json:"ringToOpen"`     // Bit 1: while ring to open is active
+	Ring           bool `json:"ring"`           // Bit 2: always
+}
+
+func (d DoorbellSuppression) String() string {
+	var vals []string
+	if d.ContinuousMode {
+		vals = append(vals, "Continuous Mode")
+	}
+	if d.RingToOpen {
+		vals = append(vals, "Ring to Open")
+	}
+	if d.Ring {
+		vals = append(vals, "Ring")
+	}
+	if len(vals) == 0 {
+		return "Off"
+	}
+	return strings.Join(vals, ", ")
+}
+
+func newDoorbellSuppression(b byte) DoorbellSuppression {
+	return DoorbellSuppression{
+		ContinuousMode: b&0x01 != 0,
+		RingToOpen:     b&0x02 != 0,
+		Ring:           b&0x04 != 0,
+	}
+}
+
+var _ Response = &OpenerStates{}
+
+// OpenerStates Command 0x000C, as sent by an Opener
+type OpenerStates struct {
+	NukiState                      NukiState           `json:"nukiState"`
+	LockState                      OpenerLockState     `json:"lockState"`
+	Trigger                        Trigger             `json:"trigger"`
+	CurrentTime                    time.Time           `json:"currentTime"`
+	TimezoneOffset                 int16               `json:"timezoneOffset"`
+	BatteryStateCritical           bool                `json:"batteryStateCritical"`
+	ConfigUpdateCount              byte                `json:"configUpdateCount"`
+	RingToOpenTimer                byte                `json:"ringToOpenTimer"` // remaining minutes
+	LastLockAction                 OpenerAction        `json:"lastLockAction"`
+	LastLockActionTrigger          Trigger             `json:"lastLockActionTrigger"`
+	LastLockActionCompletionStatus StatusCode          `json:"lastLockActionCompletionStatus"`
+	DoorbellSuppression            DoorbellSuppression `json:"doorbellSuppression"`
+}
+
+// ContinuousModeActive reports whether the Opener opens the door on every ring.
+func (c *OpenerStates) ContinuousModeActive() bool {
+	return c.NukiState == NukiStateContinuousMode
+}
+
+// RingToOpenActive reports whether the Opener opens the door on the next ring.
+func (c *OpenerStates) RingToOpenActive() bool {
+	return c.LockState == OpenerLockStateRTOActive
+}
+
+func (c *OpenerStates) GetCommandCode() CommandCode {
+	return CommandKeyturnerStates
+}
+
+func (c *OpenerStates) FromMessage(b []byte) error {
+	if len(b) < 18 {
+		return fmt.Errorf("opener states length must be at least 18 bytes, got: %d", len(b))
+	}
+	c.NukiState = NukiState(b[0])
+	c.LockState = OpenerLockState(b[1])
+	c.Trigger = Trigger(b[2])
+	c.CurrentTime = fromNukiTime(b[3:10], time.UTC)
+	c.TimezoneOffset = int16(binary.LittleEndian.Uint16(b[10:12]))
+	c.BatteryStateCritical = byteToBool(b[12] & 0x01)
+	c.ConfigUpdateCount = b[13]
+	c.RingToOpenTimer = b[14]
+	c.LastLockAction = OpenerAction(b[15])
+	c.LastLockActionTrigger = Trigger(b[16])
+	c.LastLockActionCompletionStatus = StatusCode(b[17])
+	if len(b) > 18 {
+		c.DoorbellSuppression = newDoorbellSuppression(b[18])
+	}
+	return nil
+}
+
+// OpenerCapabilities tells which kinds of doors the Opener is able to open.
+type OpenerCapabilities uint8
+
+const (
+	OpenerCapabilitiesDoorOpener OpenerCapabilities = 0x00 // only the electric strike actuation
+	OpenerCapabilitiesBoth       OpenerCapabilities = 0x01
+	OpenerCapabilitiesRingToOpen OpenerCapabilities = 0x02 // only ring to open
+)
+
+func (c OpenerCapabilities) String() string {
+	switch c {
+	case OpenerCapabilitiesDoorOpener:
+		return "Door Opener"
+	case OpenerCapabilitiesBoth:
+		return "Door Opener and Ring to Open"
+	case OpenerCapabilitiesRingToOpen:
+		return "Ring to Open"
+	}
+	return fmt.Sprintf("Unknown (%d)", uint8(c))
+}
+
+var _ Response = &OpenerConfig{}
+
+// OpenerConfig Command 0x0015, as sent by an Opener
+type OpenerConfig struct {
+	NukiID           uint32             `json:"nukiId"`
+	Name             string             `json:"name"`
+	Latitude         float32            `json:"latitude"`
+	Longitude        float32            `json:"longitude"`
+	Capabilities     OpenerCapabilities `json:"capabilities"`
+	PairingEnabled   bool               `json:"pairingEnabled"`
+	ButtonEnabled    bool               `json:"buttonEnabled"`
+	LedFlashEnabled  bool               `json:"ledFlashEnabled"`
+	CurrentTime      time.Time          `json:"currentTime"`
+	TimezoneOffset   int16              `json:"timezoneOffset"`
+	DstMode          uint8              `json:"dstMode"`
+	HasFob           bool               `json:"hasFob"`
+	FobAction1       uint8              `json:"fobAction1"`
+	FobAction2       uint8              `json:"fobAction2"`
+	FobAction3       uint8              `json:"fobAction3"`
+	OperatingMode    uint8              `json:"operatingMode"` // the intercom model the Opener is configured for
+	AdvertisingMode  uint8              `json:"advertisingMode"`
+	HasKeypad        bool               `json:"hasKeypad"`
+	FirmwareVersion  string             `json:"firmwareVersion"`
+	HardwareRevision string             `json:"hardwareRevision"`
+	TimezoneID       uint16             `json:"timezoneId"`
+	HasKeypad2       bool               `json:"hasKeypad2"`
+}
+
+func (c *OpenerConfig) GetCommandCode() CommandCode {
+	return CommandConfig
+}
+
+func (c *OpenerConfig) FromMessage(b []byte) error {
+	if len(b) < 72 {
+		return fmt.Errorf("opener config length must be at least 72 bytes, got: %d", len(b))
+	}
+	c.NukiID = binary.LittleEndian.Uint32(b[0:4])
+	c.Name = string(bytes.Trim(b[4:36], "\x00"))
+	c.Latitude = math.Float32frombits(binary.LittleEndian.Uint32(b[36:40]))
+	c.Longitude = math.Float32frombits(binary.LittleEndian.Uint32(b[40:44]))
+	c.Capabilities = OpenerCapabilities(b[44])
+	c.PairingEnabled = byteToBool(b[45])
+	c.ButtonEnabled = byteToBool(b[46])
+	c.LedFlashEnabled = byteToBool(b[47])
+
+	c.TimezoneID = binary.LittleEndian.Uint16(b[70:72])
+	c.CurrentTime = fromNukiTime(b[48:55], (&Config{TimezoneID: c.TimezoneID}).GetTimezoneLocation())
+	c.TimezoneOffset = int16(binary.LittleEndian.Uint16(b[55:57]))
+	c.DstMode = b[57]
+	c.HasFob = byteToBool(b[58])
+	c.FobAction1 = b[59]
+	c.FobAction2 = b[60]
+	c.FobAction3 = b[61]
+	c.OperatingMode = b[62]
+	c.AdvertisingMode = b[63]
+	c.HasKeypad = byteToBool(b[64])
+	c.FirmwareVersion = fmt.Sprintf("%d.%d.%d", b[65], b[66], b[67])
+	c.HardwareRevision = fmt.Sprintf("%d.%d", b[68], b[69])
+	if len(b) > 72 {
+		c.HasKeypad2 = byteToBool(b[72])
+	}
+	return nil
+}
+
+// Config returns the fields the Opener has in common with the Smart Lock as a Config with DeviceType DeviceTypeOpener.
+func (c *OpenerConfig) Config() *Config {
+	return &Config{
+		NukiID:           c.NukiID,
+		Name:             c.Name,
+		Latitude:         c.Latitude,
+		Longitude:        c.Longitude,
+		PairingEnabled:   c.PairingEnabled,
+		ButtonEnabled:    c.ButtonEnabled,
+		LedEnabled:       c.LedFlashEnabled,
+		CurrentTime:      c.CurrentTime,
+		TimezoneOffset:   c.TimezoneOffset,
+		DstMode:          c.DstMode,
+		HasFob:           c.HasFob,
+		FobAction1:       c.FobAction1,
+		FobAction2:       c.FobAction2,
+		FobAction3:       c.FobAction3,
+		AdvertisingMode:  c.AdvertisingMode,
+		HasKeypad:        c.HasKeypad,
+		FirmwareVersion:  c.FirmwareVersion,
+		HardwareRevision: c.HardwareRevision,
+		TimezoneID:       c.TimezoneID,
+		DeviceType:       DeviceTypeOpener,
+		Capabilities:     uint8(c.Capabilities),
+		HasKeypad2:       c.HasKeypad2,
+	}
+}
+
+// openerResponseImplMap overrides responseImplMap for responses whose layout differs on the Opener.
+var openerResponseImplMap = map[CommandCode]func() Response{
+	CommandKeyturnerStates: func() Response { return &OpenerStates{} },
+	CommandConfig:          func() Response { return &OpenerConfig{} },
+}
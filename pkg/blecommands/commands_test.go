@@ -1,7 +1,10 @@
 package blecommands_test
 
 import (
+	"encoding/binary"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/nuki-io/nuki-cli/pkg/blecommands"
 	"github.com/stretchr/testify/require"
@@ -40,3 +43,265 @@ func TestEncryptedRequestChallenge(t *testing.T) {
 	}
 	require.Equal(t, want, msg)
 }
+
+func TestAuthorizationEntryFromMessage(t *testing.T) {
+	name := [32]byte{}
+	copy(name[:], "Front door app")
+	msg := slices.Concat(
+		[]byte{0x03, 0x00, 0x00, 0x00}, // auth id
+		[]byte{0x00},                   // app
+		name[:],
+		[]byte{0x01, 0x00}, // enabled, remote allowed
+		[]byte{0xE9, 0x07, 0x05, 0x17, 0x0A, 0x1E, 0x00}, // created 2025-05-23 10:30:00
+		[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, // never active
+		[]byte{0x2A, 0x00}, // lock count
+		[]byte{0x01},       // time limited
+		[]byte{0xE9, 0x07, 0x06, 0x01, 0x00, 0x00, 0x00}, // allowed from 2025-06-01
+		[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, // no end date
+		[]byte{0x7C},                   // mon-fri
+		[]byte{0x08, 0x00, 0x12, 0x1E}, // 08:00-18:30
+	)
+
+	e := &blecommands.AuthorizationEntry{}
+	require.NoError(t, e.FromMessage(msg))
+	require.Equal(t, uint32(3), e.AuthId)
+	require.Equal(t, blecommands.AuthorizationTypeApp, e.IdType)
+	require.Equal(t, "Front door app", e.Name)
+	require.True(t, e.Enabled)
+	require.False(t, e.RemoteAllowed)
+	require.Equal(t, time.Date(2025, 5, 23, 10, 30, 0, 0, time.UTC), e.CreatedAt)
+	require.True(t, e.LastActiveAt.IsZero())
+	require.Equal(t, uint16(42), e.LockCount)
+	require.True(t, e.TimeLimit.TimeLimited)
+	require.Equal(t, time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), e.TimeLimit.AllowedFromDate)
+	require.True(t, e.TimeLimit.AllowedUntilDate.IsZero())
+	require.Equal(t, blecommands.WorkingDays, e.TimeLimit.AllowedWeekdays)
+	require.Equal(t, blecommands.TimeOfDay{Hour: 8, Minute: 0}, e.TimeLimit.AllowedFromTime)
+	require.Equal(t, blecommands.TimeOfDay{Hour: 18, Minute: 30}, e.TimeLimit.AllowedUntilTime)
+	require.Equal(t, "2025-06-01 - … mon,tue,wed,thu,fri 08:00-18:30", e.TimeLimit.String())
+}
+
+func TestParseWeekdays(t *testing.T) {
+	w, err := blecommands.ParseWeekdays("Mon, wednesday,weekend")
+	require.NoError(t, err)
+	require.Equal(t, blecommands.Monday|blecommands.Wednesday|blecommands.Saturday|blecommands.Sunday, w)
+	require.Equal(t, "mon,wed,sat,sun", w.String())
+
+	_, err = blecommands.ParseWeekdays("someday")
+	require.Error(t, err)
+}
+
+func TestValidateKeypadCode(t *testing.T) {
+	require.NoError(t, blecommands.ValidateKeypadCode(345678))
+	require.Error(t, blecommands.ValidateKeypadCode(34567))   // too short
+	require.Error(t, blecommands.ValidateKeypadCode(3456789)) // too long
+	require.Error(t, blecommands.ValidateKeypadCode(345670))  // contains 0
+	require.Error(t, blecommands.ValidateKeypadCode(123456))  // starts with 12
+}
+
+func TestKeypadCodeFromMessage(t *testing.T) {
+	update := &blecommands.UpdateKeypadCode{
+		CodeId:  7,
+		Code:    345678,
+		Name:    "Cleaner",
+		Enabled: true,
+		TimeLimit: blecommands.TimeLimit{
+			TimeLimited:      true,
+			AllowedFromDate:  time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+			AllowedWeekdays:  blecommands.WorkingDays,
+			AllowedFromTime:  blecommands.TimeOfDay{Hour: 8},
+			AllowedUntilTime: blecommands.TimeOfDay{Hour: 18, Minute: 30},
+		},
+		Nonce:       make([]byte, 32),
+		SecurityPin: blecommands.NewPin("1234"),
+	}
+	payload := update.GetPayload()
+	// the keypad code has the layout of the update, with dates and the lock count before the time limit
+	msg := slices.Concat(
+		payload[:27],
+		[]byte{0xE9, 0x07, 0x05, 0x17, 0x0A, 0x1E, 0x00}, // created 2025-05-23 10:30:00
+		[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, // never active
+		[]byte{0x03, 0x00}, // lock count
+		payload[27:47],
+	)
+
+	c := &blecommands.KeypadCode{}
+	require.NoError(t, c.FromMessage(msg))
+	require.Equal(t, update.CodeId, c.CodeId)
+	require.Equal(t, update.Code, c.Code)
+	require.Equal(t, update.Name, c.Name)
+	require.True(t, c.Enabled)
+	require.Equal(t, time.Date(2025, 5, 23, 10, 30, 0, 0, time.UTC), c.CreatedAt)
+	require.True(t, c.LastActiveAt.IsZero())
+	require.Equal(t, uint16(3), c.LockCount)
+	require.Equal(t, update.TimeLimit, c.TimeLimit)
+
+	msg[26] = 0x00
+	require.NoError(t, c.FromMessage(msg))
+	require.False(t, c.Enabled)
+}
+
+func TestSetConfigPayload(t *testing.T) {
+	cfg := &blecommands.Config{
+		Name:            "Front Door",
+		Latitude:        48.2,
+		Longitude:       16.37,
+		AutoUnlatch:     true,
+		ButtonEnabled:   true,
+		LedBrightness:   3,
+		TimezoneOffset:  -60,
+		DstMode:         1,
+		FobAction2:      2,
+		SingleLock:      true,
+		AdvertisingMode: 1,
+		TimezoneID:      37,
+	}
+	nonce := make([]byte, 32)
+	payload := blecommands.NewSetConfig(cfg, nonce, blecommands.NewPin("1234")).GetPayload()
+
+	require.Len(t, payload, 55+32+2)
+	require.Equal(t, "Front Door", string(payload[:10]))
+	require.Equal(t, []byte{0xCD, 0xCC, 0x40, 0x42}, payload[32:36]) // 48.2
+	require.Equal(t, []byte{0x01, 0x00, 0x01, 0x00, 0x03}, payload[40:45])
+	require.Equal(t, []byte{0xC4, 0xFF}, payload[45:47]) // -60
+	require.Equal(t, []byte{0x01, 0x00, 0x02, 0x00, 0x01, 0x01}, payload[47:53])
+	require.Equal(t, []byte{0x25, 0x00}, payload[53:55])
+	require.Equal(t, []byte{0xD2, 0x04}, payload[87:])
+}
+
+func TestAdvancedConfigRoundTrip(t *testing.T) {
+	raw := []byte{
+		0x68, 0x01, // total degrees
+		0x05, 0x00, 0xFB, 0xFF, 0x00, 0x00, 0x0A, 0x00, // position offsets
+		0x14, 0x01, 0x05, 0x00, 0x02, 0x01, 0x03, // lock'n'go, buttons, cylinder, battery, unlatch
+		0x78, 0x00, 0x00, 0x01, 0x16, 0x00, 0x06, 0x1E, // auto lock timeout, night mode 22:00-06:30
+		0x01, 0x00, 0x01, 0x01, 0x00, 0x01, // night mode flags, auto lock, auto update
+		0x02, 0x01, // motor speed
+	}
+	cfg := &blecommands.AdvancedConfig{}
+	require.NoError(t, cfg.FromMessage(raw))
+	require.Equal(t, uint16(360), cfg.TotalDegrees)
+	require.Equal(t, int16(-5), cfg.LockedPositionOffsetDegrees)
+	require.Equal(t, blecommands.ButtonPressActionLockNGo, cfg.DoubleButtonPressAction)
+	require.Equal(t, blecommands.BatteryTypeLithium, cfg.BatteryType)
+	require.Equal(t, "22:00", cfg.NightmodeStartTime.String())
+	require.Equal(t, "06:30", cfg.NightmodeEndTime.String())
+	require.Equal(t, uint8(2), cfg.MotorSpeed)
+
+	nonce := make([]byte, 32)
+	payload := (&blecommands.SetAdvancedConfig{Config: cfg, Nonce: nonce, SecurityPin: blecommands.NewPin("1234")}).GetPayload()
+	require.Equal(t, slices.Concat(raw[2:], nonce, []byte{0xD2, 0x04}), payload)
+
+	// older firmwares do not report the motor speed, so it must not be written either
+	require.NoError(t, cfg.FromMessage(raw[:31]))
+	payload = (&blecommands.SetAdvancedConfig{Config: cfg, Nonce: nonce, SecurityPin: blecommands.NewPin("1234")}).GetPayload()
+	require.Len(t, payload, 29+32+2)
+}
+
+func TestBatteryReportFromMessage(t *testing.T) {
+	raw := []byte{
+		0x2C, 0x01, // drain 300 mWs
+		0x5A, 0x17, // voltage 5978 mV
+		0x00,       // not critical
+		0x02,       // lock
+		0x6E, 0x17, // start 5998 mV
+		0xD0, 0x16, // lowest 5840 mV
+		0x68, 0x01, // 360°
+		0xFB,       // -5 °C
+		0xC2, 0x01, // 450 mA
+		0x8C, 0x00, // 140 mOhm
+	}
+	r := &blecommands.BatteryReport{}
+	require.NoError(t, r.FromMessage(raw))
+	require.Equal(t, blecommands.BatteryReport{
+		BatteryDrain:      300,
+		BatteryVoltage:    5978,
+		LockAction:        blecommands.Lock,
+		StartVoltage:      5998,
+		LowestVoltage:     5840,
+		LockDistance:      360,
+		StartTemperature:  -5,
+		MaxTurnCurrent:    450,
+		BatteryResistance: 140,
+	}, *r)
+	require.Error(t, r.FromMessage(raw[:16]))
+}
+
+func TestOpenerStatesFromMessage(t *testing.T) {
+	raw := []byte{
+		0x03,                                     // continuous mode
+		0x03,                                     // rto active
+		0x00,                                     // system
+		0xE9, 0x07, 0x05, 0x17, 0x0A, 0x1E, 0x00, // 2025-05-23 10:30:00
+		0x3C, 0x00, // timezone offset
+		0x00, // battery ok
+		0x07, // config update count
+		0x0F, // rto timer
+		0x01, // activate rto
+		0x00, // system
+		0x00, // success
+		0x06, // suppress ring during rto and always
+	}
+	s := &blecommands.OpenerStates{}
+	require.NoError(t, s.FromMessage(raw))
+	require.True(t, s.ContinuousModeActive())
+	require.True(t, s.RingToOpenActive())
+	require.Equal(t, byte(15), s.RingToOpenTimer)
+	require.Equal(t, blecommands.OpenerActionActivateRTO, s.LastLockAction)
+	require.Equal(t, blecommands.DoorbellSuppression{RingToOpen: true, Ring: true}, s.DoorbellSuppression)
+}
+
+func TestOpenerConfigFromMessage(t *testing.T) {
+	raw := make([]byte, 72)
+	copy(raw[0:4], []byte{0x78, 0x56, 0x34, 0x12})
+	copy(raw[4:36], "Entrance")
+	raw[44] = 0x01 // door opener and rto
+	raw[47] = 0x01 // led flash
+	raw[62] = 0x03 // operating mode
+	copy(raw[65:70], []byte{1, 8, 3, 2, 1})
+
+	c := &blecommands.OpenerConfig{}
+	require.NoError(t, c.FromMessage(raw))
+	require.Equal(t, uint32(0x12345678), c.NukiID)
+	require.Equal(t, "Entrance", c.Name)
+	require.Equal(t, blecommands.OpenerCapabilitiesBoth, c.Capabilities)
+	require.Equal(t, uint8(3), c.OperatingMode)
+	require.Equal(t, "1.8.3", c.FirmwareVersion)
+
+	cfg := c.Config()
+	require.Equal(t, blecommands.DeviceTypeOpener, cfg.DeviceType)
+	require.True(t, cfg.LedEnabled)
+	require.Equal(t, "Entrance", cfg.Name)
+}
+
+func TestParseOpenerAction(t *testing.T) {
+	for s, want := range map[string]blecommands.OpenerAction{
+		"rto-on":                    blecommands.OpenerActionActivateRTO,
+		"deactivate-rto":            blecommands.OpenerActionDeactivateRTO,
+		"open":                      blecommands.OpenerActionElectricStrikeActuation,
+		"electric_strike_actuation": blecommands.OpenerActionElectricStrikeActuation,
+		"CM-Off":                    blecommands.OpenerActionDeactivateCM,
+		"fob-action-2":              blecommands.OpenerActionFobAction2,
+	} {
+		got, err := blecommands.ParseOpenerAction(s)
+		require.NoError(t, err, s)
+		require.Equal(t, want, got, s)
+	}
+	_, err := blecommands.ParseOpenerAction("unlock")
+	require.Error(t, err)
+}
+
+func TestHandlerDecodesOpenerResponses(t *testing.T) {
+	msg := slices.Concat([]byte{0x0C, 0x00}, make([]byte, 19))
+	msg = binary.LittleEndian.AppendUint16(msg, blecommands.CRC(msg))
+
+	handler := blecommands.NewBleHandler(nil, nil)
+	res, err := handler.FromDeviceResponse(msg)
+	require.Error(t, err) // too short for the Smart Lock keyturner states
+	require.Nil(t, res)
+
+	handler.SetDeviceType(blecommands.DeviceTypeOpener)
+	res, err = handler.FromDeviceResponse(msg)
+	require.NoError(t, err)
+	require.IsType(t, &blecommands.OpenerStates{}, res)
+}
@@ -0,0 +1,148 @@
+package blecommands
+
+import (
+	"fmt"
+	"slices"
+)
+
+// AddTimeControlEntry (0x0039)
+
+var _ Request = &AddTimeControlEntry{}
+
+type AddTimeControlEntry struct {
+	Weekdays    Weekdays
+	Time        TimeOfDay
+	Action      Action
+	Nonce       []byte
+	SecurityPin Pin
+}
+
+func (c *AddTimeControlEntry) GetCommandCode() CommandCode { return CommandAddTimeControlEntry }
+func (c *AddTimeControlEntry) GetPayload() []byte {
+	return slices.Concat(
+		[]byte{byte(c.Weekdays)},
+		c.Time.bytes(),
+		[]byte{byte(c.Action)},
+		c.Nonce,
+		c.SecurityPin.GetPinBytes(),
+	)
+}
+
+// TimeControlEntryID (0x003A)
+
+var _ Response = &TimeControlEntryID{}
+
+type TimeControlEntryID struct {
+	EntryId uint8 `json:"entryId"`
+}
+
+func (c *TimeControlEntryID) GetCommandCode() CommandCode { return CommandTimeControlEntryID }
+func (c *TimeControlEntryID) FromMessage(b []byte) error {
+	if len(b) != 1 {
+		return fmt.Errorf("time control entry ID length must be exactly 1 byte, got: %d", len(b))
+	}
+	c.EntryId = b[0]
+	return nil
+}
+
+// RemoveTimeControlEntry (0x003B)
+
+var _ Request = &RemoveTimeControlEntry{}
+
+type RemoveTimeControlEntry struct {
+	EntryId     uint8
+	Nonce       []byte
+	SecurityPin Pin
+}
+
+func (c *RemoveTimeControlEntry) GetCommandCode() CommandCode { return CommandRemoveTimeControlEntry }
+func (c *RemoveTimeControlEntry) GetPayload() []byte {
+	return slices.Concat([]byte{c.EntryId}, c.Nonce, c.SecurityPin.GetPinBytes())
+}
+
+// RequestTimeControlEntries (0x003C)
+
+var _ Request = &RequestTimeControlEntries{}
+
+type RequestTimeControlEntries struct {
+	Nonce       []byte
+	SecurityPin Pin
+}
+
+func (c *RequestTimeControlEntries) GetCommandCode() CommandCode {
+	return CommandRequestTimeControlEntries
+}
+func (c *RequestTimeControlEntries) GetPayload() []byte {
+	return slices.Concat(c.Nonce, c.SecurityPin.GetPinBytes())
+}
+
+// TimeControlEntryCount (0x003D)
+
+var _ Response = &TimeControlEntryCount{}
+
+type TimeControlEntryCount struct {
+	Count uint8 `json:"count"`
+}
+
+func (c *TimeControlEntryCount) GetCommandCode() CommandCode { return CommandTimeControlEntryCount }
+func (c *TimeControlEntryCount) FromMessage(b []byte) error {
+	if len(b) != 1 {
+		return fmt.Errorf("time control entry count length must be exactly 1 byte, got: %d", len(b))
+	}
+	c.Count = b[0]
+	return nil
+}
+
+// TimeControlEntry (0x003E)
+
+var _ Response = &TimeControlEntry{}
+
+type TimeControlEntry struct {
+	EntryId  uint8     `json:"entryId"`
+	Enabled  bool      `json:"enabled"`
+	Weekdays Weekdays  `json:"weekdays"`
+	Time     TimeOfDay `json:"time"`
+	Action   Action    `json:"action"`
+}
+
+func (c *TimeControlEntry) GetCommandCode() CommandCode { return CommandTimeControlEntry }
+func (c *TimeControlEntry) FromMessage(b []byte) error {
+	if len(b) != 6 {
+		return fmt.Errorf("time control entry length must be exactly 6 bytes, got: %d", len(b))
+	}
+	c.EntryId = b[0]
+	c.Enabled = byteToBool(b[1])
+	c.Weekdays = Weekdays(b[2])
+	c.Time = newTimeOfDay(b[3:5])
+	c.Action = Action(b[5])
+	return nil
+}
+
+func (c *TimeControlEntry) String() string {
+	return fmt.Sprintf("%s %s %s", c.Weekdays, c.Time, c.Action)
+}
+
+// UpdateTimeControlEntry (0x003F)
+
+var _ Request = &UpdateTimeControlEntry{}
+
+type UpdateTimeControlEntry struct {
+	EntryId     uint8
+	Enabled     bool
+	Weekdays    Weekdays
+	Time        TimeOfDay
+	Action      Action
+	Nonce       []byte
+	SecurityPin Pin
+}
+
+func (c *UpdateTimeControlEntry) GetCommandCode() CommandCode { return CommandUpdateTimeControlEntry }
+func (c *UpdateTimeControlEntry) GetPayload() []byte {
+	return slices.Concat(
+		[]byte{c.EntryId, boolToByte(c.Enabled), byte(c.Weekdays)},
+		c.Time.bytes(),
+		[]byte{byte(c.Action)},
+		c.Nonce,
+		c.SecurityPin.GetPinBytes(),
+	)
+}
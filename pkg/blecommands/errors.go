@@ -1,40 +1,141 @@
 package blecommands
 
-var errorCodeNames = map[byte]string{
-	0xFD: "ERROR_BAD_CRC",                  // CRC of received command is invalid
-	0xFE: "ERROR_BAD_LENGTH",               // Length of retrieved command payload does not match expected length
-	0xFF: "ERROR_UNKNOWN",                  // Used if no other error code matches
-	0x10: "P_ERROR_NOT_PAIRING",            // Returned if public key is being requested via request data command, but the Smart Lock is not in pairing mode
-	0x11: "P_ERROR_BAD_AUTHENTICATOR",      // Returned if the received authenticator does not match the own calculated authenticator
-	0x12: "P_ERROR_BAD_PARAMETER",          // Returned if a provided parameter is outside of its valid range
-	0x13: "P_ERROR_MAX_USER",               // Returned if the maximum number of users has been reached
-	0x20: "K_ERROR_NOT_AUTHORIZED",         // Returned if the provided authorization id is invalid or the payload could not be decrypted using the shared key for this authorization id
-	0x21: "K_ERROR_BAD_PIN",                // Returned if the provided pin does not match the stored one.
-	0x22: "K_ERROR_BAD_NONCE",              // Returned if the provided nonce does not match the last stored one of this authorization id or has already been used before.
-	0x23: "K_ERROR_BAD_PARAMETER",          // Returned if a provided parameter is outside of its valid range.
-	0x24: "K_ERROR_INVALID_AUTH_ID",        // Returned if the desired authorization id could not be deleted because it does not exist.
-	0x25: "K_ERROR_DISABLED",               // Returned if the provided authorization id is currently disabled.
-	0x26: "K_ERROR_REMOTE_NOT_ALLOWED",     // Returned if the request has been forwarded by the Nuki Bridge and the provided authorization id has not been granted remote access.
-	0x27: "K_ERROR_TIME_NOT_ALLOWED",       // Returned if the provided authorization id has not been granted access at the current time.
-	0x28: "K_ERROR_TOO_MANY_PIN_ATTEMPTS",  // Returned if an invalid pin has been provided too often
-	0x29: "K_ERROR_TOO_MANY_ENTRIES",       // Returned if no more entries can be stored
-	0x2A: "K_ERROR_CODE_ALREADY_EXISTS",    // Returned if a Keypad Code should be added but the given code already exists.
-	0x2B: "K_ERROR_CODE_INVALID",           // Returned if a Keypad Code that has been entered is invalid.
-	0x2C: "K_ERROR_CODE_INVALID_TIMEOUT_1", // Returned if an invalid pin has been provided multiple times.
-	0x2D: "K_ERROR_CODE_INVALID_TIMEOUT_2", // Returned if an invalid pin has been provided multiple times.
-	0x2E: "K_ERROR_CODE_INVALID_TIMEOUT_3", // Returned if an invalid pin has been provided multiple times.
-	0x40: "K_ERROR_AUTO_UNLOCK_TOO_RECENT", // Returned on an incoming auto unlock request and if an lock action has already been executed within short time.
-	0x41: "K_ERROR_POSITION_UNKNOWN",       // Returned on an incoming unlock request if the request has been forwarded by the Nuki Bridge and the Smart Lock is unsure about its actual lock position.
-	0x42: "K_ERROR_MOTOR_BLOCKED",          // Returned if the motor blocks.
-	0x43: "K_ERROR_CLUTCH_FAILURE",         // Returned if there is a problem with the clutch during motor movement.
-	0x44: "K_ERROR_MOTOR_TIMEOUT",          // Returned if the motor moves for a given period of time but did not block.
-	0x45: "K_ERROR_BUSY",                   // Returned on any lock action via bluetooth if there is already a lock action processing.
-	0x46: "K_ERROR_CANCELED",               // Returned on any lock action or during calibration if the user canceled the motor movement by pressing the button
-	0x47: "K_ERROR_NOT_CALIBRATED",         // Returned on any lock action if the Smart Lock has not yet been calibrated
-	0x48: "K_ERROR_MOTOR_POSITION_LIMIT",   // Returned during calibration if the internal position database is not able to store any more values
-	0x49: "K_ERROR_MOTOR_LOW_VOLTAGE",      // Returned if the motor blocks because of low voltage.
-	0x4A: "K_ERROR_MOTOR_POWER_FAILURE",    // Returned if the power drain during motor movement is zero
-	0x4B: "K_ERROR_CLUTCH_POWER_FAILURE",   // Returned if the power drain during clutch movement is zero
-	0x4C: "K_ERROR_VOLTAGE_TOO_LOW",        // Returned on a calibration request if the battery voltage is too low and a calibration will therefore not be started
-	0x4D: "K_ERROR_FIRMWARE_UPDATE_NEEDED", // Returned during any motor action if a firmware update is mandatory
+import (
+	"errors"
+	"fmt"
+)
+
+// DeviceError is returned when the device answers a command with an ErrorReport.
+// It matches the sentinel errors below with the same code, e.g. errors.Is(err, ErrBadPin).
+type DeviceError struct {
+	Code byte
+	// Name is the name of the code in the specification, e.g. K_ERROR_BAD_PIN.
+	Name        string
+	Description string
+	// Command is the command that failed. It is 0 for the sentinel errors.
+	Command CommandCode
+}
+
+func (e *DeviceError) Error() string {
+	if e.Command == 0 {
+		return e.Name
+	}
+	return fmt.Sprintf("%s, command: %s", e.Name, e.Command)
+}
+
+// Is reports whether target is a DeviceError with the same code.
+func (e *DeviceError) Is(target error) bool {
+	t, ok := target.(*DeviceError)
+	return ok && t.Code == e.Code
+}
+
+// NewDeviceError returns the error for an error code received for command.
+func NewDeviceError(code byte, command CommandCode) *DeviceError {
+	e := DeviceError{Code: code, Name: fmt.Sprintf("ERROR_0x%02X", code), Description: "Unknown error", Command: command}
+	if s, ok := deviceErrors[code]; ok {
+		e.Name = s.Name
+		e.Description = s.Description
+	}
+	return &e
+}
+
+func newSentinel(code byte, name, description string) *DeviceError {
+	return &DeviceError{Code: code, Name: name, Description: description}
+}
+
+var (
+	ErrBadCRC    = newSentinel(0xFD, "ERROR_BAD_CRC", "The CRC of the command is invalid")
+	ErrBadLength = newSentinel(0xFE, "ERROR_BAD_LENGTH", "The length of the command payload is invalid")
+	ErrUnknown   = newSentinel(0xFF, "ERROR_UNKNOWN", "Unknown error")
+
+	ErrNotPairing          = newSentinel(0x10, "P_ERROR_NOT_PAIRING", "The device is not in pairing mode")
+	ErrBadAuthenticator    = newSentinel(0x11, "P_ERROR_BAD_AUTHENTICATOR", "The authenticator sent during pairing is invalid")
+	ErrPairingBadParameter = newSentinel(0x12, "P_ERROR_BAD_PARAMETER", "A parameter sent during pairing is out of range")
+	ErrMaxUser             = newSentinel(0x13, "P_ERROR_MAX_USER", "The maximum number of authorizations is reached")
+
+	ErrNotAuthorized       = newSentinel(0x20, "K_ERROR_NOT_AUTHORIZED", "The authorization is not known to the device, it may have been removed")
+	ErrBadPin              = newSentinel(0x21, "K_ERROR_BAD_PIN", "The security PIN is wrong")
+	ErrBadNonce            = newSentinel(0x22, "K_ERROR_BAD_NONCE", "The nonce does not match or has been used before")
+	ErrBadParameter        = newSentinel(0x23, "K_ERROR_BAD_PARAMETER", "A parameter is out of range")
+	ErrInvalidAuthId       = newSentinel(0x24, "K_ERROR_INVALID_AUTH_ID", "The authorization ID does not exist")
+	ErrDisabled            = newSentinel(0x25, "K_ERROR_DISABLED", "The authorization is disabled")
+	ErrRemoteNotAllowed    = newSentinel(0x26, "K_ERROR_REMOTE_NOT_ALLOWED", "The authorization is not allowed to access the device through a bridge")
+	ErrTimeNotAllowed      = newSentinel(0x27, "K_ERROR_TIME_NOT_ALLOWED", "The authorization is not allowed to access the device at this time")
+	ErrTooManyPinAttempts  = newSentinel(0x28, "K_ERROR_TOO_MANY_PIN_ATTEMPTS", "A wrong security PIN was sent too often")
+	ErrTooManyEntries      = newSentinel(0x29, "K_ERROR_TOO_MANY_ENTRIES", "No more entries can be stored")
+	ErrCodeAlreadyExists   = newSentinel(0x2A, "K_ERROR_CODE_ALREADY_EXISTS", "The keypad code already exists")
+	ErrCodeInvalid         = newSentinel(0x2B, "K_ERROR_CODE_INVALID", "The keypad code is invalid")
+	ErrCodeInvalidTimeout1 = newSentinel(0x2C, "K_ERROR_CODE_INVALID_TIMEOUT_1", "A wrong PIN was sent several times, try again later")
+	ErrCodeInvalidTimeout2 = newSentinel(0x2D, "K_ERROR_CODE_INVALID_TIMEOUT_2", "A wrong PIN was sent several times, try again later")
+	ErrCodeInvalidTimeout3 = newSentinel(0x2E, "K_ERROR_CODE_INVALID_TIMEOUT_3", "A wrong PIN was sent several times, try again later")
+
+	ErrAutoUnlockTooRecent  = newSentinel(0x40, "K_ERROR_AUTO_UNLOCK_TOO_RECENT", "A lock action was performed shortly before the auto unlock")
+	ErrPositionUnknown      = newSentinel(0x41, "K_ERROR_POSITION_UNKNOWN", "The lock is unsure about its position")
+	ErrMotorBlocked         = newSentinel(0x42, "K_ERROR_MOTOR_BLOCKED", "The motor is blocked")
+	ErrClutchFailure        = newSentinel(0x43, "K_ERROR_CLUTCH_FAILURE", "The clutch failed during the motor movement")
+	ErrMotorTimeout         = newSentinel(0x44, "K_ERROR_MOTOR_TIMEOUT", "The motor moved too long without blocking")
+	ErrBusy                 = newSentinel(0x45, "K_ERROR_BUSY", "Another lock action is in progress")
+	ErrCanceled             = newSentinel(0x46, "K_ERROR_CANCELED", "The lock action was canceled with the button")
+	ErrNotCalibrated        = newSentinel(0x47, "K_ERROR_NOT_CALIBRATED", "The lock is not calibrated")
+	ErrMotorPositionLimit   = newSentinel(0x48, "K_ERROR_MOTOR_POSITION_LIMIT", "The calibration cannot store any more positions")
+	ErrMotorLowVoltage      = newSentinel(0x49, "K_ERROR_MOTOR_LOW_VOLTAGE", "The motor is blocked because of low battery voltage")
+	ErrMotorPowerFailure    = newSentinel(0x4A, "K_ERROR_MOTOR_POWER_FAILURE", "The motor drew no power")
+	ErrClutchPowerFailure   = newSentinel(0x4B, "K_ERROR_CLUTCH_POWER_FAILURE", "The clutch drew no power")
+	ErrVoltageTooLow        = newSentinel(0x4C, "K_ERROR_VOLTAGE_TOO_LOW", "The battery voltage is too low to calibrate")
+	ErrFirmwareUpdateNeeded = newSentinel(0x4D, "K_ERROR_FIRMWARE_UPDATE_NEEDED", "A firmware update is required")
+)
+
+var deviceErrors = map[byte]*DeviceError{}
+
+func init() {
+	for _, e := range []*DeviceError{
+		ErrBadCRC, ErrBadLength, ErrUnknown,
+		ErrNotPairing, ErrBadAuthenticator, ErrPairingBadParameter, ErrMaxUser,
+		ErrNotAuthorized, ErrBadPin, ErrBadNonce, ErrBadParameter, ErrInvalidAuthId, ErrDisabled, ErrRemoteNotAllowed,
+		ErrTimeNotAllowed, ErrTooManyPinAttempts, ErrTooManyEntries, ErrCodeAlreadyExists, ErrCodeInvalid,
+		ErrCodeInvalidTimeout1, ErrCodeInvalidTimeout2, ErrCodeInvalidTimeout3,
+		ErrAutoUnlockTooRecent, ErrPositionUnknown, ErrMotorBlocked, ErrClutchFailure, ErrMotorTimeout, ErrBusy,
+		ErrCanceled, ErrNotCalibrated, ErrMotorPositionLimit, ErrMotorLowVoltage, ErrMotorPowerFailure,
+		ErrClutchPowerFailure, ErrVoltageTooLow, ErrFirmwareUpdateNeeded,
+	} {
+		deviceErrors[e.Code] = e
+	}
+}
+
+// errorCodeName returns the name of an error code in the specification.
+func errorCodeName(code byte) string {
+	return NewDeviceError(code, 0).Name
+}
+
+func isAny(err error, targets ...error) bool {
+	for _, t := range targets {
+		if errors.Is(err, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPairingError reports whether err is a device error of the pairing process.
+func IsPairingError(err error) bool {
+	return isAny(err, ErrNotPairing, ErrBadAuthenticator, ErrPairingBadParameter, ErrMaxUser)
+}
+
+// IsAuthError reports whether err is a device error rejecting the authorization or the security PIN.
+func IsAuthError(err error) bool {
+	return isAny(err, ErrNotAuthorized, ErrBadPin, ErrInvalidAuthId, ErrDisabled, ErrRemoteNotAllowed, ErrTimeNotAllowed,
+		ErrTooManyPinAttempts, ErrCodeInvalidTimeout1, ErrCodeInvalidTimeout2, ErrCodeInvalidTimeout3)
+}
+
+// IsMotorError reports whether err is a device error of the motor, the clutch or the calibration.
+func IsMotorError(err error) bool {
+	return isAny(err, ErrPositionUnknown, ErrMotorBlocked, ErrClutchFailure, ErrMotorTimeout, ErrCanceled, ErrNotCalibrated,
+		ErrMotorPositionLimit, ErrMotorLowVoltage, ErrMotorPowerFailure, ErrClutchPowerFailure, ErrVoltageTooLow)
+}
+
+// IsTransientError reports whether err is a device error that may not occur when the command is sent again.
+// The device did not carry out the command. K_ERROR_AUTO_UNLOCK_TOO_RECENT is not transient, the lock refused
+// the command on purpose because another action was just performed.
+func IsTransientError(err error) bool {
+	return isAny(err, ErrBadCRC, ErrBadNonce, ErrBusy)
 }
@@ -0,0 +1,39 @@
+package blecommands_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/nuki-io/nuki-cli/pkg/blecommands"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeviceErrorFromResponse(t *testing.T) {
+	handler := blecommands.NewBleHandler(nil, nil)
+	msg := handler.ToMessage(&blecommands.ErrorReport{ErrorCode: 0x21, CommandIdentifier: blecommands.CommandRequestLogEntries})
+	_, err := handler.FromDeviceResponse(msg)
+
+	var de *blecommands.DeviceError
+	require.ErrorAs(t, err, &de)
+	require.Equal(t, byte(0x21), de.Code)
+	require.Equal(t, "K_ERROR_BAD_PIN", de.Name)
+	require.Equal(t, blecommands.CommandRequestLogEntries, de.Command)
+	require.EqualError(t, err, "K_ERROR_BAD_PIN, command: CommandRequestLogEntries")
+	require.ErrorIs(t, fmt.Errorf("failed to read logs: %w", err), blecommands.ErrBadPin)
+	require.NotErrorIs(t, err, blecommands.ErrBadNonce)
+}
+
+func TestDeviceErrorClassification(t *testing.T) {
+	motor := blecommands.NewDeviceError(blecommands.ErrMotorBlocked.Code, blecommands.CommandLockAction)
+	require.True(t, blecommands.IsMotorError(motor))
+	require.False(t, blecommands.IsAuthError(motor))
+	require.True(t, blecommands.IsAuthError(blecommands.ErrBadPin))
+	require.True(t, blecommands.IsPairingError(blecommands.ErrNotPairing))
+	require.True(t, blecommands.IsTransientError(fmt.Errorf("wrapped: %w", blecommands.ErrBusy)))
+	require.False(t, blecommands.IsTransientError(errors.New("K_ERROR_BUSY")))
+	require.False(t, blecommands.IsTransientError(blecommands.ErrAutoUnlockTooRecent))
+
+	unknown := blecommands.NewDeviceError(0x99, blecommands.CommandLockAction)
+	require.Equal(t, "ERROR_0x99", unknown.Name)
+}
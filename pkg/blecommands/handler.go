@@ -10,8 +10,9 @@ import (
 var authId5GPairing = []byte{0x7F, 0xFF, 0xFF, 0xFF}
 
 type BleHandler struct {
-	crypto Crypto
-	authId []byte
+	crypto     Crypto
+	authId     []byte
+	deviceType DeviceType
 }
 
 func NewBleHandler(crypto Crypto, authId []byte) *BleHandler {
@@ -21,6 +22,26 @@ func NewBleHandler(crypto Crypto, authId []byte) *BleHandler {
 	}
 }
 
+// SetDeviceType selects how responses are decoded. The Opener uses a different layout for some responses
+// than the Smart Lock, which is assumed by default.
+func (h *BleHandler) SetDeviceType(t DeviceType) {
+	h.deviceType = t
+}
+
+// newResponse returns an empty response for cmdCode, matching the device type of the handler.
+func (h *BleHandler) newResponse(cmdCode CommandCode) (Response, error) {
+	if h.deviceType == DeviceTypeOpener {
+		if impl, ok := openerResponseImplMap[cmdCode]; ok {
+			return impl(), nil
+		}
+	}
+	impl, ok := responseImplMap[cmdCode]
+	if !ok {
+		return nil, fmt.Errorf("unhandled response command code: %x, name: %s", int(cmdCode), cmdCode)
+	}
+	return impl(), nil
+}
+
 func (h *BleHandler) ToMessage(c Request) []byte {
 	payload := c.GetPayload()
 	res := make([]byte, 2+len(payload))
@@ -70,23 +91,28 @@ func (h *BleHandler) FromDeviceResponse(b []byte) (Command, error) {
 	if crcReceived != crcExpect {
 		return nil, fmt.Errorf("CRC mismatch: expected %x, got %x", crcExpect, crcReceived)
 	}
-	cmdImpl, ok := responseImplMap[cmdCode]
-	if !ok {
-		return nil, fmt.Errorf("unhandled response command code: %x, name: %s", int(cmdCode), cmdCode)
-	}
-	cmd := cmdImpl()
-	err := cmd.FromMessage(payload)
+	cmd, err := h.newResponse(cmdCode)
 	if err != nil {
+		return nil, err
+	}
+	if err := cmd.FromMessage(payload); err != nil {
 		return nil, fmt.Errorf("failed to parse command: %w", err)
 	}
 	if e, ok := cmd.(*ErrorReport); ok {
-		return cmd, fmt.Errorf("%s, command: %s", e.Error, e.CommandIdentifier)
+		return cmd, NewDeviceError(e.ErrorCode, e.CommandIdentifier)
 	}
 
 	return cmd, nil
 }
 
 func (h *BleHandler) FromEncryptedDeviceResponse(b []byte) (Response, error) {
+	// errors the device cannot encrypt, e.g. K_ERROR_NOT_AUTHORIZED for an unknown authorization ID, are sent
+	// unencrypted and are shorter than the header of an encrypted message
+	if len(b) < 30 {
+		cmd, err := h.FromDeviceResponse(b)
+		res, _ := cmd.(Response)
+		return res, err
+	}
 	nonce := b[0:24]
 	authId := b[24:28]
 	// msgLen := b[28:30]
@@ -98,6 +124,9 @@ func (h *BleHandler) FromEncryptedDeviceResponse(b []byte) (Response, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to decrypt response: %w", err)
 	}
+	if len(pdata) < 8 {
+		return nil, fmt.Errorf("invalid decrypted response length: %d. must be at least 8 bytes", len(pdata))
+	}
 
 	// TODO: the code below is mostly the same as the one in FromDeviceResponse but with a different offset for the CRC calculation
 	// pdata = authId[4] + command[2] + payload + crc[2]
@@ -116,17 +145,15 @@ func (h *BleHandler) FromEncryptedDeviceResponse(b []byte) (Response, error) {
 	if crcReceived != crcExpect {
 		return nil, fmt.Errorf("CRC mismatch: expected %x, got %x", crcExpect, crcReceived)
 	}
-	cmdImpl, ok := responseImplMap[cmdCode]
-	if !ok {
-		return nil, fmt.Errorf("unhandled response command code: %x, name: %s", int(cmdCode), cmdCode)
-	}
-	cmd := cmdImpl()
-	err = cmd.FromMessage(payload)
+	cmd, err := h.newResponse(cmdCode)
 	if err != nil {
+		return nil, err
+	}
+	if err := cmd.FromMessage(payload); err != nil {
 		return nil, fmt.Errorf("failed to parse command: %w", err)
 	}
 	if e, ok := cmd.(*ErrorReport); ok {
-		return cmd, fmt.Errorf("%s, command: %s", e.Error, e.CommandIdentifier)
+		return cmd, NewDeviceError(e.ErrorCode, e.CommandIdentifier)
 	}
 	return cmd, nil
 }
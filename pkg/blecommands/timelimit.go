@@ -0,0 +1,193 @@
+package blecommands
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Weekdays is the weekday bitmask used by authorization entries, keypad codes and time control entries.
+type Weekdays uint8
+
+const (
+	Sunday    Weekdays = 0x01
+	Saturday  Weekdays = 0x02
+	Friday    Weekdays = 0x04
+	Thursday  Weekdays = 0x08
+	Wednesday Weekdays = 0x10
+	Tuesday   Weekdays = 0x20
+	Monday    Weekdays = 0x40
+
+	WorkingDays Weekdays = Monday | Tuesday | Wednesday | Thursday | Friday
+	Weekend     Weekdays = Saturday | Sunday
+	AllWeekdays Weekdays = WorkingDays | Weekend
+)
+
+var weekdayNames = []struct {
+	day  Weekdays
+	name string
+}{
+	{Monday, "mon"},
+	{Tuesday, "tue"},
+	{Wednesday, "wed"},
+	{Thursday, "thu"},
+	{Friday, "fri"},
+	{Saturday, "sat"},
+	{Sunday, "sun"},
+}
+
+func (w Weekdays) String() string {
+	var vals []string
+	for _, d := range weekdayNames {
+		if w&d.day != 0 {
+			vals = append(vals, d.name)
+		}
+	}
+	return strings.Join(vals, ",")
+}
+
+func (w Weekdays) MarshalText() ([]byte, error) { return []byte(w.String()), nil }
+func (w *Weekdays) UnmarshalText(b []byte) error {
+	v, err := ParseWeekdays(string(b))
+	if err != nil {
+		return err
+	}
+	*w = v
+	return nil
+}
+
+// ParseWeekdays parses a comma separated list of weekdays like "mon,tue,fri".
+// The shortcuts "all", "weekdays" and "weekend" are supported as well. An empty string means no weekdays.
+func ParseWeekdays(s string) (Weekdays, error) {
+	var w Weekdays
+	for _, part := range strings.Split(s, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		switch part {
+		case "":
+			continue
+		case "all":
+			w |= AllWeekdays
+			continue
+		case "weekdays":
+			w |= WorkingDays
+			continue
+		case "weekend":
+			w |= Weekend
+			continue
+		}
+		day, ok := parseWeekday(part)
+		if !ok {
+			return 0, fmt.Errorf("invalid weekday %q", part)
+		}
+		w |= day
+	}
+	return w, nil
+}
+
+func parseWeekday(s string) (Weekdays, bool) {
+	for _, d := range weekdayNames {
+		if strings.HasPrefix(s, d.name) {
+			return d.day, true
+		}
+	}
+	return 0, false
+}
+
+// TimeOfDay is an hour and minute pair as used by the time window fields of the lock.
+type TimeOfDay struct {
+	Hour   uint8
+	Minute uint8
+}
+
+func (t TimeOfDay) String() string {
+	return fmt.Sprintf("%02d:%02d", t.Hour, t.Minute)
+}
+
+func (t TimeOfDay) MarshalText() ([]byte, error) { return []byte(t.String()), nil }
+func (t *TimeOfDay) UnmarshalText(b []byte) error {
+	v, err := ParseTimeOfDay(string(b))
+	if err != nil {
+		return err
+	}
+	*t = v
+	return nil
+}
+
+// ParseTimeOfDay parses a time in the format HH:MM.
+func ParseTimeOfDay(s string) (TimeOfDay, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return TimeOfDay{}, fmt.Errorf("invalid time of day %q, expected HH:MM", s)
+	}
+	return TimeOfDay{Hour: uint8(t.Hour()), Minute: uint8(t.Minute())}, nil
+}
+
+func (t TimeOfDay) bytes() []byte {
+	return []byte{t.Hour, t.Minute}
+}
+
+func newTimeOfDay(b []byte) TimeOfDay {
+	return TimeOfDay{Hour: b[0], Minute: b[1]}
+}
+
+// timeLimitLength is the encoded length of TimeLimit.
+const timeLimitLength = 20
+
+// TimeLimit restricts when an authorization or keypad code may be used.
+// It is only evaluated by the lock if TimeLimited is set.
+type TimeLimit struct {
+	TimeLimited      bool      `json:"timeLimited"`
+	AllowedFromDate  time.Time `json:"allowedFromDate"`
+	AllowedUntilDate time.Time `json:"allowedUntilDate"`
+	AllowedWeekdays  Weekdays  `json:"allowedWeekdays"`
+	AllowedFromTime  TimeOfDay `json:"allowedFromTime"`
+	AllowedUntilTime TimeOfDay `json:"allowedUntilTime"`
+}
+
+func (l TimeLimit) bytes() []byte {
+	return slices.Concat(
+		[]byte{boolToByte(l.TimeLimited)},
+		toNukiTime(l.AllowedFromDate),
+		toNukiTime(l.AllowedUntilDate),
+		[]byte{byte(l.AllowedWeekdays)},
+		l.AllowedFromTime.bytes(),
+		l.AllowedUntilTime.bytes(),
+	)
+}
+
+func newTimeLimit(b []byte) TimeLimit {
+	return TimeLimit{
+		TimeLimited:      byteToBool(b[0]),
+		AllowedFromDate:  fromNukiTime(b[1:8], time.UTC),
+		AllowedUntilDate: fromNukiTime(b[8:15], time.UTC),
+		AllowedWeekdays:  Weekdays(b[15]),
+		AllowedFromTime:  newTimeOfDay(b[16:18]),
+		AllowedUntilTime: newTimeOfDay(b[18:20]),
+	}
+}
+
+// String returns a human readable representation of the time limit.
+func (l TimeLimit) String() string {
+	if !l.TimeLimited {
+		return "unlimited"
+	}
+	var parts []string
+	if !l.AllowedFromDate.IsZero() || !l.AllowedUntilDate.IsZero() {
+		parts = append(parts, fmt.Sprintf("%s - %s", formatDate(l.AllowedFromDate), formatDate(l.AllowedUntilDate)))
+	}
+	if l.AllowedWeekdays != 0 {
+		parts = append(parts, l.AllowedWeekdays.String())
+	}
+	if l.AllowedFromTime != (TimeOfDay{}) || l.AllowedUntilTime != (TimeOfDay{}) {
+		parts = append(parts, fmt.Sprintf("%s-%s", l.AllowedFromTime, l.AllowedUntilTime))
+	}
+	return strings.Join(parts, " ")
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return "…"
+	}
+	return t.Format(time.DateOnly)
+}
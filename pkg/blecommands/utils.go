@@ -16,8 +16,13 @@ func boolToByte(b bool) byte {
 	return 0
 }
 
+// toNukiTime encodes t in UTC. The zero time is encoded as all zero bytes, which the lock uses for "not set".
 func toNukiTime(t time.Time) []byte {
 	b := make([]byte, 7)
+	if t.IsZero() {
+		return b
+	}
+	t = t.UTC()
 	binary.LittleEndian.PutUint16(b[0:2], uint16(t.Year()))
 	b[2] = byte(t.Month())
 	b[3] = byte(t.Day())
@@ -32,6 +37,9 @@ func fromNukiTime(b []byte, tz *time.Location) time.Time {
 		return time.Time{}
 	}
 	year := int(binary.LittleEndian.Uint16(b[0:2]))
+	if year == 0 {
+		return time.Time{}
+	}
 	month := int(b[2])
 	day := int(b[3])
 	hour := int(b[4])
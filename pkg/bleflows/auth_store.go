@@ -5,4 +5,8 @@ package bleflows
 type AuthStore interface {
 	Load(deviceId string) (*AuthorizeContext, error)
 	Store(deviceId string, ctx *AuthorizeContext) error
+	// List returns the IDs of the devices with a stored authorization, sorted.
+	List() ([]string, error)
+	// Delete removes the authorization of a device. Deleting a missing authorization does nothing.
+	Delete(deviceId string) error
 }
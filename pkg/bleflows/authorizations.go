@@ -0,0 +1,115 @@
+package bleflows
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/nuki-io/nuki-cli/pkg/blecommands"
+)
+
+// authorizationPageSize is the number of authorization entries requested per round trip by GetAllAuthorizationEntries.
+const authorizationPageSize = 20
+
+// GetAuthorizationEntries reads count authorization entries starting at offset.
+// The total number of entries stored on the device is returned as well, if the device sent it.
+func (f *Flow) GetAuthorizationEntries(ctx context.Context, offset int, count int) ([]blecommands.AuthorizationEntry, *blecommands.AuthorizationEntryCount, error) {
+	nonce, err := f.getChallenge(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get challenge from device: %w", err)
+	}
+
+	req := &blecommands.RequestAuthorizationEntries{
+		Offset:      uint16(offset),
+		Count:       uint16(count),
+		Nonce:       nonce,
+		SecurityPin: blecommands.NewPin(f.authCtx.Pin),
+	}
+	msg := f.encrypt(req)
+	ch, stop := f.transport.WriteUsdioStream(ctx, msg)
+	defer stop()
+
+	var entries []blecommands.AuthorizationEntry
+	var total *blecommands.AuthorizationEntryCount
+	for {
+		select {
+		case buf := <-ch:
+			res, err := f.handler.FromEncryptedDeviceResponse(buf)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to decrypt authorization entry response: %w", err)
+			}
+			slog.Debug("Received authorization entry response", "cmd", res.GetCommandCode(), "payload", res)
+			switch r := res.(type) {
+			case *blecommands.AuthorizationEntry:
+				entries = append(entries, *r)
+			case *blecommands.AuthorizationEntryCount:
+				total = r
+			case *blecommands.Status:
+				if r.Status == blecommands.StatusComplete {
+					return entries, total, nil
+				}
+			}
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+	}
+}
+
+// GetAllAuthorizationEntries pages through all authorization entries stored on the device.
+func (f *Flow) GetAllAuthorizationEntries(ctx context.Context) ([]blecommands.AuthorizationEntry, error) {
+	var all []blecommands.AuthorizationEntry
+	for {
+		entries, total, err := f.GetAuthorizationEntries(ctx, len(all), authorizationPageSize)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, entries...)
+		if len(entries) < authorizationPageSize || (total != nil && len(all) >= int(total.Count)) {
+			return all, nil
+		}
+	}
+}
+
+// GetAuthorizationEntry looks up a single authorization entry by its ID.
+func (f *Flow) GetAuthorizationEntry(ctx context.Context, authId uint32) (*blecommands.AuthorizationEntry, error) {
+	entries, err := f.GetAllAuthorizationEntries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.AuthId == authId {
+			return &e, nil
+		}
+	}
+	return nil, fmt.Errorf("no authorization entry with ID %d found", authId)
+}
+
+// UpdateAuthorizationEntry writes name, enabled, remote allowed and time limit settings of the given entry to the device.
+func (f *Flow) UpdateAuthorizationEntry(ctx context.Context, entry *blecommands.AuthorizationEntry) error {
+	nonce, err := f.getChallenge(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get challenge: %w", err)
+	}
+	return f.performSimpleOp(ctx, &blecommands.UpdateAuthorizationEntry{
+		AuthId:        entry.AuthId,
+		Name:          entry.Name,
+		Enabled:       entry.Enabled,
+		RemoteAllowed: entry.RemoteAllowed,
+		TimeLimit:     entry.TimeLimit,
+		Nonce:         nonce,
+		SecurityPin:   blecommands.NewPin(f.authCtx.Pin),
+	})
+}
+
+// RemoveAuthorizationEntry deletes the authorization with the given ID from the device.
+func (f *Flow) RemoveAuthorizationEntry(ctx context.Context, authId uint32) error {
+	nonce, err := f.getChallenge(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get challenge: %w", err)
+	}
+	return f.performSimpleOp(ctx, &blecommands.RemoveAuthorizationEntry{
+		AuthId:      authId,
+		Nonce:       nonce,
+		SecurityPin: blecommands.NewPin(f.authCtx.Pin),
+	})
+}
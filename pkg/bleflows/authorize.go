@@ -4,6 +4,7 @@ import (
 	"context"
 	crypto_rand "crypto/rand"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 
@@ -11,11 +12,11 @@ import (
 )
 
 func (f *Flow) Authorize(ctx context.Context, pin string) error {
-	f.authCtx = NewAuthorizeContext()
+	f.authCtx = newAuthorizeContext(f.rand())
 	f.authCtx.Pin = pin
 	slog.Info("Requesting public key from smartlock")
 	msg := f.handler.ToMessage(&blecommands.RequestData{CommandIdentifier: blecommands.CommandPublicKey})
-	raw, err := f.device.WritePairing(ctx, msg)
+	raw, err := f.transport.WritePairing(ctx, msg)
 	if err != nil {
 		return fmt.Errorf("failed to get public key from device: %w", err)
 	}
@@ -26,11 +27,11 @@ func (f *Flow) Authorize(ctx context.Context, pin string) error {
 	f.authCtx.SlPublicKey = res.(*blecommands.PublicKey).PublicKey
 	slog.Info("Received public key from smartlock", "pubkey", fmt.Sprintf("%x", f.authCtx.SlPublicKey))
 
-	f.authCtx.GenerateKeyPair()
+	f.authCtx.generateKeyPair(f.rand())
 
 	slog.Info("Sending CLI public key", "pubkey", fmt.Sprintf("%x", f.authCtx.CliPublicKey))
 	msg = f.handler.ToMessage(&blecommands.PublicKey{PublicKey: f.authCtx.CliPublicKey})
-	raw, err = f.device.WritePairing(ctx, msg)
+	raw, err = f.transport.WritePairing(ctx, msg)
 	if err != nil {
 		return fmt.Errorf("failed to send public key to device: %w", err)
 	}
@@ -47,7 +48,7 @@ func (f *Flow) Authorize(ctx context.Context, pin string) error {
 	authenticator := f.authCtx.GetMessageAuthenticator(f.authCtx.CliPublicKey, f.authCtx.SlPublicKey, challenge)
 	slog.Info("Sending authenticator", "authenticator", fmt.Sprintf("%x", authenticator))
 	msg = f.handler.ToMessage(&blecommands.AuthorizationAuthenticator{Authenticator: authenticator})
-	raw, err = f.device.WritePairing(ctx, msg)
+	raw, err = f.transport.WritePairing(ctx, msg)
 	if err != nil {
 		return fmt.Errorf("failed to send authenticator to device: %w", err)
 	}
@@ -65,7 +66,7 @@ func (f *Flow) Authorize(ctx context.Context, pin string) error {
 		return err
 	}
 
-	f.device.DiscoverKeyturnerUsdio()
+	f.transport.DiscoverKeyturnerUsdio()
 	f.initializeHandlerWithCrypto()
 	nonce, err := f.getChallenge(ctx)
 	if err != nil {
@@ -74,8 +75,8 @@ func (f *Flow) Authorize(ctx context.Context, pin string) error {
 
 	slog.Info("Reading config from smartlock")
 	cfg := &blecommands.RequestConfig{Nonce: nonce}
-	msg = f.handler.ToEncryptedMessage(cfg, GetNonce24())
-	raw, err = f.device.WriteUsdio(ctx, msg)
+	msg = f.encrypt(cfg)
+	raw, err = f.transport.WriteUsdio(ctx, msg)
 	if err != nil {
 		return fmt.Errorf("failed to get config from device: %w", err)
 	}
@@ -83,7 +84,11 @@ func (f *Flow) Authorize(ctx context.Context, pin string) error {
 	if err != nil {
 		return fmt.Errorf("failed to get config from device: %w", err)
 	}
-	f.UpdateAuthCtxFromConfig(res.(*blecommands.Config))
+	config, err := toConfig(res)
+	if err != nil {
+		return err
+	}
+	f.UpdateAuthCtxFromConfig(config)
 
 	return nil
 }
@@ -96,7 +101,7 @@ func (f *Flow) authPre5G(ctx context.Context, res blecommands.Command) error {
 		IdType: 0x00,
 		Id:     f.authCtx.AppId,
 		Name:   getAuthName(),
-		Nonce:  GetNonce32(),
+		Nonce:  f.nonce32(),
 	}
 	// at this point, the payload will not contain the authenticator as it has 0 length
 	authenticator := f.authCtx.GetMessageAuthenticator(authData.GetPayload(), challenge)
@@ -104,7 +109,7 @@ func (f *Flow) authPre5G(ctx context.Context, res blecommands.Command) error {
 	authData.Authenticator = authenticator
 
 	msg := f.handler.ToMessage(authData)
-	raw, err := f.device.WritePairing(ctx, msg)
+	raw, err := f.transport.WritePairing(ctx, msg)
 	if err != nil {
 		return fmt.Errorf("failed to send authorization data: %w", err)
 	}
@@ -118,7 +123,7 @@ func (f *Flow) authPre5G(ctx context.Context, res blecommands.Command) error {
 
 	authenticator = f.authCtx.GetMessageAuthenticator(f.authCtx.AuthId, authId.Nonce)
 	msg = f.handler.ToMessage(&blecommands.AuthorizationIDConfirmation{Authenticator: authenticator, AuthId: f.authCtx.AuthId})
-	raw, err = f.device.WritePairing(ctx, msg)
+	raw, err = f.transport.WritePairing(ctx, msg)
 	if err != nil {
 		return fmt.Errorf("failed to send authorization ID confirmation: %w", err)
 	}
@@ -149,8 +154,8 @@ func (f *Flow) auth5G(ctx context.Context, res blecommands.Command) error {
 		Name:        getAuthName(),
 		SecurityPin: securityPin,
 	}
-	msg := f.handler.ToEncryptedMessage(authData, GetNonce24())
-	raw, err := f.device.WritePairing(ctx, msg)
+	msg := f.encrypt(authData)
+	raw, err := f.transport.WritePairing(ctx, msg)
 	if err != nil {
 		return fmt.Errorf("failed to send authorization data: %w", err)
 	}
@@ -175,12 +180,43 @@ func getAuthName() string {
 	return fmt.Sprintf("Nuki CLI (%s)", hostname)
 }
 
+// SetRandom sets the source of the nonces and keys of the flow, which is crypto/rand by default.
+// A recorded session can only be replayed with the random bytes of the recording.
+func (f *Flow) SetRandom(r io.Reader) {
+	f.random = r
+}
+
+func (f *Flow) rand() io.Reader {
+	if f.random == nil {
+		return crypto_rand.Reader
+	}
+	return f.random
+}
+
+func (f *Flow) nonce24() []byte {
+	return f.nonce(24)
+}
+
+func (f *Flow) nonce32() []byte {
+	return f.nonce(32)
+}
+
+func (f *Flow) nonce(n int) []byte {
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(f.rand(), buf); err != nil {
+		slog.Warn("Failed to read random bytes for nonce", "error", err)
+	}
+	return buf
+}
+
+// GetNonce32 returns a random nonce of 32 bytes from crypto/rand.
 func GetNonce32() []byte {
 	var buf [32]byte
 	crypto_rand.Read(buf[:])
 	return buf[:]
 }
 
+// GetNonce24 returns a random nonce of 24 bytes from crypto/rand.
 func GetNonce24() []byte {
 	var buf [24]byte
 	crypto_rand.Read(buf[:])
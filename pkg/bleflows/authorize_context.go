@@ -4,6 +4,7 @@ import (
 	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
+	"io"
 	"slices"
 
 	"golang.org/x/crypto/nacl/box"
@@ -22,9 +23,13 @@ type AuthorizeContext struct {
 }
 
 func NewAuthorizeContext() *AuthorizeContext {
+	return newAuthorizeContext(rand.Reader)
+}
+
+func newAuthorizeContext(random io.Reader) *AuthorizeContext {
 	ctx := &AuthorizeContext{}
 	ctx.AppId = make([]byte, 4)
-	if _, err := rand.Read(ctx.AppId); err != nil {
+	if _, err := io.ReadFull(random, ctx.AppId); err != nil {
 		panic(err)
 	}
 	return ctx
@@ -43,7 +48,11 @@ func (ac *AuthorizeContext) GetMessageAuthenticator(parts ...[]byte) []byte {
 }
 
 func (ac *AuthorizeContext) GenerateKeyPair() {
-	pub, priv, err := box.GenerateKey(rand.Reader)
+	ac.generateKeyPair(rand.Reader)
+}
+
+func (ac *AuthorizeContext) generateKeyPair(random io.Reader) {
+	pub, priv, err := box.GenerateKey(random)
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,63 @@
+package bleflows
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nuki-io/nuki-cli/pkg/blecommands"
+)
+
+// SetConfig writes all writable fields of cfg to the device. Read-only fields like the firmware version are ignored.
+func (f *Flow) SetConfig(ctx context.Context, cfg *blecommands.Config) error {
+	if err := f.requireSmartLock(ctx, "writing the config"); err != nil {
+		return err
+	}
+	nonce, err := f.getChallenge(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get challenge: %w", err)
+	}
+	return f.performSimpleOp(ctx, blecommands.NewSetConfig(cfg, nonce, blecommands.NewPin(f.authCtx.Pin)))
+}
+
+// GetAdvancedConfig reads the advanced configuration of the device.
+func (f *Flow) GetAdvancedConfig(ctx context.Context) (*blecommands.AdvancedConfig, error) {
+	if err := f.requireSmartLock(ctx, "reading the advanced config"); err != nil {
+		return nil, err
+	}
+	nonce, err := f.getChallenge(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get challenge from device: %w", err)
+	}
+
+	msg := f.encrypt(&blecommands.RequestAdvancedConfig{Nonce: nonce})
+	raw, err := f.transport.WriteUsdio(ctx, msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get advanced config from device: %w", err)
+	}
+	res, err := f.handler.FromEncryptedDeviceResponse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get advanced config from device: %w", err)
+	}
+	cfg, ok := res.(*blecommands.AdvancedConfig)
+	if !ok {
+		return nil, fmt.Errorf("unexpected response to advanced config request: %s", res.GetCommandCode())
+	}
+	return cfg, nil
+}
+
+// SetAdvancedConfig writes the advanced configuration to the device.
+// cfg should originate from GetAdvancedConfig, as it determines which optional fields the device supports.
+func (f *Flow) SetAdvancedConfig(ctx context.Context, cfg *blecommands.AdvancedConfig) error {
+	if err := f.requireSmartLock(ctx, "writing the advanced config"); err != nil {
+		return err
+	}
+	nonce, err := f.getChallenge(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get challenge: %w", err)
+	}
+	return f.performSimpleOp(ctx, &blecommands.SetAdvancedConfig{
+		Config:      cfg,
+		Nonce:       nonce,
+		SecurityPin: blecommands.NewPin(f.authCtx.Pin),
+	})
+}
@@ -3,26 +3,34 @@ package bleflows
 import (
 	"context"
 	"fmt"
+	"io"
 
 	"github.com/nuki-io/nuki-cli/pkg/blecommands"
-	"github.com/nuki-io/nuki-cli/pkg/nukible"
 )
 
 type Flow struct {
-	ble     *nukible.NukiBle
-	handler *blecommands.BleHandler
-	device  *nukible.Device
-	authCtx *AuthorizeContext
-	store   AuthStore
-	id      string
+	transport Transport
+	handler   *blecommands.BleHandler
+	authCtx   *AuthorizeContext
+	store     AuthStore
+	id        string
+
+	// deviceType is cached from the first config read, see DeviceType
+	deviceType *blecommands.DeviceType
+	// random is the source of nonces and keys, see SetRandom
+	random io.Reader
+	// changes counts the written requests changing the device, lastChanges is set if the last request
+	// was one of them, see mayHaveChanged
+	changes     int
+	lastChanges bool
 }
 
-// NewAuthenticatedFlow creates a new Flow instance for a Nuki device that was already paired.
-func NewAuthenticatedFlow(ble *nukible.NukiBle, id string, store AuthStore) (*Flow, error) {
+// NewAuthenticatedFlow connects through transport to a Nuki device that was already paired.
+func NewAuthenticatedFlow(transport Transport, id string, store AuthStore) (*Flow, error) {
 	f := &Flow{
-		ble:   ble,
-		id:    id,
-		store: store,
+		transport: transport,
+		id:        id,
+		store:     store,
 	}
 	err := f.loadAuthContext(id)
 	if err != nil {
@@ -32,29 +40,29 @@ func NewAuthenticatedFlow(ble *nukible.NukiBle, id string, store AuthStore) (*Fl
 	if err != nil {
 		return nil, err
 	}
-	err = f.device.DiscoverKeyturnerUsdio()
+	err = f.transport.DiscoverKeyturnerUsdio()
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %w", ErrDiscovery, err)
 	}
 	f.initializeHandlerWithCrypto()
 
 	return f, nil
 }
 
-// NewUnauthenticatedFlow creates a new Flow instance for a Nuki device that has not been paired yet.
-func NewUnauthenticatedFlow(ble *nukible.NukiBle, id string, store AuthStore) (*Flow, error) {
+// NewUnauthenticatedFlow connects through transport to a Nuki device that has not been paired yet.
+func NewUnauthenticatedFlow(transport Transport, id string, store AuthStore) (*Flow, error) {
 	f := &Flow{
-		ble:   ble,
-		id:    id,
-		store: store,
+		transport: transport,
+		id:        id,
+		store:     store,
 	}
 	err := f.connect(id)
 	if err != nil {
 		return nil, err
 	}
-	err = f.device.DiscoverPairing()
+	err = f.transport.DiscoverPairing()
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %w", ErrDiscovery, err)
 	}
 	f.initializeHandler()
 
@@ -62,16 +70,10 @@ func NewUnauthenticatedFlow(ble *nukible.NukiBle, id string, store AuthStore) (*
 }
 
 func (f *Flow) connect(id string) error {
-	addr, ok := f.ble.GetDeviceAddress(id)
-	if !ok {
-		return fmt.Errorf("requested device with MAC %s was not discovered", id)
-	}
-
-	device, err := f.ble.Connect(*addr)
+	err := f.transport.Connect()
 	if err != nil {
-		return fmt.Errorf("cannot connect to device %s. %s", id, err.Error())
+		return fmt.Errorf("%w %s. %w", ErrConnect, id, err)
 	}
-	f.device = device
 	return nil
 }
 
@@ -86,16 +88,64 @@ func (f *Flow) loadAuthContext(id string) error {
 
 func (f *Flow) initializeHandler() {
 	f.handler = blecommands.NewBleHandler(nil, nil)
+	f.setHandlerDeviceType()
 }
 
 func (f *Flow) initializeHandlerWithCrypto() {
 	crypto := blecommands.NewCrypto(f.authCtx.SharedKey)
 	f.handler = blecommands.NewBleHandler(crypto, f.authCtx.AuthId)
+	f.setHandlerDeviceType()
+}
+
+// setHandlerDeviceType makes the handler decode Opener responses if the Opener services were discovered.
+func (f *Flow) setHandlerDeviceType() {
+	if f.transport.IsOpener() {
+		f.handler.SetDeviceType(blecommands.DeviceTypeOpener)
+	}
+}
+
+// encrypt encrypts req for the device and counts it if it changes the device, see mayHaveChanged.
+func (f *Flow) encrypt(req blecommands.Request) []byte {
+	f.lastChanges = !isReadOnly(req)
+	if f.lastChanges {
+		f.changes++
+	}
+	return f.handler.ToEncryptedMessage(req, f.nonce24())
+}
+
+// isReadOnly reports whether req only reads from the device.
+func isReadOnly(req blecommands.Request) bool {
+	switch req.(type) {
+	case *blecommands.RequestData, *blecommands.RequestConfig, *blecommands.RequestAdvancedConfig,
+		*blecommands.RequestAuthorizationEntries, *blecommands.RequestKeypadCodes, *blecommands.RequestTimeControlEntries,
+		*blecommands.RequestLogEntries, *blecommands.VerifySecurityPIN:
+		return true
+	}
+	return false
+}
+
+// mayHaveChanged reports whether the device may have carried out a request changing it, like a lock action,
+// since the flow was created or resetChanges was called, given that the flow failed with err.
+// A request is counted once it is written, as the device may carry it out even if its response is lost.
+// The last request is not counted if the device refused it with a transient error like K_ERROR_BUSY.
+// Operations must not be retried if this is true, e.g. an unlatch would be performed twice.
+func (f *Flow) mayHaveChanged(err error) bool {
+	changes := f.changes
+	if f.lastChanges && blecommands.IsTransientError(err) {
+		changes--
+	}
+	return changes > 0
+}
+
+// resetChanges forgets the requests counted by mayHaveChanged, before the flow is reused for another operation.
+func (f *Flow) resetChanges() {
+	f.changes = 0
+	f.lastChanges = false
 }
 
 func (f *Flow) getChallenge(ctx context.Context) ([]byte, error) {
-	msg := f.handler.ToEncryptedMessage(&blecommands.RequestData{CommandIdentifier: blecommands.CommandChallenge}, GetNonce24())
-	raw, err := f.device.WriteUsdio(ctx, msg)
+	msg := f.encrypt(&blecommands.RequestData{CommandIdentifier: blecommands.CommandChallenge})
+	raw, err := f.transport.WriteUsdio(ctx, msg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get challenge from device: %w", err)
 	}
@@ -113,10 +163,10 @@ func (f *Flow) UpdateAuthCtxFromConfig(cfg *blecommands.Config) {
 }
 
 func (f *Flow) DisconnectDevice() error {
-	if f.device == nil {
+	if f.transport == nil {
 		return fmt.Errorf("no device connected")
 	}
-	f.device.Disconnect()
-	f.device = nil
+	f.transport.Disconnect()
+	f.transport = nil
 	return nil
 }
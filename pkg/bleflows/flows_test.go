@@ -0,0 +1,61 @@
+package bleflows_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTransport records the calls of a Flow, writes fail.
+type fakeTransport struct {
+	connectErr   error
+	connected    bool
+	disconnected bool
+}
+
+func (t *fakeTransport) Connect() error {
+	t.connected = t.connectErr == nil
+	return t.connectErr
+}
+func (t *fakeTransport) DiscoverPairing() error        { return nil }
+func (t *fakeTransport) DiscoverKeyturnerUsdio() error { return nil }
+func (t *fakeTransport) WritePairing(ctx context.Context, data []byte) ([]byte, error) {
+	return nil, errors.New("not implemented")
+}
+func (t *fakeTransport) WriteUsdio(ctx context.Context, data []byte) ([]byte, error) {
+	return nil, errors.New("not implemented")
+}
+func (t *fakeTransport) WriteUsdioStream(ctx context.Context, data []byte) (<-chan []byte, func()) {
+	return make(chan []byte), func() {}
+}
+func (t *fakeTransport) IsOpener() bool { return false }
+func (t *fakeTransport) Disconnect()    { t.disconnected = true }
+
+type staticAuthStore struct{}
+
+func (staticAuthStore) Load(deviceId string) (*bleflows.AuthorizeContext, error) {
+	return &bleflows.AuthorizeContext{SharedKey: make([]byte, 32), AuthId: []byte{1, 0, 0, 0}}, nil
+}
+func (staticAuthStore) Store(deviceId string, ctx *bleflows.AuthorizeContext) error { return nil }
+func (staticAuthStore) List() ([]string, error)                                     { return []string{"dev"}, nil }
+func (staticAuthStore) Delete(deviceId string) error                                { return nil }
+
+func TestFlowConnectsTransport(t *testing.T) {
+	transport := &fakeTransport{}
+	flow, err := bleflows.NewAuthenticatedFlow(transport, "dev", staticAuthStore{})
+	require.NoError(t, err)
+	require.True(t, transport.connected)
+
+	require.NoError(t, flow.DisconnectDevice())
+	require.True(t, transport.disconnected)
+	require.Error(t, flow.DisconnectDevice())
+}
+
+func TestFlowConnectError(t *testing.T) {
+	transport := &fakeTransport{connectErr: errors.New("out of range")}
+	_, err := bleflows.NewUnauthenticatedFlow(transport, "dev", staticAuthStore{})
+	require.ErrorContains(t, err, "cannot connect to device dev. out of range")
+}
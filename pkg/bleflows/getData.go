@@ -3,20 +3,34 @@ package bleflows
 import (
 	"context"
 	"fmt"
-	"log/slog"
 
 	"github.com/nuki-io/nuki-cli/pkg/blecommands"
 )
 
+// GetConfig reads the configuration of the device. For an Opener, the fields it has in common with
+// the Smart Lock are returned and DeviceType is set to DeviceTypeOpener, see GetOpenerConfig for the full configuration.
 func (f *Flow) GetConfig(ctx context.Context) (*blecommands.Config, error) {
+	res, err := f.requestConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := toConfig(res)
+	if err != nil {
+		return nil, err
+	}
+	f.deviceType = &cfg.DeviceType
+	return cfg, nil
+}
+
+func (f *Flow) requestConfig(ctx context.Context) (blecommands.Response, error) {
 	nonce, err := f.getChallenge(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get challenge from device: %w", err)
 	}
 
 	cfg := &blecommands.RequestConfig{Nonce: nonce}
-	msg := f.handler.ToEncryptedMessage(cfg, GetNonce24())
-	raw, err := f.device.WriteUsdio(ctx, msg)
+	msg := f.encrypt(cfg)
+	raw, err := f.transport.WriteUsdio(ctx, msg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get config from device: %w", err)
 	}
@@ -24,14 +38,24 @@ func (f *Flow) GetConfig(ctx context.Context) (*blecommands.Config, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to get config from device: %w", err)
 	}
+	return res, nil
+}
 
-	return res.(*blecommands.Config), nil
+// toConfig converts a Smart Lock or Opener config response to a Config.
+func toConfig(res blecommands.Command) (*blecommands.Config, error) {
+	switch c := res.(type) {
+	case *blecommands.Config:
+		return c, nil
+	case *blecommands.OpenerConfig:
+		return c.Config(), nil
+	}
+	return nil, fmt.Errorf("unexpected response to config request: %s", res.GetCommandCode())
 }
 
 func (f *Flow) RequestData(ctx context.Context, cmd blecommands.CommandCode) (*blecommands.Response, error) {
 	cfg := &blecommands.RequestData{CommandIdentifier: cmd}
-	msg := f.handler.ToEncryptedMessage(cfg, GetNonce24())
-	raw, err := f.device.WriteUsdio(ctx, msg)
+	msg := f.encrypt(cfg)
+	raw, err := f.transport.WriteUsdio(ctx, msg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to request data from device: %w", err)
 	}
@@ -50,48 +74,23 @@ func (f *Flow) GetStatus(ctx context.Context) (*blecommands.KeyturnerStates, err
 	}
 	state, ok := (*res).(*blecommands.KeyturnerStates)
 	if !ok {
+		if _, ok := (*res).(*blecommands.OpenerStates); ok {
+			return nil, fmt.Errorf("device is an Opener, use GetOpenerStatus instead")
+		}
 		return nil, fmt.Errorf("failed to cast response to KeyturnerStates: %w", err)
 	}
 	return state, nil
 }
 
-func (f *Flow) GetLogs(ctx context.Context, start int, count int) ([]blecommands.LogEntry, error) {
-	nonce, err := f.getChallenge(ctx)
+// GetBatteryReport reads the detailed battery report of the device.
+func (f *Flow) GetBatteryReport(ctx context.Context) (*blecommands.BatteryReport, error) {
+	res, err := f.RequestData(ctx, blecommands.CommandBatteryReport)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get challenge from device: %w", err)
-	}
-
-	cfg := &blecommands.RequestLogEntries{
-		StartIndex:  uint32(start),
-		Count:       uint16(count),
-		Nonce:       nonce,
-		SortOrder:   blecommands.LogSortOrderDescending,
-		TotalCount:  0x00,
-		SecurityPin: blecommands.NewPin(f.authCtx.Pin),
+		return nil, fmt.Errorf("failed to request battery report: %w", err)
 	}
-	msg := f.handler.ToEncryptedMessage(cfg, GetNonce24())
-	ch, stop := f.device.WriteUsdioStream(ctx, msg)
-	defer stop()
-
-	var entries []blecommands.LogEntry
-	for {
-		select {
-		case buf := <-ch:
-			res, err := f.handler.FromEncryptedDeviceResponse(buf)
-			if err != nil {
-				return nil, fmt.Errorf("failed to decrypt log response: %w", err)
-			}
-			slog.Debug("Received log entry response", "cmd", res.GetCommandCode(), "payload", res)
-			switch r := res.(type) {
-			case *blecommands.LogEntry:
-				entries = append(entries, *r)
-			case *blecommands.Status:
-				if r.Status == blecommands.StatusComplete {
-					return entries, nil
-				}
-			}
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		}
+	report, ok := (*res).(*blecommands.BatteryReport)
+	if !ok {
+		return nil, fmt.Errorf("unexpected response to battery report request: %s", (*res).GetCommandCode())
 	}
+	return report, nil
 }
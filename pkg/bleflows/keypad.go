@@ -0,0 +1,254 @@
+package bleflows
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/nuki-io/nuki-cli/pkg/blecommands"
+)
+
+// keypadCodePageSize is the number of keypad codes requested per round trip by GetAllKeypadCodes.
+const keypadCodePageSize = 20
+
+// GetKeypadCodes reads count keypad codes starting at offset. The device streams one KeypadCode
+// per packet, preceded by the total KeypadCodeCount, until it signals StatusComplete.
+func (f *Flow) GetKeypadCodes(ctx context.Context, offset int, count int) ([]blecommands.KeypadCode, *blecommands.KeypadCodeCount, error) {
+	nonce, err := f.getChallenge(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get challenge from device: %w", err)
+	}
+
+	req := &blecommands.RequestKeypadCodes{
+		Offset:      uint16(offset),
+		Count:       uint16(count),
+		Nonce:       nonce,
+		SecurityPin: blecommands.NewPin(f.authCtx.Pin),
+	}
+	msg := f.encrypt(req)
+	ch, stop := f.transport.WriteUsdioStream(ctx, msg)
+	defer stop()
+
+	var codes []blecommands.KeypadCode
+	var total *blecommands.KeypadCodeCount
+	for {
+		select {
+		case buf := <-ch:
+			res, err := f.handler.FromEncryptedDeviceResponse(buf)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to decrypt keypad code response: %w", err)
+			}
+			slog.Debug("Received keypad code response", "cmd", res.GetCommandCode(), "payload", res)
+			switch r := res.(type) {
+			case *blecommands.KeypadCode:
+				codes = append(codes, *r)
+			case *blecommands.KeypadCodeCount:
+				total = r
+			case *blecommands.Status:
+				if r.Status == blecommands.StatusComplete {
+					return codes, total, nil
+				}
+			}
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+	}
+}
+
+// GetAllKeypadCodes pages through all keypad codes stored on the device.
+func (f *Flow) GetAllKeypadCodes(ctx context.Context) ([]blecommands.KeypadCode, error) {
+	var all []blecommands.KeypadCode
+	for {
+		codes, total, err := f.GetKeypadCodes(ctx, len(all), keypadCodePageSize)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, codes...)
+		if len(codes) < keypadCodePageSize || (total != nil && len(all) >= int(total.Count)) {
+			return all, nil
+		}
+	}
+}
+
+// GetKeypadCode looks up a single keypad code by its ID.
+func (f *Flow) GetKeypadCode(ctx context.Context, codeId uint16) (*blecommands.KeypadCode, error) {
+	codes, err := f.GetAllKeypadCodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range codes {
+		if c.CodeId == codeId {
+			return &c, nil
+		}
+	}
+	return nil, fmt.Errorf("no keypad code with ID %d found", codeId)
+}
+
+// AddKeypadCode stores a new keypad code on the device and returns the ID assigned to it.
+// The code is validated before anything is sent to the device.
+func (f *Flow) AddKeypadCode(ctx context.Context, code *blecommands.KeypadCode) (uint16, error) {
+	if err := blecommands.ValidateKeypadCode(code.Code); err != nil {
+		return 0, err
+	}
+	nonce, err := f.getChallenge(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get challenge: %w", err)
+	}
+	req := &blecommands.AddKeypadCode{
+		Code:        code.Code,
+		Name:        code.Name,
+		TimeLimit:   code.TimeLimit,
+		Nonce:       nonce,
+		SecurityPin: blecommands.NewPin(f.authCtx.Pin),
+	}
+	msg := f.encrypt(req)
+	ch, stop := f.transport.WriteUsdioStream(ctx, msg)
+	defer stop()
+
+	for {
+		select {
+		case buf := <-ch:
+			res, err := f.handler.FromEncryptedDeviceResponse(buf)
+			if err != nil {
+				return 0, fmt.Errorf("failed to add keypad code: %w", err)
+			}
+			slog.Debug("Received add keypad code response", "cmd", res.GetCommandCode(), "payload", res)
+			switch r := res.(type) {
+			case *blecommands.KeypadCodeID:
+				return r.CodeId, nil
+			case *blecommands.Status:
+				if r.Status == blecommands.StatusComplete {
+					return 0, fmt.Errorf("failed to add keypad code: device completed without sending the keypad code ID")
+				}
+			}
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+// UpdateKeypadCode writes code, name, enabled state and time limit of the given keypad code to the device.
+// The code is validated before anything is sent to the device.
+func (f *Flow) UpdateKeypadCode(ctx context.Context, code *blecommands.KeypadCode) error {
+	if err := blecommands.ValidateKeypadCode(code.Code); err != nil {
+		return err
+	}
+	nonce, err := f.getChallenge(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get challenge: %w", err)
+	}
+	return f.performSimpleOp(ctx, &blecommands.UpdateKeypadCode{
+		CodeId:      code.CodeId,
+		Code:        code.Code,
+		Name:        code.Name,
+		Enabled:     code.Enabled,
+		TimeLimit:   code.TimeLimit,
+		Nonce:       nonce,
+		SecurityPin: blecommands.NewPin(f.authCtx.Pin),
+	})
+}
+
+// RemoveKeypadCode deletes the keypad code with the given ID from the device.
+func (f *Flow) RemoveKeypadCode(ctx context.Context, codeId uint16) error {
+	nonce, err := f.getChallenge(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get challenge: %w", err)
+	}
+	return f.performSimpleOp(ctx, &blecommands.RemoveKeypadCode{
+		CodeId:      codeId,
+		Nonce:       nonce,
+		SecurityPin: blecommands.NewPin(f.authCtx.Pin),
+	})
+}
+
+// KeypadCodeChanges lists the operations needed to turn the keypad codes stored on a device into the desired ones.
+type KeypadCodeChanges struct {
+	Add    []blecommands.KeypadCode `json:"add"`
+	Update []KeypadCodeUpdate       `json:"update"`
+	Remove []blecommands.KeypadCode `json:"remove"`
+}
+
+// KeypadCodeUpdate replaces an existing keypad code (From) with a new definition (To) under the same code ID.
+type KeypadCodeUpdate struct {
+	From blecommands.KeypadCode `json:"from"`
+	To   blecommands.KeypadCode `json:"to"`
+}
+
+// IsEmpty reports whether the device already matches the desired keypad codes.
+func (c *KeypadCodeChanges) IsEmpty() bool {
+	return len(c.Add) == 0 && len(c.Update) == 0 && len(c.Remove) == 0
+}
+
+// DiffKeypadCodes compares the keypad codes currently stored on the device with the desired ones.
+// Codes are matched by name, since code IDs are assigned by the device and differ between devices.
+// A matched code is updated if its code, enabled state or time limit differs.
+func DiffKeypadCodes(current, desired []blecommands.KeypadCode) *KeypadCodeChanges {
+	changes := &KeypadCodeChanges{}
+	matched := make([]bool, len(current))
+	for _, d := range desired {
+		found := false
+		for i, c := range current {
+			if matched[i] || c.Name != d.Name {
+				continue
+			}
+			matched[i] = true
+			found = true
+			if c.Code != d.Code || c.Enabled != d.Enabled || !sameTimeLimit(c.TimeLimit, d.TimeLimit) {
+				d.CodeId = c.CodeId
+				changes.Update = append(changes.Update, KeypadCodeUpdate{From: c, To: d})
+			}
+			break
+		}
+		if !found {
+			changes.Add = append(changes.Add, d)
+		}
+	}
+	for i, c := range current {
+		if !matched[i] {
+			changes.Remove = append(changes.Remove, c)
+		}
+	}
+	return changes
+}
+
+// sameTimeLimit reports whether a and b restrict access in the same way. The restrictions are ignored
+// if the code is not time limited.
+func sameTimeLimit(a, b blecommands.TimeLimit) bool {
+	if !a.TimeLimited || !b.TimeLimited {
+		return a.TimeLimited == b.TimeLimited
+	}
+	return a.AllowedFromDate.Equal(b.AllowedFromDate) &&
+		a.AllowedUntilDate.Equal(b.AllowedUntilDate) &&
+		a.AllowedWeekdays == b.AllowedWeekdays &&
+		a.AllowedFromTime == b.AllowedFromTime &&
+		a.AllowedUntilTime == b.AllowedUntilTime
+}
+
+// ApplyKeypadCodeChanges sends the given changes to the device. Removals are applied first
+// to free up slots on the device.
+func (f *Flow) ApplyKeypadCodeChanges(ctx context.Context, changes *KeypadCodeChanges) error {
+	for _, c := range changes.Remove {
+		if err := f.RemoveKeypadCode(ctx, c.CodeId); err != nil {
+			return fmt.Errorf("failed to remove keypad code %q: %w", c.Name, err)
+		}
+	}
+	for _, u := range changes.Update {
+		if err := f.UpdateKeypadCode(ctx, &u.To); err != nil {
+			return fmt.Errorf("failed to update keypad code %q: %w", u.To.Name, err)
+		}
+	}
+	for _, c := range changes.Add {
+		id, err := f.AddKeypadCode(ctx, &c)
+		if err != nil {
+			return fmt.Errorf("failed to add keypad code %q: %w", c.Name, err)
+		}
+		// new codes are always created enabled
+		if !c.Enabled {
+			c.CodeId = id
+			if err := f.UpdateKeypadCode(ctx, &c); err != nil {
+				return fmt.Errorf("failed to disable keypad code %q: %w", c.Name, err)
+			}
+		}
+	}
+	return nil
+}
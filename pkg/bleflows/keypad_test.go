@@ -0,0 +1,99 @@
+package bleflows_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nuki-io/nuki-cli/pkg/blecommands"
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffKeypadCodes(t *testing.T) {
+	current := []blecommands.KeypadCode{
+		{CodeId: 1, Enabled: true, Code: 345678, Name: "Family", LockCount: 12},
+		{CodeId: 2, Enabled: true, Code: 456789, Name: "Cleaning"},
+		{CodeId: 3, Enabled: true, Code: 567893, Name: "Guest"},
+	}
+	desired := []blecommands.KeypadCode{
+		// unchanged, statistics are ignored
+		{Enabled: true, Code: 345678, Name: "Family"},
+		// restricted to fridays
+		{Enabled: true, Code: 456789, Name: "Cleaning", TimeLimit: blecommands.TimeLimit{TimeLimited: true, AllowedWeekdays: blecommands.Friday}},
+		// new
+		{Enabled: false, Code: 678934, Name: "Plumber"},
+	}
+
+	changes := bleflows.DiffKeypadCodes(current, desired)
+	require.Equal(t, []blecommands.KeypadCode{desired[2]}, changes.Add)
+	require.Equal(t, []blecommands.KeypadCode{current[2]}, changes.Remove)
+	require.Len(t, changes.Update, 1)
+	require.Equal(t, uint16(2), changes.Update[0].To.CodeId)
+	require.True(t, changes.Update[0].To.TimeLimit.TimeLimited)
+
+	require.True(t, bleflows.DiffKeypadCodes(current, current).IsEmpty())
+}
+
+// scriptedTransport answers challenges and streams the given responses to every streamed request.
+type scriptedTransport struct {
+	fakeTransport
+	handler *blecommands.BleHandler
+	stream  []blecommands.Request
+}
+
+func newScriptedTransport(stream ...blecommands.Request) *scriptedTransport {
+	auth, _ := pinAuthStore{}.Load("dev")
+	return &scriptedTransport{
+		handler: blecommands.NewBleHandler(blecommands.NewCrypto(auth.SharedKey), auth.AuthId),
+		stream:  stream,
+	}
+}
+
+func (t *scriptedTransport) WriteUsdio(ctx context.Context, data []byte) ([]byte, error) {
+	return t.handler.ToEncryptedMessage(&blecommands.Challenge{Nonce: make([]byte, 32)}, make([]byte, 24)), nil
+}
+
+func (t *scriptedTransport) WriteUsdioStream(ctx context.Context, data []byte) (<-chan []byte, func()) {
+	ch := make(chan []byte, len(t.stream))
+	for _, res := range t.stream {
+		ch <- t.handler.ToEncryptedMessage(res, make([]byte, 24))
+	}
+	return ch, func() {}
+}
+
+// rawResponse is a response of the device for commands that are only decoded by the CLI.
+type rawResponse struct {
+	code    blecommands.CommandCode
+	payload []byte
+}
+
+func (r *rawResponse) GetCommandCode() blecommands.CommandCode { return r.code }
+func (r *rawResponse) GetPayload() []byte                      { return r.payload }
+
+type pinAuthStore struct{ staticAuthStore }
+
+func (pinAuthStore) Load(deviceId string) (*bleflows.AuthorizeContext, error) {
+	return &bleflows.AuthorizeContext{SharedKey: make([]byte, 32), AuthId: []byte{1, 0, 0, 0}, Pin: "123456"}, nil
+}
+
+func TestAddKeypadCode(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	code := &blecommands.KeypadCode{Code: 345678, Name: "Family"}
+
+	transport := newScriptedTransport(&rawResponse{blecommands.CommandKeypadCodeID, []byte{7, 0}}, &blecommands.Status{Status: blecommands.StatusComplete})
+	flow, err := bleflows.NewAuthenticatedFlow(transport, "dev", pinAuthStore{})
+	require.NoError(t, err)
+	id, err := flow.AddKeypadCode(ctx, code)
+	require.NoError(t, err)
+	require.Equal(t, uint16(7), id)
+
+	// without the ID the flow fails right away instead of waiting for the timeout
+	transport = newScriptedTransport(&blecommands.Status{Status: blecommands.StatusComplete})
+	flow, err = bleflows.NewAuthenticatedFlow(transport, "dev", pinAuthStore{})
+	require.NoError(t, err)
+	_, err = flow.AddKeypadCode(ctx, code)
+	require.ErrorContains(t, err, "without sending the keypad code ID")
+	require.NoError(t, ctx.Err())
+}
@@ -8,34 +8,107 @@ import (
 	"github.com/nuki-io/nuki-cli/pkg/blecommands"
 )
 
+// LockProgressType is the kind of a LockProgress.
+type LockProgressType int
+
+const (
+	// LockProgressAccepted is reported when the device accepted the action.
+	LockProgressAccepted LockProgressType = iota
+	// LockProgressStates is reported for every state the device sends while the motor moves.
+	LockProgressStates
+	// LockProgressComplete is reported when the device completed the action.
+	LockProgressComplete
+)
+
+// LockProgress is reported while a lock action is performed, see PerformLockOperationWithProgress.
+type LockProgress struct {
+	Type LockProgressType
+	// States is set for LockProgressStates of a Smart Lock.
+	States *blecommands.KeyturnerStates
+	// OpenerStates is set for LockProgressStates of an Opener.
+	OpenerStates *blecommands.OpenerStates
+}
+
+// PerformLockOperation performs a lock action and waits until the device completed it.
 func (f *Flow) PerformLockOperation(ctx context.Context, action blecommands.Action) error {
+	_, err := f.PerformLockOperationWithProgress(ctx, action, nil)
+	return err
+}
+
+// PerformLockOperationWithProgress performs a lock action and calls progress, if not nil, when the device accepted it,
+// for every state sent while the motor moves and when the device completed it.
+// It returns the states of the lock after the action, which are read from the device if it did not send them.
+// On an Opener, the returned states are nil.
+func (f *Flow) PerformLockOperationWithProgress(ctx context.Context, action blecommands.Action, progress func(LockProgress)) (*blecommands.KeyturnerStates, error) {
 	nonce, err := f.getChallenge(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get challenge from device: %w", err)
+		return nil, fmt.Errorf("failed to get challenge from device: %w", err)
 	}
-
-	lock := &blecommands.LockAction{
+	states, err := f.performLockAction(ctx, &blecommands.LockAction{
 		Action: action,
 		AppId:  f.authCtx.AppId,
 		Nonce:  nonce,
+	}, progress)
+	if err != nil || states != nil || f.transport.IsOpener() {
+		return states, err
+	}
+	states, err = f.GetStatus(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read states after lock action: %w", err)
+	}
+	return states, nil
+}
+
+// PerformOpenerAction executes an action on an Opener. The Opener uses the lock action command with its own action codes.
+func (f *Flow) PerformOpenerAction(ctx context.Context, action blecommands.OpenerAction) error {
+	nonce, err := f.getChallenge(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get challenge from device: %w", err)
+	}
+	_, err = f.performLockAction(ctx, &blecommands.LockAction{
+		Action: blecommands.Action(action),
+		AppId:  f.authCtx.AppId,
+		Nonce:  nonce,
+	}, nil)
+	return err
+}
+
+// performLockAction sends lock and waits until the device reports StatusComplete.
+// It returns the last Smart Lock states sent by the device, if any.
+func (f *Flow) performLockAction(ctx context.Context, lock *blecommands.LockAction, progress func(LockProgress)) (*blecommands.KeyturnerStates, error) {
+	if progress == nil {
+		progress = func(LockProgress) {}
 	}
-	msg := f.handler.ToEncryptedMessage(lock, GetNonce24())
-	ch, stop := f.device.WriteUsdioStream(ctx, msg)
+	msg := f.encrypt(lock)
+	ch, stop := f.transport.WriteUsdioStream(ctx, msg)
 	defer stop()
 
+	var states *blecommands.KeyturnerStates
 	for {
 		select {
 		case buf := <-ch:
 			res, err := f.handler.FromEncryptedDeviceResponse(buf)
 			if err != nil {
-				return fmt.Errorf("failed to decrypt lock response: %w", err)
+				return nil, fmt.Errorf("failed to decrypt lock response: %w", err)
 			}
-			slog.Info("Received lock action response", "cmd", res.GetCommandCode(), "payload", res)
-			if s, ok := res.(*blecommands.Status); ok && s.Status == blecommands.StatusComplete {
-				return nil
+			slog.Debug("Received lock action response", "cmd", res.GetCommandCode(), "payload", res)
+			switch r := res.(type) {
+			case *blecommands.Status:
+				switch r.Status {
+				case blecommands.StatusAccepted:
+					progress(LockProgress{Type: LockProgressAccepted})
+				case blecommands.StatusComplete:
+					progress(LockProgress{Type: LockProgressComplete, States: states})
+					return states, nil
+				}
+			case *blecommands.KeyturnerStates:
+				states = r
+				progress(LockProgress{Type: LockProgressStates, States: r})
+			case *blecommands.OpenerStates:
+				progress(LockProgress{Type: LockProgressStates, OpenerStates: r})
 			}
 		case <-ctx.Done():
-			return ctx.Err()
+			return nil, ctx.Err()
 		}
 	}
 }
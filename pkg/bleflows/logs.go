@@ -34,8 +34,8 @@ func (f *Flow) GetLogs(ctx context.Context, start int, count int, withCount bool
 		TotalCount:  withCount,
 		SecurityPin: blecommands.NewPin(f.authCtx.Pin),
 	}
-	msg := f.handler.ToEncryptedMessage(cfg, GetNonce24())
-	ch, stop := f.device.WriteUsdioStream(ctx, msg)
+	msg := f.encrypt(cfg)
+	ch, stop := f.transport.WriteUsdioStream(ctx, msg)
 	defer stop()
 
 	var entries []blecommands.LogEntry
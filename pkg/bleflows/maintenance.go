@@ -12,8 +12,8 @@ import (
 // performSimpleOp sends a command that requires a challenge+PIN and waits for StatusComplete.
 // The caller provides an already-built request (with nonce and pin already set).
 func (f *Flow) performSimpleOp(ctx context.Context, req blecommands.Request) error {
-	msg := f.handler.ToEncryptedMessage(req, GetNonce24())
-	ch, stop := f.device.WriteUsdioStream(ctx, msg)
+	msg := f.encrypt(req)
+	ch, stop := f.transport.WriteUsdioStream(ctx, msg)
 	defer stop()
 
 	for {
@@ -95,15 +95,3 @@ func (f *Flow) VerifyPIN(ctx context.Context) error {
 		SecurityPin: blecommands.NewPin(f.authCtx.Pin),
 	})
 }
-
-func (f *Flow) EnableLogging(ctx context.Context, enabled bool) error {
-	nonce, err := f.getChallenge(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to get challenge: %w", err)
-	}
-	return f.performSimpleOp(ctx, &blecommands.EnableLogging{
-		Enabled:     enabled,
-		Nonce:       nonce,
-		SecurityPin: blecommands.NewPin(f.authCtx.Pin),
-	})
-}
@@ -0,0 +1,59 @@
+package bleflows
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nuki-io/nuki-cli/pkg/blecommands"
+)
+
+// DeviceType returns the type of the device as reported by Config.DeviceType.
+// The config is only read if it has not been read by this flow before.
+func (f *Flow) DeviceType(ctx context.Context) (blecommands.DeviceType, error) {
+	if f.deviceType == nil {
+		if _, err := f.GetConfig(ctx); err != nil {
+			return 0, err
+		}
+	}
+	return *f.deviceType, nil
+}
+
+// requireSmartLock returns an error if the device is an Opener, for operations only implemented for the Smart Lock.
+func (f *Flow) requireSmartLock(ctx context.Context, operation string) error {
+	t, err := f.DeviceType(ctx)
+	if err != nil {
+		return err
+	}
+	if t == blecommands.DeviceTypeOpener {
+		return fmt.Errorf("%s is not supported for the Opener", operation)
+	}
+	return nil
+}
+
+// GetOpenerStatus reads the states of an Opener.
+func (f *Flow) GetOpenerStatus(ctx context.Context) (*blecommands.OpenerStates, error) {
+	res, err := f.RequestData(ctx, blecommands.CommandKeyturnerStates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request opener states: %w", err)
+	}
+	state, ok := (*res).(*blecommands.OpenerStates)
+	if !ok {
+		return nil, fmt.Errorf("device is not an Opener")
+	}
+	return state, nil
+}
+
+// GetOpenerConfig reads the full configuration of an Opener.
+func (f *Flow) GetOpenerConfig(ctx context.Context) (*blecommands.OpenerConfig, error) {
+	res, err := f.requestConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cfg, ok := res.(*blecommands.OpenerConfig)
+	if !ok {
+		return nil, fmt.Errorf("device is not an Opener")
+	}
+	t := blecommands.DeviceTypeOpener
+	f.deviceType = &t
+	return cfg, nil
+}
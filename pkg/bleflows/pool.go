@@ -0,0 +1,106 @@
+package bleflows
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+
+	"github.com/nuki-io/nuki-cli/pkg/blecommands"
+)
+
+// Dialer returns a transport to the device with the given ID. The transport is connected by the flow.
+type Dialer func(deviceId string) (Transport, error)
+
+// Pool keeps one authenticated flow per device open between uses and serializes the use of each flow,
+// since a device only handles one command exchange at a time.
+type Pool struct {
+	dial  Dialer
+	store AuthStore
+
+	mu      sync.Mutex
+	devices map[string]*pooledFlow
+}
+
+type pooledFlow struct {
+	mu   sync.Mutex
+	flow *Flow
+}
+
+// NewPool returns a pool that connects to the devices paired in store through dial.
+func NewPool(dial Dialer, store AuthStore) *Pool {
+	return &Pool{
+		dial:    dial,
+		store:   store,
+		devices: map[string]*pooledFlow{},
+	}
+}
+
+// Do calls fn with the flow of the device with the given id, connecting to the device if required.
+// If fn fails on a flow that was kept from a previous use, the device may have closed the connection
+// in between, so fn is retried once on a new connection. It is not retried if the device answered with
+// an error, since the connection works then, or if fn wrote a request changing the device, e.g. a lock action,
+// since the device may have carried it out.
+func (p *Pool) Do(ctx context.Context, id string, fn func(ctx context.Context, flow *Flow) error) error {
+	d := p.device(id)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for attempt := 0; ; attempt++ {
+		reused := d.flow != nil
+		if !reused {
+			flow, err := p.connect(id)
+			if err != nil {
+				return err
+			}
+			d.flow = flow
+		}
+		d.flow.resetChanges()
+		err := fn(ctx, d.flow)
+		if err == nil {
+			return nil
+		}
+		changed := d.flow.mayHaveChanged(err)
+		d.flow.DisconnectDevice()
+		d.flow = nil
+		var deviceErr *blecommands.DeviceError
+		if !reused || attempt > 0 || changed || errors.As(err, &deviceErr) || ctx.Err() != nil {
+			return err
+		}
+		slog.Debug("Retrying on new connection", "device", id, "error", err)
+	}
+}
+
+// Close disconnects all devices.
+func (p *Pool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for id, d := range p.devices {
+		d.mu.Lock()
+		if d.flow != nil {
+			d.flow.DisconnectDevice()
+			d.flow = nil
+		}
+		d.mu.Unlock()
+		delete(p.devices, id)
+	}
+}
+
+func (p *Pool) connect(id string) (*Flow, error) {
+	transport, err := p.dial(id)
+	if err != nil {
+		return nil, err
+	}
+	return NewAuthenticatedFlow(transport, id, p.store)
+}
+
+func (p *Pool) device(id string) *pooledFlow {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	d, ok := p.devices[id]
+	if !ok {
+		d = &pooledFlow{}
+		p.devices[id] = d
+	}
+	return d
+}
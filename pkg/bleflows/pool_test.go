@@ -0,0 +1,90 @@
+package bleflows_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nuki-io/nuki-cli/pkg/blecommands"
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+	"github.com/nuki-io/nuki-cli/pkg/nukisim"
+	"github.com/nuki-io/nuki-cli/pkg/nukisim/nukisimtest"
+	"github.com/stretchr/testify/require"
+)
+
+// closedTransport is a transport whose connection can be closed by the device, writes fail afterwards.
+type closedTransport struct {
+	bleflows.Transport
+	closed bool
+}
+
+func (t *closedTransport) WriteUsdio(ctx context.Context, data []byte) ([]byte, error) {
+	if t.closed {
+		return nil, errors.New("connection closed")
+	}
+	return t.Transport.WriteUsdio(ctx, data)
+}
+
+// newPool returns a pool connected to a paired simulator and the transports it dialed.
+func newPool(t *testing.T) (*nukisim.Simulator, *bleflows.Pool, *[]*closedTransport) {
+	t.Helper()
+	sim, store := nukisimtest.Paired(t)
+	var transports []*closedTransport
+	pool := bleflows.NewPool(func(deviceId string) (bleflows.Transport, error) {
+		transport := &closedTransport{Transport: sim.Connect()}
+		transports = append(transports, transport)
+		return transport, nil
+	}, store)
+	t.Cleanup(pool.Close)
+
+	err := pool.Do(context.Background(), nukisimtest.PairedDeviceId, func(ctx context.Context, flow *bleflows.Flow) error {
+		_, err := flow.GetStatus(ctx)
+		return err
+	})
+	require.NoError(t, err)
+	return sim, pool, &transports
+}
+
+func TestPoolReconnects(t *testing.T) {
+	sim, pool, transports := newPool(t)
+	(*transports)[0].closed = true
+
+	calls := 0
+	err := pool.Do(context.Background(), nukisimtest.PairedDeviceId, func(ctx context.Context, flow *bleflows.Flow) error {
+		calls++
+		return flow.PerformLockOperation(ctx, blecommands.Unlock)
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, calls)
+	require.Len(t, *transports, 2)
+	require.Equal(t, blecommands.LockStateUnlocked, sim.KeyturnerStates().LockState)
+}
+
+func TestPoolDoesNotRepeatActions(t *testing.T) {
+	_, pool, transports := newPool(t)
+
+	calls := 0
+	err := pool.Do(context.Background(), nukisimtest.PairedDeviceId, func(ctx context.Context, flow *bleflows.Flow) error {
+		calls++
+		if err := flow.PerformLockOperation(ctx, blecommands.Unlatch); err != nil {
+			return err
+		}
+		(*transports)[0].closed = true
+		_, err := flow.GetStatus(ctx)
+		return err
+	})
+	require.ErrorContains(t, err, "connection closed")
+	require.Equal(t, 1, calls)
+}
+
+func TestPoolDoesNotRetryDeviceErrors(t *testing.T) {
+	_, pool, _ := newPool(t)
+
+	calls := 0
+	err := pool.Do(context.Background(), nukisimtest.PairedDeviceId, func(ctx context.Context, flow *bleflows.Flow) error {
+		calls++
+		return blecommands.NewDeviceError(blecommands.ErrMotorBlocked.Code, blecommands.CommandLockAction)
+	})
+	require.ErrorIs(t, err, blecommands.ErrMotorBlocked)
+	require.Equal(t, 1, calls)
+}
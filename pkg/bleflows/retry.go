@@ -0,0 +1,123 @@
+package bleflows
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/nuki-io/nuki-cli/pkg/blecommands"
+)
+
+var (
+	// ErrConnect is wrapped by the errors of failed connection attempts.
+	ErrConnect = errors.New("cannot connect to device")
+	// ErrDiscovery is wrapped by the errors of failed service discovery.
+	ErrDiscovery = errors.New("service discovery failed")
+)
+
+// RetryPolicy retries operations on a device that fail because of a flaky connection, see Do.
+type RetryPolicy struct {
+	// Attempts is the maximum number of attempts, values below 1 are treated as 1.
+	Attempts int
+	// Backoff is the time to wait before the second attempt, it doubles with every further attempt up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// AttemptTimeout limits each attempt, 0 only limits all attempts together by the context.
+	AttemptTimeout time.Duration
+	// Retryable reports whether an attempt that failed with err is retried. If nil, IsRetryable is used.
+	Retryable func(err error) bool
+}
+
+// DefaultRetryPolicy tries three times.
+var DefaultRetryPolicy = RetryPolicy{
+	Attempts:   3,
+	Backoff:    500 * time.Millisecond,
+	MaxBackoff: 5 * time.Second,
+}
+
+// IsRetryable reports whether an operation that failed with err may succeed on another attempt: failures to connect
+// or to discover the services, timeouts and transient device errors like K_ERROR_BUSY and K_ERROR_BAD_NONCE,
+// see blecommands.IsTransientError. RetryPolicy.Do only retries them as long as the device did not carry out
+// anything, so a timed out lock action is not sent again.
+func IsRetryable(err error) bool {
+	if blecommands.IsTransientError(err) {
+		return true
+	}
+	return errors.Is(err, ErrConnect) || errors.Is(err, ErrDiscovery) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// RetryError is returned by RetryPolicy.Do if more than one attempt failed. It wraps the errors of all attempts.
+type RetryError struct {
+	Errors []error
+}
+
+func (e *RetryError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = fmt.Sprintf("attempt %d: %s", i+1, err)
+	}
+	return fmt.Sprintf("failed after %d attempts: %s", len(e.Errors), strings.Join(msgs, "; "))
+}
+
+func (e *RetryError) Unwrap() []error {
+	return e.Errors
+}
+
+// Do connects with connect and calls fn with the flow. If connecting or fn fail with a retryable error,
+// the device is disconnected and both are retried after the backoff. Since connect is called again,
+// it can scan for the device again, fn requests a new challenge with every command.
+// fn is not retried once it wrote a request changing the device that the device may have carried out,
+// e.g. a lock action whose response timed out, since all of fn would be performed again.
+// The flow is disconnected when Do returns.
+func (p RetryPolicy) Do(ctx context.Context, connect func() (*Flow, error), fn func(ctx context.Context, flow *Flow) error) error {
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = IsRetryable
+	}
+	backoff := p.Backoff
+	var errs []error
+	for attempt := 1; ; attempt++ {
+		changed, err := p.attempt(ctx, connect, fn)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+		if attempt >= p.Attempts || changed || !retryable(err) || ctx.Err() != nil {
+			break
+		}
+		slog.Warn("Attempt failed, retrying", "attempt", attempt, "attempts", p.Attempts, "backoff", backoff, "error", err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			errs = append(errs, ctx.Err())
+			return &RetryError{Errors: errs}
+		}
+		backoff *= 2
+		if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+			backoff = p.MaxBackoff
+		}
+	}
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return &RetryError{Errors: errs}
+}
+
+// attempt connects and calls fn once. It reports whether the device may have been changed, see Flow.mayHaveChanged.
+func (p RetryPolicy) attempt(ctx context.Context, connect func() (*Flow, error), fn func(ctx context.Context, flow *Flow) error) (bool, error) {
+	flow, err := connect()
+	if err != nil {
+		return false, err
+	}
+	defer flow.DisconnectDevice()
+	if p.AttemptTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.AttemptTimeout)
+		defer cancel()
+	}
+	err = fn(ctx, flow)
+	return err != nil && flow.mayHaveChanged(err), err
+}
@@ -0,0 +1,110 @@
+package bleflows_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nuki-io/nuki-cli/pkg/blecommands"
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+	"github.com/nuki-io/nuki-cli/pkg/nukisim/nukisimtest"
+	"github.com/stretchr/testify/require"
+)
+
+var testRetryPolicy = bleflows.RetryPolicy{Attempts: 3, Backoff: time.Millisecond}
+
+// failingConnect returns a connect function whose first failures attempts fail to connect.
+func failingConnect(failures int, transports *[]*fakeTransport) func() (*bleflows.Flow, error) {
+	return func() (*bleflows.Flow, error) {
+		transport := &fakeTransport{}
+		if len(*transports) < failures {
+			transport.connectErr = errors.New("out of range")
+		}
+		*transports = append(*transports, transport)
+		return bleflows.NewAuthenticatedFlow(transport, "dev", staticAuthStore{})
+	}
+}
+
+func TestRetryConnect(t *testing.T) {
+	var transports []*fakeTransport
+	calls := 0
+	err := testRetryPolicy.Do(context.Background(), failingConnect(2, &transports), func(ctx context.Context, flow *bleflows.Flow) error {
+		calls++
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, calls)
+	require.Len(t, transports, 3)
+	require.True(t, transports[2].disconnected)
+}
+
+func TestRetryExhausted(t *testing.T) {
+	var transports []*fakeTransport
+	err := testRetryPolicy.Do(context.Background(), failingConnect(5, &transports), func(ctx context.Context, flow *bleflows.Flow) error {
+		return nil
+	})
+	var retryErr *bleflows.RetryError
+	require.ErrorAs(t, err, &retryErr)
+	require.Len(t, retryErr.Errors, 3)
+	require.ErrorIs(t, err, bleflows.ErrConnect)
+	require.ErrorContains(t, err, "failed after 3 attempts: attempt 1: cannot connect to device dev. out of range")
+}
+
+func TestRetryDeviceError(t *testing.T) {
+	var transports []*fakeTransport
+	calls := 0
+	err := testRetryPolicy.Do(context.Background(), failingConnect(0, &transports), func(ctx context.Context, flow *bleflows.Flow) error {
+		calls++
+		if calls == 1 {
+			return fmt.Errorf("failed to lock: %w", blecommands.NewDeviceError(blecommands.ErrBusy.Code, blecommands.CommandLockAction))
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, calls)
+	require.True(t, transports[0].disconnected)
+}
+
+func TestRetryNotRetryable(t *testing.T) {
+	var transports []*fakeTransport
+	badPin := blecommands.NewDeviceError(blecommands.ErrBadPin.Code, blecommands.CommandRequestLogEntries)
+	err := testRetryPolicy.Do(context.Background(), failingConnect(0, &transports), func(ctx context.Context, flow *bleflows.Flow) error {
+		return badPin
+	})
+	require.Equal(t, badPin, err)
+	require.Len(t, transports, 1)
+}
+
+func TestRetryTimeoutBeforeChange(t *testing.T) {
+	var transports []*fakeTransport
+	calls := 0
+	err := testRetryPolicy.Do(context.Background(), failingConnect(0, &transports), func(ctx context.Context, flow *bleflows.Flow) error {
+		calls++
+		if calls == 1 {
+			return fmt.Errorf("failed to get challenge: %w", context.DeadlineExceeded)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, calls)
+}
+
+func TestRetryNotAfterChange(t *testing.T) {
+	sim, store := nukisimtest.Paired(t)
+	sim.SetActionDuration(time.Second)
+	connect := func() (*bleflows.Flow, error) {
+		return bleflows.NewAuthenticatedFlow(sim.Connect(), nukisimtest.PairedDeviceId, store)
+	}
+	policy := testRetryPolicy
+	policy.AttemptTimeout = 50 * time.Millisecond
+	calls := 0
+	err := policy.Do(context.Background(), connect, func(ctx context.Context, flow *bleflows.Flow) error {
+		calls++
+		return flow.PerformLockOperation(ctx, blecommands.Unlatch)
+	})
+	// the device may have unlatched already, so the action must not be sent again
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Equal(t, 1, calls)
+}
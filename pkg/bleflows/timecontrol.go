@@ -0,0 +1,207 @@
+package bleflows
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/nuki-io/nuki-cli/pkg/blecommands"
+)
+
+// GetTimeControlEntries reads all time control entries (scheduled lock actions) stored on the device.
+func (f *Flow) GetTimeControlEntries(ctx context.Context) ([]blecommands.TimeControlEntry, error) {
+	nonce, err := f.getChallenge(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get challenge from device: %w", err)
+	}
+
+	req := &blecommands.RequestTimeControlEntries{
+		Nonce:       nonce,
+		SecurityPin: blecommands.NewPin(f.authCtx.Pin),
+	}
+	msg := f.encrypt(req)
+	ch, stop := f.transport.WriteUsdioStream(ctx, msg)
+	defer stop()
+
+	var entries []blecommands.TimeControlEntry
+	for {
+		select {
+		case buf := <-ch:
+			res, err := f.handler.FromEncryptedDeviceResponse(buf)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decrypt time control response: %w", err)
+			}
+			slog.Debug("Received time control response", "cmd", res.GetCommandCode(), "payload", res)
+			switch r := res.(type) {
+			case *blecommands.TimeControlEntry:
+				entries = append(entries, *r)
+			case *blecommands.Status:
+				if r.Status == blecommands.StatusComplete {
+					return entries, nil
+				}
+			}
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// AddTimeControlEntry stores a new scheduled lock action on the device and returns the ID assigned to it.
+// New entries are always enabled.
+func (f *Flow) AddTimeControlEntry(ctx context.Context, entry *blecommands.TimeControlEntry) (uint8, error) {
+	nonce, err := f.getChallenge(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get challenge: %w", err)
+	}
+	req := &blecommands.AddTimeControlEntry{
+		Weekdays:    entry.Weekdays,
+		Time:        entry.Time,
+		Action:      entry.Action,
+		Nonce:       nonce,
+		SecurityPin: blecommands.NewPin(f.authCtx.Pin),
+	}
+	msg := f.encrypt(req)
+	ch, stop := f.transport.WriteUsdioStream(ctx, msg)
+	defer stop()
+
+	for {
+		select {
+		case buf := <-ch:
+			res, err := f.handler.FromEncryptedDeviceResponse(buf)
+			if err != nil {
+				return 0, fmt.Errorf("failed to add time control entry: %w", err)
+			}
+			slog.Debug("Received add time control entry response", "cmd", res.GetCommandCode(), "payload", res)
+			if r, ok := res.(*blecommands.TimeControlEntryID); ok {
+				return r.EntryId, nil
+			}
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+// UpdateTimeControlEntry overwrites the time control entry with the ID of the given entry.
+func (f *Flow) UpdateTimeControlEntry(ctx context.Context, entry *blecommands.TimeControlEntry) error {
+	nonce, err := f.getChallenge(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get challenge: %w", err)
+	}
+	return f.performSimpleOp(ctx, &blecommands.UpdateTimeControlEntry{
+		EntryId:     entry.EntryId,
+		Enabled:     entry.Enabled,
+		Weekdays:    entry.Weekdays,
+		Time:        entry.Time,
+		Action:      entry.Action,
+		Nonce:       nonce,
+		SecurityPin: blecommands.NewPin(f.authCtx.Pin),
+	})
+}
+
+// RemoveTimeControlEntry deletes the time control entry with the given ID from the device.
+func (f *Flow) RemoveTimeControlEntry(ctx context.Context, entryId uint8) error {
+	nonce, err := f.getChallenge(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get challenge: %w", err)
+	}
+	return f.performSimpleOp(ctx, &blecommands.RemoveTimeControlEntry{
+		EntryId:     entryId,
+		Nonce:       nonce,
+		SecurityPin: blecommands.NewPin(f.authCtx.Pin),
+	})
+}
+
+// TimeControlChanges lists the operations needed to turn the schedule stored on a device into a desired one.
+type TimeControlChanges struct {
+	Add    []blecommands.TimeControlEntry `json:"add"`
+	Update []TimeControlUpdate            `json:"update"`
+	Remove []blecommands.TimeControlEntry `json:"remove"`
+}
+
+// TimeControlUpdate replaces an existing entry (From) with a new definition (To) under the same entry ID.
+type TimeControlUpdate struct {
+	From blecommands.TimeControlEntry `json:"from"`
+	To   blecommands.TimeControlEntry `json:"to"`
+}
+
+// IsEmpty reports whether the device already matches the desired schedule.
+func (c *TimeControlChanges) IsEmpty() bool {
+	return len(c.Add) == 0 && len(c.Update) == 0 && len(c.Remove) == 0
+}
+
+// DiffTimeControlEntries compares the entries currently stored on the device with the desired ones.
+// Entry IDs of desired entries are ignored: entries are matched by weekdays, time and action.
+// Unmatched current entries are reused for unmatched desired entries before anything is added or removed,
+// to keep the number of commands sent to the device low.
+func DiffTimeControlEntries(current, desired []blecommands.TimeControlEntry) *TimeControlChanges {
+	changes := &TimeControlChanges{}
+	sameSlot := func(a, b blecommands.TimeControlEntry) bool {
+		return a.Weekdays == b.Weekdays && a.Time == b.Time && a.Action == b.Action
+	}
+
+	matched := make([]bool, len(current))
+	var unmatched []blecommands.TimeControlEntry
+	for _, d := range desired {
+		found := false
+		for i, c := range current {
+			if matched[i] || !sameSlot(c, d) {
+				continue
+			}
+			matched[i] = true
+			found = true
+			if c.Enabled != d.Enabled {
+				d.EntryId = c.EntryId
+				changes.Update = append(changes.Update, TimeControlUpdate{From: c, To: d})
+			}
+			break
+		}
+		if !found {
+			unmatched = append(unmatched, d)
+		}
+	}
+
+	for i, c := range current {
+		if matched[i] {
+			continue
+		}
+		if len(unmatched) > 0 {
+			d := unmatched[0]
+			unmatched = unmatched[1:]
+			d.EntryId = c.EntryId
+			changes.Update = append(changes.Update, TimeControlUpdate{From: c, To: d})
+			continue
+		}
+		changes.Remove = append(changes.Remove, c)
+	}
+	changes.Add = unmatched
+	return changes
+}
+
+// ApplyTimeControlChanges sends the given changes to the device. Removals are applied first
+// to free up slots on the device.
+func (f *Flow) ApplyTimeControlChanges(ctx context.Context, changes *TimeControlChanges) error {
+	for _, e := range changes.Remove {
+		if err := f.RemoveTimeControlEntry(ctx, e.EntryId); err != nil {
+			return fmt.Errorf("failed to remove time control entry %d: %w", e.EntryId, err)
+		}
+	}
+	for _, u := range changes.Update {
+		if err := f.UpdateTimeControlEntry(ctx, &u.To); err != nil {
+			return fmt.Errorf("failed to update time control entry %d: %w", u.To.EntryId, err)
+		}
+	}
+	for _, e := range changes.Add {
+		id, err := f.AddTimeControlEntry(ctx, &e)
+		if err != nil {
+			return fmt.Errorf("failed to add time control entry %s: %w", e.String(), err)
+		}
+		// new entries are always created enabled
+		if !e.Enabled {
+			e.EntryId = id
+			if err := f.UpdateTimeControlEntry(ctx, &e); err != nil {
+				return fmt.Errorf("failed to disable time control entry %d: %w", id, err)
+			}
+		}
+	}
+	return nil
+}
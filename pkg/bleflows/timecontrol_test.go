@@ -0,0 +1,44 @@
+package bleflows_test
+
+import (
+	"testing"
+
+	"github.com/nuki-io/nuki-cli/pkg/blecommands"
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffTimeControlEntries(t *testing.T) {
+	morning := blecommands.TimeOfDay{Hour: 7, Minute: 30}
+	evening := blecommands.TimeOfDay{Hour: 22, Minute: 0}
+	noon := blecommands.TimeOfDay{Hour: 12, Minute: 0}
+
+	current := []blecommands.TimeControlEntry{
+		{EntryId: 1, Enabled: true, Weekdays: blecommands.WorkingDays, Time: morning, Action: blecommands.Unlock},
+		{EntryId: 2, Enabled: true, Weekdays: blecommands.AllWeekdays, Time: evening, Action: blecommands.Lock},
+		{EntryId: 3, Enabled: true, Weekdays: blecommands.Weekend, Time: noon, Action: blecommands.Unlock},
+		{EntryId: 4, Enabled: true, Weekdays: blecommands.Sunday, Time: noon, Action: blecommands.Lock},
+	}
+	desired := []blecommands.TimeControlEntry{
+		// unchanged
+		{Enabled: true, Weekdays: blecommands.WorkingDays, Time: morning, Action: blecommands.Unlock},
+		// disabled
+		{Enabled: false, Weekdays: blecommands.AllWeekdays, Time: evening, Action: blecommands.Lock},
+		// new, reuses the slot of entry 3
+		{Enabled: true, Weekdays: blecommands.Weekend, Time: noon, Action: blecommands.Unlatch},
+	}
+
+	changes := bleflows.DiffTimeControlEntries(current, desired)
+	require.Empty(t, changes.Add)
+	require.Equal(t, []blecommands.TimeControlEntry{current[3]}, changes.Remove)
+	require.Len(t, changes.Update, 2)
+	require.Equal(t, uint8(2), changes.Update[0].To.EntryId)
+	require.False(t, changes.Update[0].To.Enabled)
+	require.Equal(t, uint8(3), changes.Update[1].To.EntryId)
+	require.Equal(t, blecommands.Unlatch, changes.Update[1].To.Action)
+
+	require.True(t, bleflows.DiffTimeControlEntries(current, current).IsEmpty())
+
+	changes = bleflows.DiffTimeControlEntries(nil, desired)
+	require.Equal(t, desired, changes.Add)
+}
@@ -0,0 +1,33 @@
+package bleflows
+
+import (
+	"context"
+
+	"github.com/nuki-io/nuki-cli/pkg/nukible"
+)
+
+var _ Transport = &nukible.Device{}
+
+// Transport carries the messages of a Flow to a Nuki device. It is implemented by nukible.Device for devices
+// connected through Bluetooth, other implementations can simulate, record or forward the messages.
+//
+// Messages written to the pairing and USDIO characteristics are raw protocol messages as created by
+// blecommands.BleHandler. Each response is a single message, like a BLE notification.
+type Transport interface {
+	// Connect establishes the connection, connecting a transport that is already connected does nothing.
+	Connect() error
+	// DiscoverPairing prepares writing to the pairing characteristic.
+	DiscoverPairing() error
+	// DiscoverKeyturnerUsdio prepares writing to the keyturner USDIO characteristic.
+	DiscoverKeyturnerUsdio() error
+	// WritePairing sends data to the pairing characteristic and returns the first response.
+	WritePairing(ctx context.Context, data []byte) ([]byte, error)
+	// WriteUsdio sends data to the USDIO characteristic and returns the first response.
+	WriteUsdio(ctx context.Context, data []byte) ([]byte, error)
+	// WriteUsdioStream sends data to the USDIO characteristic and returns a channel receiving all responses
+	// until stop is called.
+	WriteUsdioStream(ctx context.Context, data []byte) (ch <-chan []byte, stop func())
+	// IsOpener reports whether the device is a Nuki Opener, it is known after discovery.
+	IsOpener() bool
+	Disconnect()
+}
@@ -0,0 +1,123 @@
+package blerecord_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/nuki-io/nuki-cli/pkg/blecommands"
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+	"github.com/nuki-io/nuki-cli/pkg/blerecord"
+	"github.com/nuki-io/nuki-cli/pkg/nukisim"
+	"github.com/nuki-io/nuki-cli/pkg/nukisim/nukisimtest"
+	"github.com/stretchr/testify/require"
+)
+
+// record pairs with a simulator and records an unlock followed by a status request.
+func record(t *testing.T) *blerecord.Session {
+	return recordWith(t, true, func(ctx context.Context, flow *bleflows.Flow) error {
+		if err := flow.PerformLockOperation(ctx, blecommands.Unlock); err != nil {
+			return err
+		}
+		_, err := flow.GetStatus(ctx)
+		return err
+	})
+}
+
+// recordWith pairs with a simulator and records fn, withAuth tells whether the authorization is recorded.
+func recordWith(t *testing.T, withAuth bool, fn func(ctx context.Context, flow *bleflows.Flow) error) *blerecord.Session {
+	t.Helper()
+	sim, store := nukisimtest.Paired(t)
+	auth, err := store.Load("sim")
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	header := blerecord.Header{DeviceID: "sim", Command: "unlock"}
+	if withAuth {
+		header.Authorization = blerecord.NewAuthorization(auth)
+	}
+	recorder, err := blerecord.NewRecorder(sim.Connect(), buf, header)
+	require.NoError(t, err)
+	recorder.SetAuthorization(auth)
+
+	flow, err := bleflows.NewAuthenticatedFlow(recorder, "sim", store)
+	require.NoError(t, err)
+	flow.SetRandom(recorder.Random())
+	require.NoError(t, fn(context.Background(), flow))
+	require.NoError(t, flow.DisconnectDevice())
+
+	session, err := blerecord.ReadSession(buf)
+	require.NoError(t, err)
+	return session
+}
+
+func TestRecord(t *testing.T) {
+	session := record(t)
+	require.Equal(t, "sim", session.Header.DeviceID)
+	require.NotNil(t, session.Header.Authorization)
+
+	var commands []string
+	for _, e := range session.Events {
+		if e.Type == blerecord.EventWrite || e.Type == blerecord.EventNotification {
+			commands = append(commands, string(e.Type)+" "+e.Command)
+		}
+	}
+	require.Equal(t, "write CommandRequestData", commands[0])
+	require.Equal(t, "notification CommandChallenge", commands[1])
+	require.Equal(t, "write CommandLockAction", commands[2])
+	require.Contains(t, commands, "notification CommandKeyturnerStates")
+	require.Equal(t, blerecord.EventDisconnect, session.Events[len(session.Events)-1].Type)
+}
+
+func TestRecordRedactsPin(t *testing.T) {
+	pin := blecommands.NewPin(nukisim.DefaultPin).GetPinBytes()
+	enableLogging := func(withAuth bool) []byte {
+		session := recordWith(t, withAuth, func(ctx context.Context, flow *bleflows.Flow) error {
+			return flow.EnableLogging(ctx, true)
+		})
+		for _, e := range session.Events {
+			if e.Type == blerecord.EventWrite && e.Command == "CommandEnableLogging" {
+				return e.Payload
+			}
+		}
+		t.Fatal("no logging request recorded")
+		return nil
+	}
+
+	payload := enableLogging(true)
+	require.Equal(t, pin, payload[len(payload)-len(pin):])
+
+	payload = enableLogging(false)
+	require.Equal(t, make([]byte, len(pin)), payload[len(payload)-len(pin):])
+}
+
+func TestReplay(t *testing.T) {
+	session := record(t)
+	store, err := session.AuthStore()
+	require.NoError(t, err)
+
+	replay := blerecord.NewReplay(session)
+	flow, err := bleflows.NewAuthenticatedFlow(replay, "sim", store)
+	require.NoError(t, err)
+	flow.SetRandom(replay.Random())
+	require.NoError(t, flow.PerformLockOperation(context.Background(), blecommands.Unlock))
+	states, err := flow.GetStatus(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, blecommands.LockStateUnlocked, states.LockState)
+	require.NoError(t, flow.DisconnectDevice())
+	require.NoError(t, replay.Done())
+}
+
+func TestReplayMismatch(t *testing.T) {
+	session := record(t)
+	store, err := session.AuthStore()
+	require.NoError(t, err)
+
+	replay := blerecord.NewReplay(session)
+	flow, err := bleflows.NewAuthenticatedFlow(replay, "sim", store)
+	require.NoError(t, err)
+	// fresh nonces produce different frames than recorded
+	_, err = flow.GetStatus(context.Background())
+	require.ErrorContains(t, err, "replay mismatch")
+	require.ErrorContains(t, replay.Done(), "replay mismatch")
+}
@@ -0,0 +1,359 @@
+// Package blerecord records the messages exchanged by a bleflows.Flow with a device and replays them.
+//
+// A recording is a JSON Lines file. The first line is the Header, every other line is an Event. Besides
+// the raw frames, the recording contains the timing, the decoded commands and the random bytes used
+// by the flow, so that a Replay produces the same frames as the recorded session.
+package blerecord
+
+import (
+	"bytes"
+	"context"
+	crypto_rand "crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/nuki-io/nuki-cli/pkg/blecommands"
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+)
+
+// EventType is the kind of a recorded event.
+type EventType string
+
+const (
+	EventSession      EventType = "session" // the header, see Header
+	EventConnect      EventType = "connect"
+	EventDiscover     EventType = "discover"
+	EventWrite        EventType = "write"
+	EventNotification EventType = "notification"
+	EventError        EventType = "error"
+	EventRandom       EventType = "random"
+	EventDisconnect   EventType = "disconnect"
+)
+
+// Channels a message is written to or received from.
+const (
+	ChannelPairing = "pairing"
+	ChannelUsdio   = "usdio"
+)
+
+// HexBytes is a byte slice that is encoded as hex string in JSON.
+type HexBytes []byte
+
+func (b HexBytes) MarshalText() ([]byte, error) {
+	return []byte(hex.EncodeToString(b)), nil
+}
+
+func (b *HexBytes) UnmarshalText(text []byte) error {
+	v, err := hex.DecodeString(string(text))
+	if err != nil {
+		return err
+	}
+	*b = v
+	return nil
+}
+
+// Header is the first line of a recording.
+type Header struct {
+	Type     EventType `json:"type"`
+	Started  time.Time `json:"started"`
+	DeviceID string    `json:"deviceId"`
+	Command  string    `json:"command,omitempty"`
+	// Authorization is only recorded on request, since it allows to control the device.
+	Authorization *Authorization `json:"authorization,omitempty"`
+}
+
+// Authorization holds the parts of a bleflows.AuthorizeContext that are needed to replay an authenticated session.
+type Authorization struct {
+	AuthId    HexBytes `json:"authId"`
+	AppId     HexBytes `json:"appId"`
+	SharedKey HexBytes `json:"sharedKey"`
+	NukiId    uint32   `json:"nukiId"`
+	Pin       string   `json:"pin,omitempty"`
+	Name      string   `json:"name,omitempty"`
+}
+
+func NewAuthorization(ctx *bleflows.AuthorizeContext) *Authorization {
+	return &Authorization{
+		AuthId:    ctx.AuthId,
+		AppId:     ctx.AppId,
+		SharedKey: ctx.SharedKey,
+		NukiId:    ctx.NukiId,
+		Pin:       ctx.Pin,
+		Name:      ctx.Name,
+	}
+}
+
+func (a *Authorization) AuthorizeContext() *bleflows.AuthorizeContext {
+	return &bleflows.AuthorizeContext{
+		AuthId:    a.AuthId,
+		AppId:     a.AppId,
+		SharedKey: a.SharedKey,
+		NukiId:    a.NukiId,
+		Pin:       a.Pin,
+		Name:      a.Name,
+	}
+}
+
+// Event is a single line of a recording.
+type Event struct {
+	Type      EventType `json:"type"`
+	ElapsedMs float64   `json:"elapsedMs"`
+	Channel   string    `json:"channel,omitempty"`
+	// Data is the raw frame of writes and notifications or the random bytes read by the flow.
+	Data HexBytes `json:"data,omitempty"`
+	// Command is the name of the decoded command, if it could be decoded.
+	Command string `json:"command,omitempty"`
+	// Payload is the plain payload of a written command. The security PIN in it is zeroed unless the
+	// authorization is recorded, see Recorder.
+	Payload HexBytes `json:"payload,omitempty"`
+	// Decoded is the decoded response of a notification.
+	Decoded any    `json:"decoded,omitempty"`
+	Opener  bool   `json:"opener,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+var _ bleflows.Transport = &Recorder{}
+
+// Recorder is a bleflows.Transport that records all messages passed to and received from another transport.
+// Unless the header contains the authorization, the security PIN is zeroed in the recorded payloads.
+type Recorder struct {
+	transport bleflows.Transport
+	start     time.Time
+	redact    bool
+
+	mu      sync.Mutex
+	enc     *json.Encoder
+	crypto  blecommands.Crypto
+	handler *blecommands.BleHandler
+	plain   *blecommands.BleHandler
+	pin     []byte
+}
+
+// NewRecorder writes header to w and returns a transport that records all messages of transport to w.
+func NewRecorder(transport bleflows.Transport, w io.Writer, header Header) (*Recorder, error) {
+	header.Type = EventSession
+	if header.Started.IsZero() {
+		header.Started = time.Now()
+	}
+	r := &Recorder{
+		transport: transport,
+		start:     header.Started,
+		redact:    header.Authorization == nil,
+		enc:       json.NewEncoder(w),
+		plain:     blecommands.NewBleHandler(nil, nil),
+	}
+	if err := r.enc.Encode(header); err != nil {
+		return nil, fmt.Errorf("failed to write recording header: %w", err)
+	}
+	return r, nil
+}
+
+// SetAuthorization enables decoding of encrypted messages, which are recorded undecoded otherwise.
+func (r *Recorder) SetAuthorization(ctx *bleflows.AuthorizeContext) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.crypto = blecommands.NewCrypto(ctx.SharedKey)
+	r.handler = blecommands.NewBleHandler(r.crypto, ctx.AuthId)
+	r.pin = nil
+	if pin := blecommands.NewPin(ctx.Pin); pin != nil {
+		r.pin = pin.GetPinBytes()
+	}
+}
+
+// Random returns a source of random bytes for bleflows.Flow.SetRandom that records all bytes read.
+// The bytes read by a pairing include its private key, so only use it for pairings that may record the authorization.
+func (r *Recorder) Random() io.Reader {
+	return &recordingReader{recorder: r, src: crypto_rand.Reader}
+}
+
+type recordingReader struct {
+	recorder *Recorder
+	src      io.Reader
+}
+
+func (rr *recordingReader) Read(p []byte) (int, error) {
+	n, err := rr.src.Read(p)
+	if n > 0 {
+		rr.recorder.record(Event{Type: EventRandom, Data: slices.Clone(p[:n])})
+	}
+	return n, err
+}
+
+func (r *Recorder) Connect() error {
+	err := r.transport.Connect()
+	r.record(Event{Type: EventConnect, Error: errorString(err)})
+	return err
+}
+
+func (r *Recorder) DiscoverPairing() error {
+	err := r.transport.DiscoverPairing()
+	r.recordDiscover(ChannelPairing, err)
+	return err
+}
+
+func (r *Recorder) DiscoverKeyturnerUsdio() error {
+	err := r.transport.DiscoverKeyturnerUsdio()
+	r.recordDiscover(ChannelUsdio, err)
+	return err
+}
+
+func (r *Recorder) recordDiscover(channel string, err error) {
+	opener := err == nil && r.transport.IsOpener()
+	if opener {
+		r.mu.Lock()
+		r.plain.SetDeviceType(blecommands.DeviceTypeOpener)
+		if r.handler != nil {
+			r.handler.SetDeviceType(blecommands.DeviceTypeOpener)
+		}
+		r.mu.Unlock()
+	}
+	r.record(Event{Type: EventDiscover, Channel: channel, Opener: opener, Error: errorString(err)})
+}
+
+func (r *Recorder) WritePairing(ctx context.Context, data []byte) ([]byte, error) {
+	r.recordWrite(ChannelPairing, data)
+	res, err := r.transport.WritePairing(ctx, data)
+	r.recordResponse(ChannelPairing, res, err)
+	return res, err
+}
+
+func (r *Recorder) WriteUsdio(ctx context.Context, data []byte) ([]byte, error) {
+	r.recordWrite(ChannelUsdio, data)
+	res, err := r.transport.WriteUsdio(ctx, data)
+	r.recordResponse(ChannelUsdio, res, err)
+	return res, err
+}
+
+func (r *Recorder) WriteUsdioStream(ctx context.Context, data []byte) (<-chan []byte, func()) {
+	r.recordWrite(ChannelUsdio, data)
+	in, stopIn := r.transport.WriteUsdioStream(ctx, data)
+	out := make(chan []byte, 32)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case buf := <-in:
+				r.recordResponse(ChannelUsdio, buf, nil)
+				select {
+				case out <- buf:
+				default:
+					slog.Warn("Recorded notification dropped: receive buffer full")
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	stop := func() {
+		once.Do(func() {
+			close(done)
+			stopIn()
+		})
+	}
+	return out, stop
+}
+
+func (r *Recorder) IsOpener() bool {
+	return r.transport.IsOpener()
+}
+
+func (r *Recorder) Disconnect() {
+	r.transport.Disconnect()
+	r.record(Event{Type: EventDisconnect})
+}
+
+func (r *Recorder) recordWrite(channel string, data []byte) {
+	e := Event{Type: EventWrite, Channel: channel, Data: slices.Clone(data)}
+	if pdata := r.plainMessage(channel, data); pdata != nil {
+		code := blecommands.CommandCode(binary.LittleEndian.Uint16(pdata[0:2]))
+		e.Command = code.String()
+		e.Payload = r.redactPin(code, pdata[2:len(pdata)-2])
+	}
+	r.record(e)
+}
+
+// redactPin zeroes the security PIN, which requests send last, in payload unless the authorization is recorded.
+// The payload of CommandSetSecurityPIN is left out completely, since it starts with the new PIN.
+func (r *Recorder) redactPin(code blecommands.CommandCode, payload []byte) []byte {
+	if !r.redact {
+		return payload
+	}
+	if code == blecommands.CommandSetSecurityPIN {
+		return nil
+	}
+	r.mu.Lock()
+	pin := r.pin
+	r.mu.Unlock()
+	if len(pin) == 0 || !bytes.HasSuffix(payload, pin) {
+		return payload
+	}
+	payload = slices.Clone(payload)
+	clear(payload[len(payload)-len(pin):])
+	return payload
+}
+
+// plainMessage returns command code, payload and CRC of a written message, or nil if it cannot be decrypted.
+func (r *Recorder) plainMessage(channel string, data []byte) []byte {
+	if channel == ChannelPairing && len(data) >= 4 &&
+		blecommands.CRC(data[:len(data)-2]) == binary.LittleEndian.Uint16(data[len(data)-2:]) {
+		return data
+	}
+	r.mu.Lock()
+	crypto := r.crypto
+	r.mu.Unlock()
+	if crypto == nil || len(data) < 30 {
+		return nil
+	}
+	pdata, err := crypto.Decrypt(data[0:24], data[30:])
+	if err != nil || len(pdata) < 8 {
+		return nil
+	}
+	// strip the authorization ID
+	return pdata[4:]
+}
+
+func (r *Recorder) recordResponse(channel string, data []byte, err error) {
+	if err != nil {
+		r.record(Event{Type: EventError, Channel: channel, Error: err.Error()})
+		return
+	}
+	e := Event{Type: EventNotification, Channel: channel, Data: slices.Clone(data)}
+
+	r.mu.Lock()
+	var res blecommands.Command
+	if channel == ChannelPairing {
+		res, _ = r.plain.FromDeviceResponse(data)
+	} else if r.handler != nil && len(data) >= 30 {
+		res, _ = r.handler.FromEncryptedDeviceResponse(data)
+	}
+	r.mu.Unlock()
+	if res != nil {
+		e.Command = res.GetCommandCode().String()
+		e.Decoded = res
+	}
+	r.record(e)
+}
+
+func (r *Recorder) record(e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e.ElapsedMs = float64(time.Since(r.start).Microseconds()) / 1000
+	if err := r.enc.Encode(e); err != nil {
+		slog.Warn("Failed to record event", "type", e.Type, "error", err)
+	}
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,278 @@
+package blerecord
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+)
+
+// Session is a recording read with ReadSession.
+type Session struct {
+	Header Header
+	Events []Event
+}
+
+// ReadSession reads a recording written by a Recorder.
+func ReadSession(r io.Reader) (*Session, error) {
+	s := &Session{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		if line == 1 {
+			if err := json.Unmarshal(scanner.Bytes(), &s.Header); err != nil {
+				return nil, fmt.Errorf("failed to parse recording header: %w", err)
+			}
+			if s.Header.Type != EventSession {
+				return nil, fmt.Errorf("recording does not start with a session header")
+			}
+			continue
+		}
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("failed to parse recording line %d: %w", line, err)
+		}
+		s.Events = append(s.Events, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read recording: %w", err)
+	}
+	if line == 0 {
+		return nil, fmt.Errorf("recording is empty")
+	}
+	return s, nil
+}
+
+// AuthStore returns a store that loads the recorded authorization for any device. It fails if the
+// session was recorded without authorization.
+func (s *Session) AuthStore() (bleflows.AuthStore, error) {
+	if s.Header.Authorization == nil {
+		return nil, fmt.Errorf("session was recorded without authorization")
+	}
+	return &sessionAuthStore{ctx: s.Header.Authorization.AuthorizeContext()}, nil
+}
+
+type sessionAuthStore struct {
+	ctx *bleflows.AuthorizeContext
+}
+
+func (s *sessionAuthStore) Load(deviceId string) (*bleflows.AuthorizeContext, error) {
+	return s.ctx, nil
+}
+
+func (s *sessionAuthStore) Store(deviceId string, ctx *bleflows.AuthorizeContext) error {
+	s.ctx = ctx
+	return nil
+}
+
+// List returns no devices, since the recorded authorization is used for any device.
+func (s *sessionAuthStore) List() ([]string, error) {
+	return nil, nil
+}
+
+func (s *sessionAuthStore) Delete(deviceId string) error {
+	return nil
+}
+
+var _ bleflows.Transport = &Replay{}
+
+// Replay is a bleflows.Transport that plays back a recorded session. Responses are delivered immediately,
+// the recorded timing is ignored. In strict mode, which is the default, every written frame must be equal
+// to the recorded one; this requires the flow to use the random bytes of the recording, see Random.
+type Replay struct {
+	mu     sync.Mutex
+	events []Event
+	pos    int
+	random []byte
+	strict bool
+	opener bool
+	err    error
+}
+
+func NewReplay(s *Session) *Replay {
+	r := &Replay{strict: true}
+	for _, e := range s.Events {
+		if e.Type == EventRandom {
+			r.random = append(r.random, e.Data...)
+			continue
+		}
+		r.events = append(r.events, e)
+	}
+	return r
+}
+
+// SetStrict sets whether written frames must be equal to the recorded ones.
+func (r *Replay) SetStrict(strict bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.strict = strict
+}
+
+// Random returns the recorded random bytes for bleflows.Flow.SetRandom.
+func (r *Replay) Random() io.Reader {
+	return bytes.NewReader(r.random)
+}
+
+// Done returns the first mismatch during the replay, or an error if recorded messages were not replayed.
+func (r *Replay) Done() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	for _, e := range r.events[r.pos:] {
+		if e.Type != EventDisconnect {
+			return fmt.Errorf("replay incomplete: %d recorded events left, next is %s", len(r.events)-r.pos, e.Type)
+		}
+	}
+	return nil
+}
+
+func (r *Replay) Connect() error {
+	e, err := r.next(EventConnect, "")
+	if err != nil {
+		return err
+	}
+	return recordedError(e)
+}
+
+func (r *Replay) DiscoverPairing() error {
+	return r.discover(ChannelPairing)
+}
+
+func (r *Replay) DiscoverKeyturnerUsdio() error {
+	return r.discover(ChannelUsdio)
+}
+
+func (r *Replay) discover(channel string) error {
+	e, err := r.next(EventDiscover, channel)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.opener = e.Opener
+	r.mu.Unlock()
+	return recordedError(e)
+}
+
+func (r *Replay) WritePairing(ctx context.Context, data []byte) ([]byte, error) {
+	if err := r.write(ChannelPairing, data); err != nil {
+		return nil, err
+	}
+	return r.response(ChannelPairing)
+}
+
+func (r *Replay) WriteUsdio(ctx context.Context, data []byte) ([]byte, error) {
+	if err := r.write(ChannelUsdio, data); err != nil {
+		return nil, err
+	}
+	return r.response(ChannelUsdio)
+}
+
+// WriteUsdioStream delivers all recorded notifications up to the next write. If the written frame does not
+// match the recording, nothing is delivered and the mismatch is returned by Done.
+func (r *Replay) WriteUsdioStream(ctx context.Context, data []byte) (<-chan []byte, func()) {
+	if err := r.write(ChannelUsdio, data); err != nil {
+		slog.Error("Replay failed", "error", err)
+		return make(chan []byte), func() {}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var notifications [][]byte
+	for r.pos < len(r.events) && r.events[r.pos].Type == EventNotification && r.events[r.pos].Channel == ChannelUsdio {
+		notifications = append(notifications, r.events[r.pos].Data)
+		r.pos++
+	}
+	ch := make(chan []byte, len(notifications))
+	for _, n := range notifications {
+		ch <- n
+	}
+	return ch, func() {}
+}
+
+func (r *Replay) IsOpener() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.opener
+}
+
+func (r *Replay) Disconnect() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.pos < len(r.events) && r.events[r.pos].Type == EventDisconnect {
+		r.pos++
+	}
+}
+
+func (r *Replay) write(channel string, data []byte) error {
+	e, err := r.next(EventWrite, channel)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.strict && !bytes.Equal(e.Data, data) {
+		return r.fail(fmt.Errorf("replay mismatch at event %d (%s): wrote %x, recorded %x", r.pos, e.Command, data, []byte(e.Data)))
+	}
+	return nil
+}
+
+func (r *Replay) response(channel string) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.pos >= len(r.events) {
+		return nil, r.fail(fmt.Errorf("replay: no response recorded on %s", channel))
+	}
+	e := r.events[r.pos]
+	switch {
+	case e.Type == EventError:
+		r.pos++
+		return nil, errors.New(e.Error)
+	case e.Type == EventNotification && e.Channel == channel:
+		r.pos++
+		return e.Data, nil
+	}
+	return nil, r.fail(fmt.Errorf("replay: expected response on %s at event %d, recorded %s", channel, r.pos, e.Type))
+}
+
+// next consumes the next event, which must be of type t on channel, if channel is set.
+func (r *Replay) next(t EventType, channel string) (Event, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.pos >= len(r.events) {
+		return Event{}, r.fail(fmt.Errorf("replay: expected %s, but the recording ended", t))
+	}
+	e := r.events[r.pos]
+	if e.Type != t || (channel != "" && e.Channel != channel) {
+		return Event{}, r.fail(fmt.Errorf("replay: expected %s %s at event %d, recorded %s %s", t, channel, r.pos, e.Type, e.Channel))
+	}
+	r.pos++
+	return e, nil
+}
+
+// fail remembers the first error for Done, must be called with r.mu held.
+func (r *Replay) fail(err error) error {
+	if r.err == nil {
+		r.err = err
+	}
+	return err
+}
+
+func recordedError(e Event) error {
+	if e.Error == "" {
+		return nil
+	}
+	return errors.New(e.Error)
+}
@@ -0,0 +1,177 @@
+package nukible
+
+import (
+	"context"
+	"encoding/binary"
+	"log/slog"
+	"strings"
+	"time"
+
+	"tinygo.org/x/bluetooth"
+)
+
+const (
+	appleCompanyID = 0x004c
+	// iBeacon data after the company ID: type 0x02, length 0x15, UUID, major, minor and TX power
+	iBeaconType   = 0x02
+	iBeaconLength = 0x15
+)
+
+// Beacon is the iBeacon part of the advertisement of a Nuki device.
+type Beacon struct {
+	UUID  bluetooth.UUID
+	Major uint16
+	Minor uint16
+	// TxPower is the measured power at 1 m in dBm.
+	TxPower int8
+	// StateChanged is set by the device when its state changed since it was last read by a paired device.
+	// It is transmitted as the least significant bit of the TX power.
+	StateChanged bool
+}
+
+// ParseBeacon decodes iBeacon manufacturer data. It returns false if the data is not an iBeacon.
+func ParseBeacon(m bluetooth.ManufacturerDataElement) (Beacon, bool) {
+	d := m.Data
+	if m.CompanyID != appleCompanyID || len(d) < 23 || d[0] != iBeaconType || d[1] != iBeaconLength {
+		return Beacon{}, false
+	}
+	var uuid [16]byte
+	copy(uuid[:], d[2:18])
+	return Beacon{
+		UUID:         bluetooth.NewUUID(uuid),
+		Major:        binary.BigEndian.Uint16(d[18:20]),
+		Minor:        binary.BigEndian.Uint16(d[20:22]),
+		TxPower:      int8(d[22]),
+		StateChanged: d[22]&0x01 == 0x01,
+	}, true
+}
+
+// Advertisement is a decoded advertisement of a Nuki device.
+type Advertisement struct {
+	Time    time.Time `json:"time"`
+	Address string    `json:"address"`
+	Name    string    `json:"name"`
+	RSSI    int16     `json:"rssi"`
+	// TxPower is only set if the device sent an iBeacon.
+	TxPower int8 `json:"txPower"`
+	// StateChanged is set if the state of the device changed since it was last read by a paired device.
+	StateChanged bool `json:"stateChanged"`
+	// PairingMode is set if the device advertises its pairing service, which it does in pairing mode.
+	PairingMode bool       `json:"pairingMode"`
+	DeviceType  DeviceType `json:"deviceType"`
+}
+
+// DecodeAdvertisement decodes the advertisement of a Nuki device. It returns false for other devices.
+func DecodeAdvertisement(sr bluetooth.ScanResult) (Advertisement, bool) {
+	name := sr.LocalName()
+	a := Advertisement{
+		Time:    time.Now(),
+		Address: sr.Address.String(),
+		Name:    name,
+		RSSI:    sr.RSSI,
+	}
+	nuki := strings.HasPrefix(name, "Nuki")
+	if nuki {
+		a.DeviceType = DeviceTypeSmartLock
+		if strings.HasPrefix(name, "Nuki_OPENER") {
+			a.DeviceType = DeviceTypeOpener
+		}
+	}
+	for _, m := range sr.ManufacturerData() {
+		b, ok := ParseBeacon(m)
+		if !ok {
+			continue
+		}
+		switch b.UUID {
+		case KeyturnerService, OpenerService:
+		case KeyturnerPairingService, KeyturnerPairingServiceUltra, OpenerPairingService:
+			a.PairingMode = true
+		default:
+			continue
+		}
+		nuki = true
+		a.DeviceType = serviceDeviceType(b.UUID)
+		a.TxPower = b.TxPower
+		a.StateChanged = b.StateChanged
+	}
+	for _, uuid := range []bluetooth.UUID{KeyturnerService, OpenerService, KeyturnerPairingService, KeyturnerPairingServiceUltra, OpenerPairingService} {
+		if !sr.HasServiceUUID(uuid) {
+			continue
+		}
+		nuki = true
+		a.DeviceType = serviceDeviceType(uuid)
+		if uuid != KeyturnerService && uuid != OpenerService {
+			a.PairingMode = true
+		}
+	}
+	if !nuki {
+		return a, false
+	}
+	if a.DeviceType == "" {
+		a.DeviceType = DeviceTypeUnknown
+	}
+	return a, true
+}
+
+// DeviceType is the type of a Nuki device as far as it can be told from its advertisement.
+// Smart Lock generations cannot be told apart, except for the Smart Lock Ultra, which only in pairing mode
+// advertises its own pairing service.
+type DeviceType string
+
+const (
+	DeviceTypeUnknown        DeviceType = "Unknown"
+	DeviceTypeSmartLock      DeviceType = "SmartLock"
+	DeviceTypeSmartLockUltra DeviceType = "SmartLockUltra"
+	DeviceTypeOpener         DeviceType = "Opener"
+)
+
+func serviceDeviceType(uuid bluetooth.UUID) DeviceType {
+	switch uuid {
+	case OpenerService, OpenerPairingService:
+		return DeviceTypeOpener
+	case KeyturnerPairingServiceUltra:
+		return DeviceTypeSmartLockUltra
+	default:
+		return DeviceTypeSmartLock
+	}
+}
+
+// Watch scans until ctx is done and sends every advertisement of a Nuki device to the returned channel,
+// which is closed when the scan ends. Advertisements are dropped if the receiver does not keep up.
+// The devices seen can be connected to afterwards, like after a Scan.
+func (n *NukiBle) Watch(ctx context.Context) (<-chan Advertisement, error) {
+	ch := make(chan Advertisement, 64)
+	started := make(chan error, 1)
+	n.mu.Lock()
+	n.devices = map[string]bluetooth.ScanResult{}
+	n.mu.Unlock()
+	go func() {
+		defer close(ch)
+		stop := context.AfterFunc(ctx, func() { n.adapter.StopScan() })
+		defer stop()
+		err := n.adapter.Scan(func(a *bluetooth.Adapter, sr bluetooth.ScanResult) {
+			adv, ok := DecodeAdvertisement(sr)
+			if !ok {
+				return
+			}
+			n.mu.Lock()
+			n.devices[adv.Address] = sr
+			n.mu.Unlock()
+			select {
+			case ch <- adv:
+			default:
+				slog.Debug("Advertisement dropped", "address", adv.Address)
+			}
+		})
+		started <- err
+	}()
+	// Scan only returns early if it could not be started
+	select {
+	case err := <-started:
+		if err != nil && ctx.Err() == nil {
+			return nil, err
+		}
+	case <-time.After(100 * time.Millisecond):
+	}
+	return ch, nil
+}
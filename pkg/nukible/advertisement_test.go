@@ -0,0 +1,92 @@
+package nukible_test
+
+import (
+	"testing"
+
+	"github.com/nuki-io/nuki-cli/pkg/nukible"
+	"github.com/stretchr/testify/require"
+	"tinygo.org/x/bluetooth"
+)
+
+func beaconData(uuid bluetooth.UUID, txPower byte) []byte {
+	b := uuid.Bytes()
+	// UUID.Bytes is little endian, the beacon carries the UUID in network byte order
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	data := []byte{0x02, 0x15}
+	data = append(data, b[:]...)
+	return append(data, 0x12, 0x34, 0x56, 0x78, txPower)
+}
+
+func TestParseBeacon(t *testing.T) {
+	b, ok := nukible.ParseBeacon(bluetooth.ManufacturerDataElement{
+		CompanyID: 0x004c,
+		Data:      beaconData(nukible.KeyturnerService, 0xc5),
+	})
+	require.True(t, ok)
+	require.Equal(t, nukible.KeyturnerService, b.UUID)
+	require.Equal(t, uint16(0x1234), b.Major)
+	require.Equal(t, uint16(0x5678), b.Minor)
+	require.Equal(t, int8(-59), b.TxPower)
+	require.True(t, b.StateChanged)
+
+	b, ok = nukible.ParseBeacon(bluetooth.ManufacturerDataElement{
+		CompanyID: 0x004c,
+		Data:      beaconData(nukible.KeyturnerService, 0xc4),
+	})
+	require.True(t, ok)
+	require.False(t, b.StateChanged)
+}
+
+func TestParseBeaconInvalid(t *testing.T) {
+	_, ok := nukible.ParseBeacon(bluetooth.ManufacturerDataElement{CompanyID: 0x0059, Data: beaconData(nukible.KeyturnerService, 0xc5)})
+	require.False(t, ok)
+	_, ok = nukible.ParseBeacon(bluetooth.ManufacturerDataElement{CompanyID: 0x004c, Data: []byte{0x02, 0x15, 0x01}})
+	require.False(t, ok)
+}
+
+type payload struct {
+	name     string
+	services []bluetooth.UUID
+	data     []bluetooth.ManufacturerDataElement
+}
+
+func (p payload) LocalName() string { return p.name }
+func (p payload) HasServiceUUID(uuid bluetooth.UUID) bool {
+	for _, s := range p.services {
+		if s == uuid {
+			return true
+		}
+	}
+	return false
+}
+func (p payload) Bytes() []byte                                         { return nil }
+func (p payload) ManufacturerData() []bluetooth.ManufacturerDataElement { return p.data }
+func (p payload) ServiceData() []bluetooth.ServiceDataElement           { return nil }
+
+func TestDecodeAdvertisement(t *testing.T) {
+	tests := []struct {
+		name        string
+		payload     payload
+		deviceType  nukible.DeviceType
+		pairingMode bool
+	}{
+		{"name", payload{name: "Nuki_2A3B4C5D"}, nukible.DeviceTypeSmartLock, false},
+		{"opener name", payload{name: "Nuki_OPENER_2A3B4C5D"}, nukible.DeviceTypeOpener, false},
+		{"pairing service", payload{services: []bluetooth.UUID{nukible.KeyturnerPairingService}}, nukible.DeviceTypeSmartLock, true},
+		{"ultra pairing service", payload{name: "Nuki_2A3B4C5D", services: []bluetooth.UUID{nukible.KeyturnerPairingServiceUltra}}, nukible.DeviceTypeSmartLockUltra, true},
+		{"opener beacon", payload{data: []bluetooth.ManufacturerDataElement{{CompanyID: 0x004c, Data: beaconData(nukible.OpenerService, 0xc4)}}}, nukible.DeviceTypeOpener, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adv, ok := nukible.DecodeAdvertisement(bluetooth.ScanResult{RSSI: -60, AdvertisementPayload: tt.payload})
+			require.True(t, ok)
+			require.Equal(t, tt.deviceType, adv.DeviceType)
+			require.Equal(t, tt.pairingMode, adv.PairingMode)
+		})
+	}
+
+	_, ok := nukible.DecodeAdvertisement(bluetooth.ScanResult{AdvertisementPayload: payload{name: "Other"}})
+	require.False(t, ok)
+}
@@ -22,3 +22,22 @@ var (
 	KeyturnerGdioCharacteristic  = baseUuid.Replace16BitComponent(0xe201)
 	KeyturnerUsdioCharacteristic = baseUuid.Replace16BitComponent(0xe202)
 )
+
+var (
+	// The Opener uses the same services and characteristics as the Smart Lock, but with its own base UUID.
+	openerBaseUuid = bluetooth.NewUUID([16]byte{
+		0xa9, 0x2a, 0x00, 0x00,
+		0x55, 0x01,
+		0x11, 0xe4,
+		0x91, 0x6c,
+		0x08, 0x00, 0x20, 0x0c, 0x9a, 0x66})
+
+	OpenerInitializationService = openerBaseUuid.Replace16BitComponent(0xe000)
+
+	OpenerPairingService            = openerBaseUuid.Replace16BitComponent(0xe100)
+	OpenerPairingGdioCharacteristic = openerBaseUuid.Replace16BitComponent(0xe101)
+
+	OpenerService             = openerBaseUuid.Replace16BitComponent(0xe200)
+	OpenerGdioCharacteristic  = openerBaseUuid.Replace16BitComponent(0xe201)
+	OpenerUsdioCharacteristic = openerBaseUuid.Replace16BitComponent(0xe202)
+)
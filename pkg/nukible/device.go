@@ -10,12 +10,52 @@ import (
 )
 
 type Device struct {
+	ble       *NukiBle
+	id        string
+	connected bool
+	// params override the connection parameters of the NukiBle
+	params ConnectionParams
+
 	btDev           bluetooth.Device
 	services        []bluetooth.DeviceService
 	characteristics []bluetooth.DeviceCharacteristic
 
 	pairingGdioChar    bluetooth.DeviceCharacteristic
 	keyturnerUsdioChar bluetooth.DeviceCharacteristic
+
+	// opener is set if the services were discovered under the Opener UUIDs
+	opener bool
+}
+
+// IsOpener reports whether the device is a Nuki Opener. It is only known after the
+// pairing or keyturner services have been discovered.
+func (n *Device) IsOpener() bool {
+	return n.opener
+}
+
+// SetConnectionParams sets the connection parameters for this device. Zero fields use the
+// parameters of the NukiBle the device was created with.
+func (n *Device) SetConnectionParams(p ConnectionParams) {
+	n.params = p
+}
+
+// Connect connects to the device. On Linux, the device must have been discovered by a scan before.
+// Connecting to a device that is already connected does nothing.
+func (n *Device) Connect() error {
+	if n.connected {
+		return nil
+	}
+	addr, ok := n.ble.GetDeviceAddress(n.id)
+	if !ok {
+		return fmt.Errorf("requested device with MAC %s was not discovered", n.id)
+	}
+	device, err := n.ble.connect(*addr, n.params)
+	if err != nil {
+		return err
+	}
+	n.btDev = device
+	n.connected = true
+	return nil
 }
 
 func (n *Device) DiscoverServicesAndCharacteristics(services []bluetooth.UUID, chars []bluetooth.UUID) error {
@@ -48,6 +88,14 @@ func (n *Device) DiscoverPairing() error {
 			[]bluetooth.UUID{KeyturnerPairingGdioCharacteristicUltra},
 		)
 	}
+	if len(n.services) == 0 && err != nil {
+		// maybe it's an Opener
+		err = n.DiscoverServicesAndCharacteristics(
+			[]bluetooth.UUID{OpenerPairingService},
+			[]bluetooth.UUID{OpenerPairingGdioCharacteristic},
+		)
+		n.opener = err == nil
+	}
 	if err != nil {
 		return fmt.Errorf("could not discover any pairing services or characteristics. %s", err.Error())
 	}
@@ -67,6 +115,14 @@ func (n *Device) DiscoverKeyturnerUsdio() error {
 		[]bluetooth.UUID{KeyturnerService},
 		[]bluetooth.UUID{KeyturnerUsdioCharacteristic},
 	)
+	if len(n.services) == 0 && err != nil {
+		// maybe it's an Opener
+		err = n.DiscoverServicesAndCharacteristics(
+			[]bluetooth.UUID{OpenerService},
+			[]bluetooth.UUID{OpenerUsdioCharacteristic},
+		)
+		n.opener = err == nil
+	}
 	if err != nil {
 		return fmt.Errorf("could not discover any Keyturner services or characteristics. %s", err.Error())
 	}
@@ -88,6 +144,7 @@ func (n *Device) Disconnect() {
 	}
 	n.services = make([]bluetooth.DeviceService, 0)
 	n.characteristics = make([]bluetooth.DeviceCharacteristic, 0)
+	n.connected = false
 }
 
 // stream enables notifications on char, writes data, and returns a channel that
@@ -2,36 +2,105 @@ package nukible
 
 import (
 	"log/slog"
+	"maps"
 	"strings"
+	"sync"
 	"time"
 
 	"tinygo.org/x/bluetooth"
 )
 
+// ConnectionParams are the parameters of the connection to a device. Zero fields use the defaults,
+// see DefaultConnectionParams.
+type ConnectionParams struct {
+	// ConnectionTimeout limits the time to establish the connection.
+	ConnectionTimeout time.Duration
+	// Interval is the connection interval. Shorter intervals are faster, but drain the battery of the device faster.
+	Interval time.Duration
+	// SupervisionTimeout is the time without communication after which the connection is considered lost.
+	SupervisionTimeout time.Duration
+}
+
+// DefaultConnectionParams are used for the fields not set in ConnectionParams.
+var DefaultConnectionParams = ConnectionParams{
+	ConnectionTimeout:  5 * time.Second,
+	Interval:           15 * time.Millisecond,
+	SupervisionTimeout: 6 * time.Second,
+}
+
+// Merge returns p with the fields set in o replaced.
+func (p ConnectionParams) Merge(o ConnectionParams) ConnectionParams {
+	if o.ConnectionTimeout != 0 {
+		p.ConnectionTimeout = o.ConnectionTimeout
+	}
+	if o.Interval != 0 {
+		p.Interval = o.Interval
+	}
+	if o.SupervisionTimeout != 0 {
+		p.SupervisionTimeout = o.SupervisionTimeout
+	}
+	return p
+}
+
+// Options configures a NukiBle.
+type Options struct {
+	// Adapter is the ID of the Bluetooth adapter to use, e.g. hci1. Empty uses the default adapter.
+	// Selecting an adapter is only supported on Linux.
+	Adapter string
+	// Connection are the connection parameters used for all devices, unless set for a device
+	// with Device.SetConnectionParams.
+	Connection ConnectionParams
+}
+
 type NukiBle struct {
 	adapter *bluetooth.Adapter
+	params  ConnectionParams
+
+	// mu guards devices, which are updated by scans running in the background, see Watch
+	mu      sync.Mutex
 	devices map[string]bluetooth.ScanResult
 }
 
+// NewNukiBle enables the default Bluetooth adapter.
 func NewNukiBle() (*NukiBle, error) {
-	adapter := bluetooth.DefaultAdapter
-	err := adapter.Enable()
+	return NewNukiBleWithOptions(Options{})
+}
 
+// NewNukiBleWithOptions enables the Bluetooth adapter set in opts.
+func NewNukiBleWithOptions(opts Options) (*NukiBle, error) {
+	adapter, err := osAdapter(opts.Adapter)
 	if err != nil {
 		return nil, err
 	}
+	if err = adapter.Enable(); err != nil {
+		return nil, err
+	}
 	return &NukiBle{
 		adapter: adapter,
+		params:  DefaultConnectionParams.Merge(opts.Connection),
 		devices: map[string]bluetooth.ScanResult{},
 	}, nil
 }
 
 func (n *NukiBle) GetDevices() map[string]bluetooth.ScanResult {
-	return n.devices
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return maps.Clone(n.devices)
 }
 
+// GetDeviceAddress returns the address of a discovered device. Device IDs are case-insensitive.
 func (n *NukiBle) GetDeviceAddress(deviceId string) (res *bluetooth.Address, ok bool) {
+	n.mu.Lock()
 	d, exists := n.devices[deviceId]
+	if !exists {
+		for id, v := range n.devices {
+			if strings.EqualFold(id, deviceId) {
+				d, exists = v, true
+				break
+			}
+		}
+	}
+	n.mu.Unlock()
 
 	if !exists {
 		return osGetUndiscoveredDeviceAddress(deviceId)
@@ -40,44 +109,33 @@ func (n *NukiBle) GetDeviceAddress(deviceId string) (res *bluetooth.Address, ok
 }
 
 func (n *NukiBle) Connect(addr bluetooth.Address) (*Device, error) {
-	device, err := n.adapter.Connect(addr, bluetooth.ConnectionParams{
-		ConnectionTimeout: bluetooth.NewDuration(5 * time.Second),
-		MinInterval:       bluetooth.NewDuration(15 * time.Millisecond),
-		MaxInterval:       bluetooth.NewDuration(15 * time.Millisecond),
-		Timeout:           bluetooth.NewDuration(6 * time.Second),
-	})
+	device, err := n.connect(addr, ConnectionParams{})
 	if err != nil {
 		return nil, err
 	}
 	return &Device{
-		btDev: device,
+		ble:       n,
+		btDev:     device,
+		connected: true,
 	}, nil
 }
 
-func (n *NukiBle) Scan(timeout time.Duration) error {
-	return n.ScanForDevice("", timeout)
-}
-
-func (n *NukiBle) ScanForDevice(deviceId string, timeout time.Duration) error {
-	n.devices = map[string]bluetooth.ScanResult{}
-	t := time.AfterFunc(timeout, func() { n.adapter.StopScan() })
-
-	slog.Info("Scanning for devices...")
-	err := n.adapter.Scan(func(a *bluetooth.Adapter, sr bluetooth.ScanResult) { n.onScan(a, sr, deviceId) })
-	t.Stop()
-	return err
+// NewDevice returns the device with the given ID without connecting to it, see Device.Connect.
+func (n *NukiBle) NewDevice(deviceId string) *Device {
+	return &Device{
+		ble: n,
+		id:  deviceId,
+	}
 }
 
-func (n *NukiBle) onScan(a *bluetooth.Adapter, d bluetooth.ScanResult, stopOnDeviceId string) {
-	if !strings.HasPrefix(d.LocalName(), "Nuki") {
-		return
-	}
-	if _, exists := n.devices[d.Address.String()]; !exists {
-		slog.Info("Found new device", "address", d.Address.String(), "rssi", d.RSSI, "name", d.LocalName())
-		n.devices[d.Address.String()] = d
-		if d.Address.String() == stopOnDeviceId {
-			a.StopScan()
-			return
-		}
-	}
+// connect connects with the connection parameters of the NukiBle, overridden by the fields set in params.
+func (n *NukiBle) connect(addr bluetooth.Address, params ConnectionParams) (bluetooth.Device, error) {
+	p := n.params.Merge(params)
+	slog.Debug("Connecting", "address", addr.String(), "timeout", p.ConnectionTimeout, "interval", p.Interval, "supervisionTimeout", p.SupervisionTimeout)
+	return n.adapter.Connect(addr, bluetooth.ConnectionParams{
+		ConnectionTimeout: bluetooth.NewDuration(p.ConnectionTimeout),
+		MinInterval:       bluetooth.NewDuration(p.Interval),
+		MaxInterval:       bluetooth.NewDuration(p.Interval),
+		Timeout:           bluetooth.NewDuration(p.SupervisionTimeout),
+	})
 }
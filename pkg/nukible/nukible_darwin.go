@@ -1,6 +1,10 @@
 package nukible
 
-import "tinygo.org/x/bluetooth"
+import (
+	"fmt"
+
+	"tinygo.org/x/bluetooth"
+)
 
 // osGetUndiscoveredDeviceAddress on Darwin will construct a bluetooth.Address from
 // the given id.
@@ -11,3 +15,11 @@ func osGetUndiscoveredDeviceAddress(id string) (res *bluetooth.Address, ok bool)
 	}
 	return addr, true
 }
+
+// osAdapter on Darwin only supports the default adapter.
+func osAdapter(id string) (*bluetooth.Adapter, error) {
+	if id != "" {
+		return nil, fmt.Errorf("selecting the Bluetooth adapter %s is not supported on macOS", id)
+	}
+	return bluetooth.DefaultAdapter, nil
+}
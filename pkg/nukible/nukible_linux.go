@@ -7,3 +7,11 @@ import "tinygo.org/x/bluetooth"
 func osGetUndiscoveredDeviceAddress(id string) (res *bluetooth.Address, ok bool) {
 	return nil, false
 }
+
+// osAdapter returns the adapter with the given ID, e.g. hci1, or the default adapter if id is empty.
+func osAdapter(id string) (*bluetooth.Adapter, error) {
+	if id == "" {
+		return bluetooth.DefaultAdapter, nil
+	}
+	return bluetooth.NewAdapter(id), nil
+}
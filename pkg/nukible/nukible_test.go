@@ -0,0 +1,19 @@
+package nukible_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nuki-io/nuki-cli/pkg/nukible"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectionParamsMerge(t *testing.T) {
+	p := nukible.DefaultConnectionParams.Merge(nukible.ConnectionParams{Interval: 30 * time.Millisecond})
+	require.Equal(t, nukible.ConnectionParams{
+		ConnectionTimeout:  5 * time.Second,
+		Interval:           30 * time.Millisecond,
+		SupervisionTimeout: 6 * time.Second,
+	}, p)
+	require.Equal(t, p, p.Merge(nukible.ConnectionParams{}))
+}
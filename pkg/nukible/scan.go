@@ -0,0 +1,94 @@
+package nukible
+
+import (
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"tinygo.org/x/bluetooth"
+)
+
+// FoundDevice is a Nuki device found by a scan.
+type FoundDevice struct {
+	Address string `json:"address"`
+	Name    string `json:"name"`
+	// RSSI is the signal strength of the last advertisement received in dBm.
+	RSSI        int16      `json:"rssi"`
+	PairingMode bool       `json:"pairingMode"`
+	DeviceType  DeviceType `json:"deviceType"`
+	// Paired is set if ScanOptions.IsPaired reported the device as paired.
+	Paired bool `json:"paired"`
+}
+
+// ScanOptions configures a scan, see ScanWithOptions.
+type ScanOptions struct {
+	Timeout time.Duration
+	// DeviceId stops the scan as soon as this device was found.
+	DeviceId string
+	// MinRSSI ignores devices with a weaker signal, 0 accepts all devices.
+	MinRSSI int16
+	// IsPaired reports whether the device with the given address is paired, it sets FoundDevice.Paired.
+	IsPaired func(address string) bool
+}
+
+// Scan scans for Nuki devices until the timeout expired.
+func (n *NukiBle) Scan(timeout time.Duration) ([]FoundDevice, error) {
+	return n.ScanWithOptions(ScanOptions{Timeout: timeout})
+}
+
+// ScanForDevice scans until the device was found or the timeout expired.
+// The device must be found before it can be connected to on Linux.
+func (n *NukiBle) ScanForDevice(deviceId string, timeout time.Duration) ([]FoundDevice, error) {
+	return n.ScanWithOptions(ScanOptions{Timeout: timeout, DeviceId: deviceId})
+}
+
+// ScanWithOptions scans for Nuki devices and returns them sorted by signal strength, strongest first.
+func (n *NukiBle) ScanWithOptions(opts ScanOptions) ([]FoundDevice, error) {
+	n.mu.Lock()
+	n.devices = map[string]bluetooth.ScanResult{}
+	n.mu.Unlock()
+	found := map[string]FoundDevice{}
+	t := time.AfterFunc(opts.Timeout, func() { n.adapter.StopScan() })
+
+	slog.Info("Scanning for devices...")
+	err := n.adapter.Scan(func(a *bluetooth.Adapter, sr bluetooth.ScanResult) {
+		adv, ok := DecodeAdvertisement(sr)
+		if !ok || (opts.MinRSSI != 0 && adv.RSSI < opts.MinRSSI) {
+			return
+		}
+		n.mu.Lock()
+		n.devices[adv.Address] = sr
+		n.mu.Unlock()
+
+		d, exists := found[adv.Address]
+		if !exists {
+			slog.Debug("Found new device", "address", adv.Address, "rssi", adv.RSSI, "name", adv.Name)
+			d = FoundDevice{Address: adv.Address, Paired: opts.IsPaired != nil && opts.IsPaired(adv.Address)}
+		}
+		d.RSSI = adv.RSSI
+		d.PairingMode = adv.PairingMode
+		// the name is not sent with every advertisement
+		if adv.Name != "" {
+			d.Name = adv.Name
+		}
+		if d.DeviceType == "" || d.DeviceType == DeviceTypeUnknown || adv.DeviceType == DeviceTypeSmartLockUltra {
+			d.DeviceType = adv.DeviceType
+		}
+		found[adv.Address] = d
+		if !exists && strings.EqualFold(adv.Address, opts.DeviceId) {
+			a.StopScan()
+		}
+	})
+	t.Stop()
+
+	devices := slices.Collect(maps.Values(found))
+	slices.SortFunc(devices, func(a, b FoundDevice) int {
+		if a.RSSI != b.RSSI {
+			return int(b.RSSI) - int(a.RSSI)
+		}
+		return strings.Compare(a.Address, b.Address)
+	})
+	return devices, err
+}
@@ -0,0 +1,287 @@
+// Package nukiexporter exports the states of paired Smart Locks as Prometheus metrics.
+//
+// The devices are polled in the background, a scrape only reads the cached results of the last poll.
+// Lock actions are counted from the log of the device, which requires the security PIN; only actions
+// logged after the first poll are counted.
+package nukiexporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/nuki-io/nuki-cli/pkg/blecommands"
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	DefaultInterval = 5 * time.Minute
+	// timeout is the maximum time of a command exchange with a device.
+	timeout = 30 * time.Second
+	// logPageSize is the number of log entries read per poll to count the lock actions.
+	logPageSize = 50
+)
+
+var (
+	labels = []string{"device", "name", "nuki_id"}
+
+	upDesc              = newDesc("up", "Whether the last poll of the device succeeded.")
+	lastPollDesc        = newDesc("last_poll_timestamp_seconds", "Time of the last successful poll of the device.")
+	batteryDesc         = newDesc("battery_percentage", "Battery charge in percent.")
+	batteryCriticalDesc = newDesc("battery_critical", "Whether the battery state is critical.")
+	batteryChargingDesc = newDesc("battery_charging", "Whether the battery is charging.")
+	batteryVoltageDesc  = newDesc("battery_voltage_millivolts", "Battery voltage in mV.")
+	bleRssiDesc         = newDesc("ble_rssi_dbm", "BLE signal strength in dBm as seen by the device.")
+	wifiRssiDesc        = newDesc("wifi_rssi_dbm", "WiFi signal strength in dBm.")
+	lockStateDesc       = newDesc("lock_state", "Lock state of the device, see the Nuki BLE API for the values.")
+	doorSensorDesc      = newDesc("door_sensor_state", "Door sensor state of the device, see the Nuki BLE API for the values.")
+	doorOpenDesc        = newDesc("door_open", "Whether the door sensor reports the door as open.")
+	lockActionsDesc     = newDesc("lock_actions_total", "Lock actions logged by the device since the exporter started.", "action")
+	pollErrorsDesc      = newDesc("poll_errors_total", "Failed polls of the device.")
+	deviceErrorsDesc    = newDesc("device_errors_total", "Error reports of the device to the commands of the exporter.", "code")
+)
+
+func newDesc(name string, help string, extraLabels ...string) *prometheus.Desc {
+	return prometheus.NewDesc("nuki_"+name, help, append(labels, extraLabels...), nil)
+}
+
+var _ prometheus.Collector = &Exporter{}
+
+type Exporter struct {
+	pool     *bleflows.Pool
+	store    bleflows.AuthStore
+	devices  []string
+	interval time.Duration
+	// intervals overrides interval per device
+	intervals map[string]time.Duration
+
+	mu      sync.Mutex
+	metrics map[string]*deviceMetrics
+}
+
+// deviceMetrics are the cached results of the polls of a device.
+type deviceMetrics struct {
+	name   string
+	nukiId string
+
+	up         bool
+	lastPoll   time.Time
+	states     *blecommands.KeyturnerStates
+	battery    *blecommands.BatteryReport
+	pollErrors int
+	// deviceErrors counts the error reports by the name of their code, e.g. K_ERROR_BAD_PIN.
+	deviceErrors map[string]int
+
+	// lastLogIndex is the index of the newest log entry that was counted, nil before the first read.
+	lastLogIndex *uint32
+	lockActions  map[string]int
+}
+
+// New returns an exporter for the given devices, which are accessed through pool.
+// The name and Nuki ID labels are taken from the authorizations in store.
+func New(pool *bleflows.Pool, store bleflows.AuthStore, devices []string) *Exporter {
+	e := &Exporter{
+		pool:      pool,
+		store:     store,
+		devices:   devices,
+		interval:  DefaultInterval,
+		intervals: map[string]time.Duration{},
+		metrics:   map[string]*deviceMetrics{},
+	}
+	for _, id := range devices {
+		e.metrics[id] = &deviceMetrics{lockActions: map[string]int{}, deviceErrors: map[string]int{}}
+	}
+	return e
+}
+
+// SetInterval sets the time between two polls of a device, DefaultInterval by default.
+func (e *Exporter) SetInterval(interval time.Duration) {
+	e.interval = interval
+}
+
+// SetDeviceInterval sets the time between two polls of the device with the given id, overriding SetInterval.
+func (e *Exporter) SetDeviceInterval(id string, interval time.Duration) {
+	e.intervals[id] = interval
+}
+
+// Run polls every device in its interval until ctx is done.
+func (e *Exporter) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, id := range e.devices {
+		interval, ok := e.intervals[id]
+		if !ok {
+			interval = e.interval
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				e.Poll(ctx, id)
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// Poll reads the states, battery report and new log entries of the device and caches them.
+func (e *Exporter) Poll(ctx context.Context, id string) {
+	e.mu.Lock()
+	m := e.metrics[id]
+	if m.nukiId == "" {
+		if auth, err := e.store.Load(id); err == nil && auth.NukiId != 0 {
+			m.name = auth.Name
+			m.nukiId = fmt.Sprintf("%X", auth.NukiId)
+		}
+	}
+	// the Nuki ID is missing in authorizations stored by older versions
+	readConfig := m.nukiId == ""
+	lastLogIndex := m.lastLogIndex
+	e.mu.Unlock()
+
+	var (
+		cfg     *blecommands.Config
+		states  *blecommands.KeyturnerStates
+		battery *blecommands.BatteryReport
+		logs    []blecommands.LogEntry
+		logErr  error
+	)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	err := e.pool.Do(ctx, id, func(ctx context.Context, flow *bleflows.Flow) error {
+		var err error
+		if readConfig {
+			if cfg, err = flow.GetConfig(ctx); err != nil {
+				return err
+			}
+		}
+		if states, err = flow.GetStatus(ctx); err != nil {
+			return err
+		}
+		if battery, err = flow.GetBatteryReport(ctx); err != nil {
+			return err
+		}
+		// the log requires the PIN, which is optional for the other metrics
+		logs, _, logErr = flow.GetLogs(ctx, 0, logPageSize, false)
+		return nil
+	})
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, err := range []error{err, logErr} {
+		var deviceErr *blecommands.DeviceError
+		if errors.As(err, &deviceErr) {
+			m.deviceErrors[deviceErr.Name]++
+		}
+	}
+	if err != nil {
+		slog.Error("Failed to poll device", "device", id, "error", err)
+		m.up = false
+		m.pollErrors++
+		return
+	}
+	if logErr != nil {
+		slog.Debug("Failed to read log, lock actions are not counted", "device", id, "error", logErr)
+	}
+	if cfg != nil {
+		m.name = cfg.Name
+		m.nukiId = fmt.Sprintf("%X", cfg.NukiID)
+	}
+	m.up = true
+	m.lastPoll = time.Now()
+	m.states = states
+	m.battery = battery
+	if logErr == nil {
+		m.lastLogIndex = countLockActions(m.lockActions, logs, lastLogIndex)
+	}
+}
+
+// countLockActions adds the lock actions of the entries newer than lastIndex to counts and returns the
+// index of the newest entry. If lastIndex is nil, nothing is counted.
+func countLockActions(counts map[string]int, entries []blecommands.LogEntry, lastIndex *uint32) *uint32 {
+	newest := lastIndex
+	for _, entry := range entries {
+		if newest == nil || entry.Index > *newest {
+			index := entry.Index
+			newest = &index
+		}
+		if lastIndex == nil || entry.Index <= *lastIndex {
+			continue
+		}
+		if entry.Type == blecommands.LogLockAction && len(entry.Data) > 0 {
+			counts[blecommands.Action(entry.Data[0]).String()]++
+		}
+	}
+	return newest
+}
+
+func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		upDesc, lastPollDesc, batteryDesc, batteryCriticalDesc, batteryChargingDesc, batteryVoltageDesc,
+		bleRssiDesc, wifiRssiDesc, lockStateDesc, doorSensorDesc, doorOpenDesc, lockActionsDesc, pollErrorsDesc,
+		deviceErrorsDesc,
+	} {
+		ch <- d
+	}
+}
+
+func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, id := range e.devices {
+		m := e.metrics[id]
+		l := []string{id, m.name, m.nukiId}
+		gauge := func(d *prometheus.Desc, v float64, extra ...string) {
+			ch <- prometheus.MustNewConstMetric(d, prometheus.GaugeValue, v, append(l, extra...)...)
+		}
+		counter := func(d *prometheus.Desc, v float64, extra ...string) {
+			ch <- prometheus.MustNewConstMetric(d, prometheus.CounterValue, v, append(l, extra...)...)
+		}
+
+		gauge(upDesc, boolToFloat(m.up))
+		counter(pollErrorsDesc, float64(m.pollErrors))
+		for action, count := range m.lockActions {
+			counter(lockActionsDesc, float64(count), action)
+		}
+		for code, count := range m.deviceErrors {
+			counter(deviceErrorsDesc, float64(count), code)
+		}
+		if m.states == nil {
+			continue
+		}
+		s := m.states
+		gauge(lastPollDesc, float64(m.lastPoll.Unix()))
+		gauge(batteryDesc, float64(s.BatteryPercentage))
+		gauge(batteryCriticalDesc, boolToFloat(s.BatteryStateCritical))
+		gauge(batteryChargingDesc, boolToFloat(s.Charging))
+		gauge(lockStateDesc, float64(s.LockState))
+		gauge(doorSensorDesc, float64(s.DoorSensorState))
+		gauge(doorOpenDesc, boolToFloat(s.DoorSensorState == blecommands.DoorSensorOpened))
+		if s.BleConnectionStrength.Status == blecommands.ConnectionStrengthOK {
+			gauge(bleRssiDesc, float64(s.BleConnectionStrength.RSSI))
+		}
+		if s.WifiConnectionStrength.Status == blecommands.ConnectionStrengthOK {
+			gauge(wifiRssiDesc, float64(s.WifiConnectionStrength.RSSI))
+		}
+		if m.battery != nil {
+			gauge(batteryVoltageDesc, float64(m.battery.BatteryVoltage))
+		}
+	}
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,81 @@
+package nukiexporter_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nuki-io/nuki-cli/pkg/blecommands"
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+	"github.com/nuki-io/nuki-cli/pkg/nukiexporter"
+	"github.com/nuki-io/nuki-cli/pkg/nukisim/nukisimtest"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/require"
+)
+
+func scrape(t *testing.T, e *nukiexporter.Exporter) string {
+	t.Helper()
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(e)
+	rec := httptest.NewRecorder()
+	promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestExporter(t *testing.T) {
+	sim, store := nukisimtest.Paired(t)
+
+	pool := bleflows.NewPool(func(deviceId string) (bleflows.Transport, error) {
+		return sim.Connect(), nil
+	}, store)
+	defer pool.Close()
+	exporter := nukiexporter.New(pool, store, []string{"sim"})
+	ctx := context.Background()
+
+	exporter.Poll(ctx, "sim")
+	labels := fmt.Sprintf(`device="sim",name="Front Door",nuki_id="%X"`, sim.State().NukiID)
+	metrics := scrape(t, exporter)
+	require.Contains(t, metrics, "nuki_up{"+labels+"} 1")
+	require.Contains(t, metrics, "nuki_battery_percentage{"+labels+"} 84")
+	require.Contains(t, metrics, "nuki_battery_voltage_millivolts{"+labels+"} 5520")
+	require.Contains(t, metrics, "nuki_lock_state{"+labels+"} 1")
+	require.Contains(t, metrics, "nuki_ble_rssi_dbm{"+labels+"} -60")
+	require.NotContains(t, metrics, "nuki_lock_actions_total")
+
+	// actions are counted from the log after the first poll
+	require.NoError(t, pool.Do(ctx, "sim", func(ctx context.Context, flow *bleflows.Flow) error {
+		if err := flow.PerformLockOperation(ctx, blecommands.Unlock); err != nil {
+			return err
+		}
+		return flow.PerformLockOperation(ctx, blecommands.Lock)
+	}))
+	exporter.Poll(ctx, "sim")
+	metrics = scrape(t, exporter)
+	require.Contains(t, metrics, "nuki_lock_actions_total{action=\"Unlock\","+labels+"} 1")
+	require.Contains(t, metrics, "nuki_lock_actions_total{action=\"Lock\","+labels+"} 1")
+	require.Contains(t, metrics, "nuki_poll_errors_total{"+labels+"} 0")
+}
+
+func TestExporterDeviceErrors(t *testing.T) {
+	sim, store := nukisimtest.Paired(t)
+	store["sim"].Pin = "654321"
+
+	pool := bleflows.NewPool(func(deviceId string) (bleflows.Transport, error) {
+		return sim.Connect(), nil
+	}, store)
+	defer pool.Close()
+	exporter := nukiexporter.New(pool, store, []string{"sim"})
+
+	exporter.Poll(context.Background(), "sim")
+	exporter.Poll(context.Background(), "sim")
+	labels := fmt.Sprintf(`device="sim",name="Front Door",nuki_id="%X"`, sim.State().NukiID)
+	metrics := scrape(t, exporter)
+	// the log cannot be read with a wrong PIN, the other metrics can
+	require.Contains(t, metrics, "nuki_up{"+labels+"} 1")
+	require.Contains(t, metrics, "nuki_device_errors_total{code=\"K_ERROR_BAD_PIN\","+labels+"} 2")
+}
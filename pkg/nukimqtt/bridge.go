@@ -0,0 +1,256 @@
+// Package nukimqtt bridges paired Smart Locks to MQTT.
+//
+// The bridge polls the KeyturnerStates of every device and publishes them as retained messages below
+// <topic>/<device id>/:
+//
+//	state            the KeyturnerStates as JSON
+//	lock             LOCKED, UNLOCKED, LOCKING, UNLOCKING, OPEN, OPENING, JAMMED or UNKNOWN
+//	door             open, closed or unknown
+//	battery          battery charge in percent
+//	batteryCritical  true or false
+//	rssi             the BLE signal strength in dBm as seen by the device
+//	availability     online or offline, depending on whether the last poll succeeded
+//
+// <topic>/availability is online while the bridge is running, see AvailabilityTopic.
+//
+// Lock actions are accepted on <topic>/<device id>/command, e.g. lock, unlock, unlatch or lockngo. Retained
+// commands are ignored, they would be performed again on every start of the bridge.
+// Home Assistant discovery messages are published for all devices, unless disabled.
+package nukimqtt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nuki-io/nuki-cli/pkg/blecommands"
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+)
+
+const (
+	DefaultTopic           = "nuki"
+	DefaultDiscoveryPrefix = "homeassistant"
+	DefaultInterval        = 5 * time.Minute
+	// timeout is the maximum time of a command exchange with a device.
+	timeout = 30 * time.Second
+)
+
+// Client is the part of an MQTT client used by the bridge, see NewPahoClient.
+type Client interface {
+	// Publish publishes payload to topic with QoS 1.
+	Publish(topic string, retained bool, payload []byte) error
+	// Subscribe calls handler for every message received on topic, except for retained messages sent on subscribing.
+	Subscribe(topic string, handler func(topic string, payload []byte)) error
+}
+
+// AvailabilityTopic returns the topic telling whether the bridge with the base topic is running. The bridge
+// publishes online to it, set offline as last will of the client to mark the devices unavailable if the bridge
+// dies.
+func AvailabilityTopic(topic string) string {
+	return strings.TrimSuffix(topic, "/") + "/availability"
+}
+
+type Bridge struct {
+	client          Client
+	pool            *bleflows.Pool
+	devices         []string
+	topic           string
+	discoveryPrefix string
+	interval        time.Duration
+
+	mu        sync.Mutex
+	announced map[string]bool
+}
+
+// New returns a bridge for the given devices, which are accessed through pool.
+func New(client Client, pool *bleflows.Pool, devices []string) *Bridge {
+	return &Bridge{
+		client:          client,
+		pool:            pool,
+		devices:         devices,
+		topic:           DefaultTopic,
+		discoveryPrefix: DefaultDiscoveryPrefix,
+		interval:        DefaultInterval,
+		announced:       map[string]bool{},
+	}
+}
+
+// SetTopic sets the base topic of all messages of the bridge, DefaultTopic by default.
+func (b *Bridge) SetTopic(topic string) {
+	b.topic = strings.TrimSuffix(topic, "/")
+}
+
+// SetDiscoveryPrefix sets the topic prefix of Home Assistant discovery messages, an empty prefix disables discovery.
+func (b *Bridge) SetDiscoveryPrefix(prefix string) {
+	b.discoveryPrefix = strings.TrimSuffix(prefix, "/")
+}
+
+// SetInterval sets the time between two polls of a device, DefaultInterval by default.
+func (b *Bridge) SetInterval(interval time.Duration) {
+	b.interval = interval
+}
+
+// Run subscribes to the command topics and polls the devices until ctx is done.
+func (b *Bridge) Run(ctx context.Context) error {
+	b.publish(AvailabilityTopic(b.topic), "online")
+	defer b.publish(AvailabilityTopic(b.topic), "offline")
+	for _, id := range b.devices {
+		err := b.client.Subscribe(b.deviceTopic(id, "command"), func(topic string, payload []byte) {
+			// the client must not be blocked by the lock action
+			go b.handleCommand(ctx, id, string(payload))
+		})
+		if err != nil {
+			return fmt.Errorf("failed to subscribe to commands of %s: %w", id, err)
+		}
+	}
+
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+	for {
+		for _, id := range b.devices {
+			b.Poll(ctx, id)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll reads the states of the device and publishes them. The discovery messages are published
+// on the first successful poll.
+func (b *Bridge) Poll(ctx context.Context, id string) {
+	var states *blecommands.KeyturnerStates
+	var cfg *blecommands.Config
+	err := b.do(ctx, id, func(ctx context.Context, flow *bleflows.Flow) error {
+		if b.discoveryPrefix != "" && !b.isAnnounced(id) {
+			var err error
+			if cfg, err = flow.GetConfig(ctx); err != nil {
+				return err
+			}
+		}
+		var err error
+		states, err = flow.GetStatus(ctx)
+		return err
+	})
+	if err != nil {
+		slog.Error("Failed to poll device", "device", id, "error", err)
+		b.publish(b.deviceTopic(id, "availability"), "offline")
+		return
+	}
+	if cfg != nil {
+		b.announce(id, cfg)
+	}
+	b.publishStates(id, states)
+}
+
+func (b *Bridge) handleCommand(ctx context.Context, id string, command string) {
+	action, err := parseCommand(command)
+	if err != nil {
+		slog.Error("Invalid command", "device", id, "error", err)
+		return
+	}
+	slog.Info("Performing lock action", "device", id, "action", action)
+	var states *blecommands.KeyturnerStates
+	err = b.do(ctx, id, func(ctx context.Context, flow *bleflows.Flow) error {
+		if err := flow.PerformLockOperation(ctx, action); err != nil {
+			return err
+		}
+		var err error
+		states, err = flow.GetStatus(ctx)
+		return err
+	})
+	if err != nil {
+		slog.Error("Lock action failed", "device", id, "action", action, "error", err)
+		return
+	}
+	b.publishStates(id, states)
+}
+
+func (b *Bridge) do(ctx context.Context, id string, fn func(ctx context.Context, flow *bleflows.Flow) error) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return b.pool.Do(ctx, id, fn)
+}
+
+// parseCommand parses a lock action, besides the action names of blecommands, the Home Assistant
+// payloads LOCK, UNLOCK and OPEN are accepted.
+func parseCommand(command string) (blecommands.Action, error) {
+	switch strings.ToLower(strings.TrimSpace(command)) {
+	case "open":
+		return blecommands.Unlatch, nil
+	case "lockngo":
+		return blecommands.LockAndGo, nil
+	}
+	return blecommands.ParseAction(strings.TrimSpace(command))
+}
+
+func (b *Bridge) publishStates(id string, s *blecommands.KeyturnerStates) {
+	states, err := json.Marshal(s)
+	if err != nil {
+		slog.Error("Failed to encode states", "device", id, "error", err)
+		return
+	}
+	b.publish(b.deviceTopic(id, "state"), string(states))
+	b.publish(b.deviceTopic(id, "lock"), lockState(s.LockState))
+	b.publish(b.deviceTopic(id, "door"), doorState(s.DoorSensorState))
+	b.publish(b.deviceTopic(id, "battery"), strconv.Itoa(s.BatteryPercentage))
+	b.publish(b.deviceTopic(id, "batteryCritical"), strconv.FormatBool(s.BatteryStateCritical))
+	if s.BleConnectionStrength.Status == blecommands.ConnectionStrengthOK {
+		b.publish(b.deviceTopic(id, "rssi"), strconv.Itoa(int(s.BleConnectionStrength.RSSI)))
+	}
+	b.publish(b.deviceTopic(id, "availability"), "online")
+}
+
+func (b *Bridge) publish(topic string, payload string) {
+	if err := b.client.Publish(topic, true, []byte(payload)); err != nil {
+		slog.Error("Failed to publish", "topic", topic, "error", err)
+	}
+}
+
+func (b *Bridge) deviceTopic(id string, name string) string {
+	return b.topic + "/" + id + "/" + name
+}
+
+func (b *Bridge) isAnnounced(id string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.announced[id]
+}
+
+// lockState maps a lock state to the states of the Home Assistant MQTT lock.
+func lockState(s blecommands.LockState) string {
+	switch s {
+	case blecommands.LockStateLocked:
+		return "LOCKED"
+	case blecommands.LockStateUnlocked, blecommands.LockStateUnlockedLockNGo:
+		return "UNLOCKED"
+	case blecommands.LockStateLocking:
+		return "LOCKING"
+	case blecommands.LockStateUnlocking:
+		return "UNLOCKING"
+	case blecommands.LockStateUnlatched:
+		return "OPEN"
+	case blecommands.LockStateUnlatching:
+		return "OPENING"
+	case blecommands.LockStateMotorBlocked:
+		return "JAMMED"
+	}
+	return "UNKNOWN"
+}
+
+func doorState(s blecommands.DoorSensorState) string {
+	switch s {
+	case blecommands.DoorSensorOpened:
+		return "open"
+	case blecommands.DoorSensorClosed:
+		return "closed"
+	}
+	return "unknown"
+}
@@ -0,0 +1,110 @@
+package nukimqtt_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nuki-io/nuki-cli/pkg/blecommands"
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+	"github.com/nuki-io/nuki-cli/pkg/nukimqtt"
+	"github.com/nuki-io/nuki-cli/pkg/nukisim"
+	"github.com/nuki-io/nuki-cli/pkg/nukisim/nukisimtest"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryBroker is a Client that keeps the retained messages and delivers published messages to subscribers.
+type memoryBroker struct {
+	mu       sync.Mutex
+	retained map[string]string
+	handlers map[string]func(topic string, payload []byte)
+}
+
+func (b *memoryBroker) Publish(topic string, retained bool, payload []byte) error {
+	b.mu.Lock()
+	if retained {
+		b.retained[topic] = string(payload)
+	}
+	h := b.handlers[topic]
+	b.mu.Unlock()
+	if h != nil {
+		h(topic, payload)
+	}
+	return nil
+}
+
+func (b *memoryBroker) Subscribe(topic string, handler func(topic string, payload []byte)) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[topic] = handler
+	return nil
+}
+
+func (b *memoryBroker) get(topic string) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.retained[topic]
+}
+
+func newBridge(t *testing.T) (*nukisim.Simulator, *memoryBroker, context.Context) {
+	t.Helper()
+	sim, store := nukisimtest.Paired(t)
+
+	pool := bleflows.NewPool(func(deviceId string) (bleflows.Transport, error) {
+		return sim.Connect(), nil
+	}, store)
+	broker := &memoryBroker{retained: map[string]string{}, handlers: map[string]func(string, []byte){}}
+	bridge := nukimqtt.New(broker, pool, []string{"sim"})
+	bridge.SetInterval(time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		bridge.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+		pool.Close()
+	})
+	require.Eventually(t, func() bool { return broker.get("nuki/sim/availability") == "online" }, time.Second, 10*time.Millisecond)
+	return sim, broker, ctx
+}
+
+func TestPublishStates(t *testing.T) {
+	sim, broker, _ := newBridge(t)
+
+	require.Equal(t, "LOCKED", broker.get("nuki/sim/lock"))
+	require.Equal(t, "84", broker.get("nuki/sim/battery"))
+	require.Equal(t, "false", broker.get("nuki/sim/batteryCritical"))
+	require.Equal(t, "unknown", broker.get("nuki/sim/door"))
+
+	var states map[string]any
+	require.NoError(t, json.Unmarshal([]byte(broker.get("nuki/sim/state")), &states))
+	require.Equal(t, "Locked", states["lockState"])
+
+	objectId := fmt.Sprintf("nuki_%X", sim.State().NukiID)
+	var lock map[string]any
+	require.NoError(t, json.Unmarshal([]byte(broker.get("homeassistant/lock/"+objectId+"/config")), &lock))
+	require.Equal(t, "nuki/sim/command", lock["command_topic"])
+	require.Equal(t, "nuki/sim/lock", lock["state_topic"])
+	require.Equal(t, []any{map[string]any{"topic": "nuki/availability"}, map[string]any{"topic": "nuki/sim/availability"}}, lock["availability"])
+	require.Equal(t, "Front Door", lock["device"].(map[string]any)["name"])
+	require.NotEmpty(t, broker.get("homeassistant/sensor/"+objectId+"_battery/config"))
+	require.NotEmpty(t, broker.get("homeassistant/binary_sensor/"+objectId+"_door/config"))
+}
+
+func TestCommand(t *testing.T) {
+	sim, broker, _ := newBridge(t)
+
+	require.NoError(t, broker.Publish("nuki/sim/command", false, []byte("unlock")))
+	require.Eventually(t, func() bool { return broker.get("nuki/sim/lock") == "UNLOCKED" }, time.Second, 10*time.Millisecond)
+	require.Equal(t, blecommands.LockStateUnlocked, sim.KeyturnerStates().LockState)
+
+	require.NoError(t, broker.Publish("nuki/sim/command", false, []byte("OPEN")))
+	require.Eventually(t, func() bool { return broker.get("nuki/sim/lock") == "OPEN" }, time.Second, 10*time.Millisecond)
+}
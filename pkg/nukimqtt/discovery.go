@@ -0,0 +1,128 @@
+package nukimqtt
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/nuki-io/nuki-cli/pkg/blecommands"
+)
+
+// discoveryDevice is the device of a Home Assistant discovery message.
+type discoveryDevice struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer"`
+	Model        string   `json:"model"`
+	SwVersion    string   `json:"sw_version,omitempty"`
+}
+
+// discoveryAvailability is a topic telling whether an entity is available.
+type discoveryAvailability struct {
+	Topic string `json:"topic"`
+}
+
+// discoveryConfig is a Home Assistant MQTT discovery message, see
+// https://www.home-assistant.io/integrations/mqtt/#mqtt-discovery
+type discoveryConfig struct {
+	Name              *string                 `json:"name"`
+	UniqueID          string                  `json:"unique_id"`
+	Device            discoveryDevice         `json:"device"`
+	Availability      []discoveryAvailability `json:"availability"`
+	AvailabilityMode  string                  `json:"availability_mode"`
+	StateTopic        string                  `json:"state_topic"`
+	CommandTopic      string                  `json:"command_topic,omitempty"`
+	PayloadLock       string                  `json:"payload_lock,omitempty"`
+	PayloadUnlock     string                  `json:"payload_unlock,omitempty"`
+	PayloadOpen       string                  `json:"payload_open,omitempty"`
+	PayloadOn         string                  `json:"payload_on,omitempty"`
+	PayloadOff        string                  `json:"payload_off,omitempty"`
+	DeviceClass       string                  `json:"device_class,omitempty"`
+	StateClass        string                  `json:"state_class,omitempty"`
+	EntityCategory    string                  `json:"entity_category,omitempty"`
+	UnitOfMeasurement string                  `json:"unit_of_measurement,omitempty"`
+}
+
+// announce publishes the discovery messages of the lock, door sensor, battery and signal strength of a device.
+func (b *Bridge) announce(id string, cfg *blecommands.Config) {
+	device := discoveryDevice{
+		Identifiers:  []string{fmt.Sprintf("nuki_%X", cfg.NukiID)},
+		Name:         cfg.Name,
+		Manufacturer: "Nuki",
+		Model:        cfg.DeviceType.String(),
+		SwVersion:    cfg.FirmwareVersion,
+	}
+	// the device is only available while the bridge is running and can reach it
+	base := discoveryConfig{
+		Device: device,
+		Availability: []discoveryAvailability{
+			{Topic: AvailabilityTopic(b.topic)},
+			{Topic: b.deviceTopic(id, "availability")},
+		},
+		AvailabilityMode: "all",
+	}
+	name := func(n string) *string { return &n }
+	objectId := fmt.Sprintf("nuki_%X", cfg.NukiID)
+
+	lock := base
+	// the entity takes the name of the device
+	lock.UniqueID = objectId
+	lock.StateTopic = b.deviceTopic(id, "lock")
+	lock.CommandTopic = b.deviceTopic(id, "command")
+	lock.PayloadLock = "lock"
+	lock.PayloadUnlock = "unlock"
+	lock.PayloadOpen = "unlatch"
+	b.publishDiscovery("lock", objectId, lock)
+
+	door := base
+	door.Name = name("Door")
+	door.UniqueID = objectId + "_door"
+	door.StateTopic = b.deviceTopic(id, "door")
+	door.DeviceClass = "door"
+	door.PayloadOn = "open"
+	door.PayloadOff = "closed"
+	b.publishDiscovery("binary_sensor", door.UniqueID, door)
+
+	battery := base
+	battery.Name = name("Battery")
+	battery.UniqueID = objectId + "_battery"
+	battery.StateTopic = b.deviceTopic(id, "battery")
+	battery.DeviceClass = "battery"
+	battery.StateClass = "measurement"
+	battery.EntityCategory = "diagnostic"
+	battery.UnitOfMeasurement = "%"
+	b.publishDiscovery("sensor", battery.UniqueID, battery)
+
+	critical := base
+	critical.Name = name("Battery critical")
+	critical.UniqueID = objectId + "_battery_critical"
+	critical.StateTopic = b.deviceTopic(id, "batteryCritical")
+	critical.DeviceClass = "battery"
+	critical.EntityCategory = "diagnostic"
+	critical.PayloadOn = "true"
+	critical.PayloadOff = "false"
+	b.publishDiscovery("binary_sensor", critical.UniqueID, critical)
+
+	rssi := base
+	rssi.Name = name("Signal strength")
+	rssi.UniqueID = objectId + "_rssi"
+	rssi.StateTopic = b.deviceTopic(id, "rssi")
+	rssi.DeviceClass = "signal_strength"
+	rssi.StateClass = "measurement"
+	rssi.EntityCategory = "diagnostic"
+	rssi.UnitOfMeasurement = "dBm"
+	b.publishDiscovery("sensor", rssi.UniqueID, rssi)
+
+	b.mu.Lock()
+	b.announced[id] = true
+	b.mu.Unlock()
+}
+
+func (b *Bridge) publishDiscovery(component string, objectId string, cfg discoveryConfig) {
+	payload, err := json.Marshal(cfg)
+	if err != nil {
+		slog.Error("Failed to encode discovery message", "error", err)
+		return
+	}
+	b.publish(fmt.Sprintf("%s/%s/%s/config", b.discoveryPrefix, component, objectId), string(payload))
+}
@@ -0,0 +1,77 @@
+package nukimqtt
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+var _ Client = &PahoClient{}
+
+// PahoClient is a Client using the Eclipse Paho MQTT client.
+type PahoClient struct {
+	client mqtt.Client
+
+	mu            sync.Mutex
+	subscriptions map[string]mqtt.MessageHandler
+}
+
+// NewPahoClient connects to the broker with the given options. The subscriptions are restored when the
+// client reconnects. If opts has a retained will, online is published to its topic on every connect, which
+// overrides the will published by the broker when the connection was lost.
+func NewPahoClient(opts *mqtt.ClientOptions) (*PahoClient, error) {
+	c := &PahoClient{subscriptions: map[string]mqtt.MessageHandler{}}
+	opts.SetAutoReconnect(true)
+	opts.SetOnConnectHandler(func(client mqtt.Client) {
+		if opts.WillEnabled && opts.WillRetained {
+			client.Publish(opts.WillTopic, opts.WillQos, true, "online")
+		}
+		c.resubscribe(client)
+	})
+	c.client = mqtt.NewClient(opts)
+	if err := wait(c.client.Connect()); err != nil {
+		return nil, fmt.Errorf("failed to connect to MQTT broker: %w", err)
+	}
+	return c, nil
+}
+
+func (c *PahoClient) Publish(topic string, retained bool, payload []byte) error {
+	return wait(c.client.Publish(topic, 1, retained, payload))
+}
+
+func (c *PahoClient) Subscribe(topic string, handler func(topic string, payload []byte)) error {
+	h := func(_ mqtt.Client, msg mqtt.Message) {
+		if msg.Retained() {
+			slog.Warn("Ignoring retained message", "topic", msg.Topic())
+			return
+		}
+		handler(msg.Topic(), msg.Payload())
+	}
+	c.mu.Lock()
+	c.subscriptions[topic] = h
+	c.mu.Unlock()
+	return wait(c.client.Subscribe(topic, 1, h))
+}
+
+// Disconnect closes the connection to the broker.
+func (c *PahoClient) Disconnect() {
+	c.client.Disconnect(250)
+}
+
+func (c *PahoClient) resubscribe(client mqtt.Client) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for topic, handler := range c.subscriptions {
+		client.Subscribe(topic, 1, handler)
+	}
+}
+
+func wait(t mqtt.Token) error {
+	if !t.WaitTimeout(30 * time.Second) {
+		return fmt.Errorf("timeout")
+	}
+	return t.Error()
+}
@@ -0,0 +1,244 @@
+package nukimqtt_test
+
+import (
+	"context"
+	"net"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/eclipse/paho.mqtt.golang/packets"
+	"github.com/nuki-io/nuki-cli/pkg/blecommands"
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+	"github.com/nuki-io/nuki-cli/pkg/nukimqtt"
+	"github.com/nuki-io/nuki-cli/pkg/nukisim"
+	"github.com/nuki-io/nuki-cli/pkg/nukisim/nukisimtest"
+	"github.com/stretchr/testify/require"
+)
+
+// tcpBroker is a minimal MQTT 3.1.1 broker with retained messages and wills. Topics are matched exactly and
+// messages are delivered with QoS 0.
+type tcpBroker struct {
+	ln net.Listener
+
+	mu       sync.Mutex
+	retained map[string][]byte
+	history  map[string][]string
+	sessions map[*brokerSession]bool
+}
+
+type brokerSession struct {
+	conn   net.Conn
+	mu     sync.Mutex
+	topics map[string]bool
+}
+
+func (s *brokerSession) write(p packets.ControlPacket) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p.Write(s.conn)
+}
+
+func newTCPBroker(t *testing.T) *tcpBroker {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	b := &tcpBroker{
+		ln:       ln,
+		retained: map[string][]byte{},
+		history:  map[string][]string{},
+		sessions: map[*brokerSession]bool{},
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go b.serve(conn)
+		}
+	}()
+	t.Cleanup(func() {
+		ln.Close()
+		b.drop()
+	})
+	return b
+}
+
+func (b *tcpBroker) url() string {
+	return "tcp://" + b.ln.Addr().String()
+}
+
+func (b *tcpBroker) serve(conn net.Conn) {
+	defer conn.Close()
+	p, err := packets.ReadPacket(conn)
+	if err != nil {
+		return
+	}
+	connect, ok := p.(*packets.ConnectPacket)
+	if !ok {
+		return
+	}
+	s := &brokerSession{conn: conn, topics: map[string]bool{}}
+	b.mu.Lock()
+	b.sessions[s] = true
+	b.mu.Unlock()
+	s.write(packets.NewControlPacket(packets.Connack))
+
+	disconnected := false
+	for !disconnected {
+		p, err := packets.ReadPacket(conn)
+		if err != nil {
+			break
+		}
+		switch p := p.(type) {
+		case *packets.PublishPacket:
+			if p.Qos > 0 {
+				ack := packets.NewControlPacket(packets.Puback).(*packets.PubackPacket)
+				ack.MessageID = p.MessageID
+				s.write(ack)
+			}
+			b.publish(p.TopicName, string(p.Payload), p.Retain)
+		case *packets.SubscribePacket:
+			ack := packets.NewControlPacket(packets.Suback).(*packets.SubackPacket)
+			ack.MessageID = p.MessageID
+			ack.ReturnCodes = make([]byte, len(p.Topics))
+			s.write(ack)
+			b.mu.Lock()
+			for _, topic := range p.Topics {
+				s.topics[topic] = true
+				if payload, ok := b.retained[topic]; ok {
+					s.write(publishPacket(topic, payload, true))
+				}
+			}
+			b.mu.Unlock()
+		case *packets.PingreqPacket:
+			s.write(packets.NewControlPacket(packets.Pingresp))
+		case *packets.DisconnectPacket:
+			disconnected = true
+		}
+	}
+
+	b.mu.Lock()
+	delete(b.sessions, s)
+	b.mu.Unlock()
+	if !disconnected && connect.WillFlag {
+		b.publish(connect.WillTopic, string(connect.WillMessage), connect.WillRetain)
+	}
+}
+
+// publish delivers a message to the subscribers, like a message published by a client.
+func (b *tcpBroker) publish(topic string, payload string, retain bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if retain {
+		b.retained[topic] = []byte(payload)
+	}
+	b.history[topic] = append(b.history[topic], payload)
+	for s := range b.sessions {
+		if s.topics[topic] {
+			s.write(publishPacket(topic, []byte(payload), false))
+		}
+	}
+}
+
+func (b *tcpBroker) get(topic string) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.retained[topic])
+}
+
+func (b *tcpBroker) published(topic string) []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.history[topic]...)
+}
+
+// drop closes all connections without a disconnect, like a lost network connection.
+func (b *tcpBroker) drop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for s := range b.sessions {
+		s.conn.Close()
+	}
+}
+
+func publishPacket(topic string, payload []byte, retain bool) *packets.PublishPacket {
+	p := packets.NewControlPacket(packets.Publish).(*packets.PublishPacket)
+	p.TopicName = topic
+	p.Payload = payload
+	p.Retain = retain
+	return p
+}
+
+// newPahoBridge runs a bridge for a simulator with a PahoClient connected to broker.
+func newPahoBridge(t *testing.T, broker *tcpBroker) *nukisim.Simulator {
+	t.Helper()
+	sim, store := nukisimtest.Paired(t)
+	pool := bleflows.NewPool(func(deviceId string) (bleflows.Transport, error) {
+		return sim.Connect(), nil
+	}, store)
+	opts := mqtt.NewClientOptions().
+		AddBroker(broker.url()).
+		SetClientID("nukictl").
+		SetMaxReconnectInterval(50*time.Millisecond).
+		SetWill(nukimqtt.AvailabilityTopic(nukimqtt.DefaultTopic), "offline", 1, true)
+	client, err := nukimqtt.NewPahoClient(opts)
+	require.NoError(t, err)
+	bridge := nukimqtt.New(client, pool, []string{"sim"})
+	bridge.SetInterval(time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		bridge.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+		client.Disconnect()
+		pool.Close()
+	})
+	require.Eventually(t, func() bool { return broker.get("nuki/sim/availability") == "online" }, time.Second, 10*time.Millisecond)
+	return sim
+}
+
+func TestPahoClientCommand(t *testing.T) {
+	broker := newTCPBroker(t)
+	sim := newPahoBridge(t, broker)
+	require.Equal(t, "online", broker.get("nuki/availability"))
+
+	broker.publish("nuki/sim/command", "unlock", false)
+	require.Eventually(t, func() bool { return broker.get("nuki/sim/lock") == "UNLOCKED" }, time.Second, 10*time.Millisecond)
+	require.Equal(t, blecommands.LockStateUnlocked, sim.KeyturnerStates().LockState)
+}
+
+func TestPahoClientIgnoresRetainedCommands(t *testing.T) {
+	broker := newTCPBroker(t)
+	broker.publish("nuki/sim/command", "unlock", true)
+	sim := newPahoBridge(t, broker)
+
+	// the retained command is delivered again on every reconnect
+	broker.drop()
+	require.Eventually(t, func() bool { return reconnected(broker) }, 5*time.Second, 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, blecommands.LockStateLocked, sim.KeyturnerStates().LockState)
+}
+
+func TestPahoClientWill(t *testing.T) {
+	broker := newTCPBroker(t)
+	newPahoBridge(t, broker)
+
+	broker.drop()
+	require.Eventually(t, func() bool { return reconnected(broker) }, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, "online", broker.get("nuki/availability"))
+}
+
+// reconnected reports whether the bridge is online again after its will was published.
+func reconnected(broker *tcpBroker) bool {
+	published := broker.published("nuki/availability")
+	return slices.Contains(published, "offline") && published[len(published)-1] == "online"
+}
@@ -0,0 +1,191 @@
+// Package nukiserver exposes paired Smart Locks through a local HTTP API.
+//
+// The server keeps one authenticated flow per device open in a bleflows.Pool, which serializes the
+// requests to each device. Requests must carry the token of the server as bearer token.
+//
+//	GET  /devices/{id}/state     KeyturnerStates
+//	GET  /devices/{id}/config    Config
+//	GET  /devices/{id}/logs      log entries, newest first, see the query parameters start and count
+//	GET  /devices/{id}/battery   BatteryReport
+//	POST /devices/{id}/lock      lock, the response is the KeyturnerStates after the action
+//	POST /devices/{id}/unlock
+//	POST /devices/{id}/unlatch
+//	POST /devices/{id}/lockngo
+package nukiserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nuki-io/nuki-cli/pkg/blecommands"
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+)
+
+// DefaultTimeout is the maximum time of a command exchange with a device.
+const DefaultTimeout = 30 * time.Second
+
+type Server struct {
+	pool    *bleflows.Pool
+	store   bleflows.AuthStore
+	token   string
+	timeout time.Duration
+	resolve func(id string) (string, error)
+}
+
+// New returns a server for all devices that are paired in store. Requests must be authorized with token.
+func New(dial bleflows.Dialer, store bleflows.AuthStore, token string) *Server {
+	return &Server{
+		pool:    bleflows.NewPool(dial, store),
+		store:   store,
+		token:   token,
+		timeout: DefaultTimeout,
+	}
+}
+
+// SetTimeout sets the maximum time of a command exchange with a device, DefaultTimeout by default.
+func (s *Server) SetTimeout(timeout time.Duration) {
+	s.timeout = timeout
+}
+
+// SetResolver sets the function that maps the {id} of a request to a device ID, e.g. to accept aliases or names
+// of devices. Without a resolver only device IDs are accepted.
+func (s *Server) SetResolver(resolve func(id string) (string, error)) {
+	s.resolve = resolve
+}
+
+// Handler returns the HTTP handler of the API.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /devices/{id}/state", s.handleState)
+	mux.HandleFunc("GET /devices/{id}/config", s.handleConfig)
+	mux.HandleFunc("GET /devices/{id}/logs", s.handleLogs)
+	mux.HandleFunc("GET /devices/{id}/battery", s.handleBattery)
+	for path, action := range map[string]blecommands.Action{
+		"lock":    blecommands.Lock,
+		"unlock":  blecommands.Unlock,
+		"unlatch": blecommands.Unlatch,
+		"lockngo": blecommands.LockAndGo,
+	} {
+		mux.HandleFunc("POST /devices/{id}/"+path, s.handleAction(action))
+	}
+	return s.authorize(mux)
+}
+
+// Close disconnects all devices.
+func (s *Server) Close() {
+	s.pool.Close()
+}
+
+func (s *Server) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, fmt.Errorf("invalid or missing token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleState(w http.ResponseWriter, r *http.Request) {
+	s.respond(w, r, func(ctx context.Context, flow *bleflows.Flow) (any, error) {
+		return flow.GetStatus(ctx)
+	})
+}
+
+func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
+	s.respond(w, r, func(ctx context.Context, flow *bleflows.Flow) (any, error) {
+		return flow.GetConfig(ctx)
+	})
+}
+
+func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
+	start, err := queryInt(r, "start", 0)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	count, err := queryInt(r, "count", 20)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	s.respond(w, r, func(ctx context.Context, flow *bleflows.Flow) (any, error) {
+		entries, _, err := flow.GetLogs(ctx, start, count, false)
+		return entries, err
+	})
+}
+
+func (s *Server) handleBattery(w http.ResponseWriter, r *http.Request) {
+	s.respond(w, r, func(ctx context.Context, flow *bleflows.Flow) (any, error) {
+		return flow.GetBatteryReport(ctx)
+	})
+}
+
+func (s *Server) handleAction(action blecommands.Action) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.respond(w, r, func(ctx context.Context, flow *bleflows.Flow) (any, error) {
+			if err := flow.PerformLockOperation(ctx, action); err != nil {
+				return nil, err
+			}
+			return flow.GetStatus(ctx)
+		})
+	}
+}
+
+// respond calls fn with the flow of the device of the request and writes its result as JSON.
+func (s *Server) respond(w http.ResponseWriter, r *http.Request, fn func(ctx context.Context, flow *bleflows.Flow) (any, error)) {
+	id := r.PathValue("id")
+	if s.resolve != nil {
+		var err error
+		if id, err = s.resolve(id); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if _, err := s.store.Load(id); err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), s.timeout)
+	defer cancel()
+	var res any
+	err := s.pool.Do(ctx, id, func(ctx context.Context, flow *bleflows.Flow) error {
+		var err error
+		res, err = fn(ctx, flow)
+		return err
+	})
+	if err != nil {
+		slog.Error("Request failed", "device", id, "path", r.URL.Path, "error", err)
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+func queryInt(r *http.Request, name string, def int) (int, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, nil
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil || i < 0 {
+		return 0, fmt.Errorf("invalid %s: %q", name, v)
+	}
+	return i, nil
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
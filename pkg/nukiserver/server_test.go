@@ -0,0 +1,137 @@
+package nukiserver_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/nuki-io/nuki-cli/pkg/blecommands"
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+	"github.com/nuki-io/nuki-cli/pkg/nukiserver"
+	"github.com/nuki-io/nuki-cli/pkg/nukisim"
+	"github.com/nuki-io/nuki-cli/pkg/nukisim/nukisimtest"
+	"github.com/stretchr/testify/require"
+)
+
+const token = "secret"
+
+// newServer pairs with a simulator as device "sim" and serves it.
+func newServer(t *testing.T) (*nukisim.Simulator, *httptest.Server, *int) {
+	t.Helper()
+	sim, store := nukisimtest.Paired(t)
+
+	dials := 0
+	s := nukiserver.New(func(deviceId string) (bleflows.Transport, error) {
+		dials++
+		return sim.Connect(), nil
+	}, store, token)
+	ts := httptest.NewServer(s.Handler())
+	t.Cleanup(func() {
+		ts.Close()
+		s.Close()
+	})
+	return sim, ts, &dials
+}
+
+func do(t *testing.T, ts *httptest.Server, method, path string, v any) int {
+	t.Helper()
+	req, err := http.NewRequest(method, ts.URL+path, nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+token)
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	if v != nil {
+		require.NoError(t, json.NewDecoder(res.Body).Decode(v))
+	}
+	return res.StatusCode
+}
+
+func TestUnauthorized(t *testing.T) {
+	_, ts, _ := newServer(t)
+	res, err := http.Get(ts.URL + "/devices/sim/state")
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+}
+
+func TestStateAndConfig(t *testing.T) {
+	_, ts, dials := newServer(t)
+
+	var states map[string]any
+	require.Equal(t, http.StatusOK, do(t, ts, http.MethodGet, "/devices/sim/state", &states))
+	require.Equal(t, "Locked", states["lockState"])
+
+	var cfg map[string]any
+	require.Equal(t, http.StatusOK, do(t, ts, http.MethodGet, "/devices/sim/config", &cfg))
+	require.Equal(t, "Front Door", cfg["name"])
+
+	var battery map[string]any
+	require.Equal(t, http.StatusOK, do(t, ts, http.MethodGet, "/devices/sim/battery", &battery))
+	// the connection is kept between requests
+	require.Equal(t, 1, *dials)
+}
+
+func TestAction(t *testing.T) {
+	sim, ts, _ := newServer(t)
+
+	var states map[string]any
+	require.Equal(t, http.StatusOK, do(t, ts, http.MethodPost, "/devices/sim/unlock", &states))
+	require.Equal(t, "Unlocked", states["lockState"])
+	require.Equal(t, blecommands.LockStateUnlocked, sim.KeyturnerStates().LockState)
+
+	var logs []map[string]any
+	require.Equal(t, http.StatusOK, do(t, ts, http.MethodGet, "/devices/sim/logs?count=5", &logs))
+	require.NotEmpty(t, logs)
+}
+
+func TestConcurrentRequests(t *testing.T) {
+	_, ts, _ := newServer(t)
+	var wg sync.WaitGroup
+	for _, path := range []string{"/devices/sim/lock", "/devices/sim/unlock", "/devices/sim/lock"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			require.Equal(t, http.StatusOK, do(t, ts, http.MethodPost, path, nil))
+		}()
+	}
+	wg.Wait()
+}
+
+func TestUnknownDevice(t *testing.T) {
+	_, ts, _ := newServer(t)
+	var res map[string]string
+	require.Equal(t, http.StatusNotFound, do(t, ts, http.MethodGet, "/devices/other/state", &res))
+	require.Contains(t, res["error"], "no authorization")
+}
+
+func TestResolver(t *testing.T) {
+	sim, store := nukisimtest.Paired(t)
+	s := nukiserver.New(func(deviceId string) (bleflows.Transport, error) {
+		return sim.Connect(), nil
+	}, store, token)
+	s.SetResolver(func(id string) (string, error) {
+		switch id {
+		case "front door":
+			return nukisimtest.PairedDeviceId, nil
+		case "door":
+			return "", fmt.Errorf("%s matches several devices", id)
+		}
+		return id, nil
+	})
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+	defer s.Close()
+
+	var states map[string]any
+	require.Equal(t, http.StatusOK, do(t, ts, http.MethodGet, "/devices/front%20door/state", &states))
+	require.Equal(t, "Locked", states["lockState"])
+
+	var res map[string]string
+	require.Equal(t, http.StatusBadRequest, do(t, ts, http.MethodGet, "/devices/door/state", &res))
+	require.Contains(t, res["error"], "matches several devices")
+	require.Equal(t, http.StatusNotFound, do(t, ts, http.MethodGet, "/devices/other/state", &res))
+}
@@ -0,0 +1,89 @@
+package nukisim
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync/atomic"
+
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+)
+
+var _ bleflows.Transport = &Device{}
+
+// Device is an in-process connection to a Simulator, see Simulator.Connect.
+type Device struct {
+	session *session
+}
+
+func (d *Device) Connect() error {
+	return nil
+}
+
+func (d *Device) DiscoverPairing() error {
+	return nil
+}
+
+func (d *Device) DiscoverKeyturnerUsdio() error {
+	return nil
+}
+
+func (d *Device) IsOpener() bool {
+	return false
+}
+
+func (d *Device) Disconnect() {}
+
+func (d *Device) WritePairing(ctx context.Context, data []byte) ([]byte, error) {
+	ch, stop := d.stream(channelPairing, data)
+	return readOne(ctx, ch, stop)
+}
+
+func (d *Device) WriteUsdio(ctx context.Context, data []byte) ([]byte, error) {
+	ch, stop := d.stream(channelUsdio, data)
+	return readOne(ctx, ch, stop)
+}
+
+// WriteUsdioStream sends data and returns a channel of responses and a stop function, like
+// nukible.Device.WriteUsdioStream.
+func (d *Device) WriteUsdioStream(ctx context.Context, data []byte) (<-chan []byte, func()) {
+	return d.stream(channelUsdio, data)
+}
+
+func (d *Device) stream(ch channel, data []byte) (<-chan []byte, func()) {
+	out, deliver, stop := newNotifications()
+	go d.session.handle(ch, slices.Clone(data), deliver)
+	return out, stop
+}
+
+// newNotifications mimics BLE notifications: packets passed to deliver are received from the returned
+// channel until stop is called, later packets are dropped.
+func newNotifications() (<-chan []byte, func([]byte), func()) {
+	ch := make(chan []byte, 32)
+	var stopped atomic.Bool
+	deliver := func(buf []byte) {
+		if stopped.Load() {
+			return
+		}
+		select {
+		case ch <- buf:
+		default:
+			slog.Warn("Simulator notification dropped: receive buffer full")
+		}
+	}
+	stop := func() {
+		stopped.Store(true)
+	}
+	return ch, deliver, stop
+}
+
+// readOne reads the first packet from ch, calls stop, and returns the packet.
+func readOne(ctx context.Context, ch <-chan []byte, stop func()) ([]byte, error) {
+	defer stop()
+	select {
+	case buf := <-ch:
+		return buf, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
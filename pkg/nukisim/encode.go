@@ -0,0 +1,215 @@
+package nukisim
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/nuki-io/nuki-cli/pkg/blecommands"
+)
+
+var _ blecommands.Request = &response{}
+
+// response is a device response encoded by the simulator. The response types of blecommands can only
+// be decoded, so the simulator encodes them itself and sends them through the BleHandler as a request.
+type response struct {
+	code    blecommands.CommandCode
+	payload []byte
+}
+
+func (r *response) GetCommandCode() blecommands.CommandCode {
+	return r.code
+}
+
+func (r *response) GetPayload() []byte {
+	return r.payload
+}
+
+func encodeConfig(c *blecommands.Config) *response {
+	name := [32]byte{}
+	copy(name[:], c.Name)
+	var fw [3]byte
+	fmt.Sscanf(c.FirmwareVersion, "%d.%d.%d", &fw[0], &fw[1], &fw[2])
+	var hw [2]byte
+	fmt.Sscanf(c.HardwareRevision, "%d.%d", &hw[0], &hw[1])
+	return &response{
+		code: blecommands.CommandConfig,
+		payload: slices.Concat(
+			binary.LittleEndian.AppendUint32(nil, c.NukiID),
+			name[:],
+			binary.LittleEndian.AppendUint32(nil, math.Float32bits(c.Latitude)),
+			binary.LittleEndian.AppendUint32(nil, math.Float32bits(c.Longitude)),
+			[]byte{
+				boolToByte(c.AutoUnlatch),
+				boolToByte(c.PairingEnabled),
+				boolToByte(c.ButtonEnabled),
+				boolToByte(c.LedEnabled),
+				c.LedBrightness,
+			},
+			toNukiTime(c.CurrentTime),
+			binary.LittleEndian.AppendUint16(nil, uint16(c.TimezoneOffset)),
+			[]byte{
+				c.DstMode,
+				boolToByte(c.HasFob),
+				c.FobAction1,
+				c.FobAction2,
+				c.FobAction3,
+				boolToByte(c.SingleLock),
+				c.AdvertisingMode,
+				boolToByte(c.HasKeypad),
+			},
+			fw[:],
+			hw[:],
+			[]byte{c.HomeKitStatus},
+			binary.LittleEndian.AppendUint16(nil, c.TimezoneID),
+			[]byte{
+				byte(c.DeviceType),
+				c.Capabilities,
+				boolToByte(c.HasKeypad2),
+				c.MatterStatus,
+			},
+		),
+	}
+}
+
+func encodeKeyturnerStates(s *blecommands.KeyturnerStates) *response {
+	battery := byte(s.BatteryPercentage/2) << 2
+	if s.BatteryStateCritical {
+		battery |= 0x01
+	}
+	if s.Charging {
+		battery |= 0x02
+	}
+	return &response{
+		code: blecommands.CommandKeyturnerStates,
+		payload: slices.Concat(
+			[]byte{byte(s.NukiState), byte(s.LockState), byte(s.Trigger)},
+			toNukiTime(s.CurrentTime),
+			binary.LittleEndian.AppendUint16(nil, uint16(s.TimezoneOffset)),
+			[]byte{
+				battery,
+				s.ConfigUpdateCount,
+				s.LockNGoTimer,
+				byte(s.LastLockAction),
+				byte(s.LastLockActionTrigger),
+				byte(s.LastLockActionCompletionStatus),
+				byte(s.DoorSensorState),
+				boolToByte(s.NightmodeActive),
+				encodeAccessoryStatus(s.AccessoryBatteryState),
+				encodeRemoteAccessStatus(s.RemoteAccessStatus),
+				encodeConnectionStrength(s.BleConnectionStrength),
+				encodeConnectionStrength(s.WifiConnectionStrength),
+				byte(s.WifiConnectionStatus.WifiStatus) | byte(s.WifiConnectionStatus.SseStatus)<<2 | s.WifiConnectionStatus.WifiQuality<<4,
+				byte(s.MqttConnectionStatus.MqttStatus) | byte(s.MqttConnectionStatus.MqttUplink)<<2,
+				encodeThreadConnectionStatus(s.ThreadConnectionStatus),
+			},
+		),
+	}
+}
+
+func encodeAccessoryStatus(s blecommands.AccessoryStatus) byte {
+	return bits(s.KeypadSupported, s.KeypadBatteryCritical, s.DoorSensorSupported, s.DoorSensorBatteryCritical)
+}
+
+func encodeRemoteAccessStatus(s blecommands.RemoteAccessStatus) byte {
+	return bits(
+		s.SSEUplinkAvailable,
+		s.BridgePaired,
+		s.SSEConnectionViaWiFi,
+		s.SSEConnectionEstablished,
+		s.SSEConnectionViaThread,
+		s.ThreadSSEUplinkEnabled,
+		s.NAT64AvailableViaThread,
+	)
+}
+
+func encodeConnectionStrength(s blecommands.ConnectionStrength) byte {
+	if s.Status == blecommands.ConnectionStrengthOK {
+		return byte(s.RSSI)
+	}
+	return byte(s.Status)
+}
+
+func encodeThreadConnectionStatus(s blecommands.ThreadConnectionStatus) byte {
+	return byte(s.ThreadStatus) | byte(s.SseStatus)<<2 | bits(false, false, false, false, s.MatterCommissioningActive, s.WifiSuspended)
+}
+
+func encodeBatteryReport(r *blecommands.BatteryReport) *response {
+	return &response{
+		code: blecommands.CommandBatteryReport,
+		payload: slices.Concat(
+			binary.LittleEndian.AppendUint16(nil, r.BatteryDrain),
+			binary.LittleEndian.AppendUint16(nil, r.BatteryVoltage),
+			[]byte{boolToByte(r.CriticalBatteryState), byte(r.LockAction)},
+			binary.LittleEndian.AppendUint16(nil, r.StartVoltage),
+			binary.LittleEndian.AppendUint16(nil, r.LowestVoltage),
+			binary.LittleEndian.AppendUint16(nil, r.LockDistance),
+			[]byte{byte(r.StartTemperature)},
+			binary.LittleEndian.AppendUint16(nil, r.MaxTurnCurrent),
+			binary.LittleEndian.AppendUint16(nil, r.BatteryResistance),
+		),
+	}
+}
+
+func encodeLogEntry(e *blecommands.LogEntry) *response {
+	name := [32]byte{}
+	copy(name[:], e.AuthName)
+	return &response{
+		code: blecommands.CommandLogEntry,
+		payload: slices.Concat(
+			binary.LittleEndian.AppendUint32(nil, e.Index),
+			toNukiTime(e.Time),
+			binary.LittleEndian.AppendUint32(nil, e.AuthId),
+			name[:],
+			[]byte{byte(e.Type)},
+			e.Data,
+		),
+	}
+}
+
+func encodeLogEntryCount(c *blecommands.LogEntryCount) *response {
+	return &response{
+		code: blecommands.CommandLogEntryCount,
+		payload: slices.Concat(
+			[]byte{boolToByte(c.LoggingEnabled)},
+			binary.LittleEndian.AppendUint16(nil, c.Count),
+			[]byte{boolToByte(c.DoorSensorEnabled), boolToByte(c.DoorSensorLoggingEnabled)},
+		),
+	}
+}
+
+// bits packs flags into a byte, the first flag being bit 0.
+func bits(flags ...bool) byte {
+	var b byte
+	for i, f := range flags {
+		if f {
+			b |= 1 << i
+		}
+	}
+	return b
+}
+
+func boolToByte(b bool) byte {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// toNukiTime encodes t in UTC, the zero time is encoded as all zero bytes.
+func toNukiTime(t time.Time) []byte {
+	b := make([]byte, 7)
+	if t.IsZero() {
+		return b
+	}
+	t = t.UTC()
+	binary.LittleEndian.PutUint16(b[0:2], uint16(t.Year()))
+	b[2] = byte(t.Month())
+	b[3] = byte(t.Day())
+	b[4] = byte(t.Hour())
+	b[5] = byte(t.Minute())
+	b[6] = byte(t.Second())
+	return b
+}
@@ -0,0 +1,62 @@
+// Package nukisimtest provides an auth store and a paired simulator for tests of packages using nukisim.
+package nukisimtest
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+	"github.com/nuki-io/nuki-cli/pkg/nukisim"
+)
+
+// PairedDeviceId is the device ID under which Paired stores the authorization.
+const PairedDeviceId = "sim"
+
+var _ bleflows.AuthStore = MemoryAuthStore{}
+
+// MemoryAuthStore keeps authorizations in memory, e.g. for tests.
+type MemoryAuthStore map[string]*bleflows.AuthorizeContext
+
+func (m MemoryAuthStore) Load(deviceId string) (*bleflows.AuthorizeContext, error) {
+	ctx, ok := m[deviceId]
+	if !ok {
+		return nil, fmt.Errorf("no authorization for device with id %s found", deviceId)
+	}
+	return ctx, nil
+}
+
+func (m MemoryAuthStore) Store(deviceId string, ctx *bleflows.AuthorizeContext) error {
+	m[deviceId] = ctx
+	return nil
+}
+
+func (m MemoryAuthStore) List() ([]string, error) {
+	return slices.Sorted(maps.Keys(m)), nil
+}
+
+func (m MemoryAuthStore) Delete(deviceId string) error {
+	delete(m, deviceId)
+	return nil
+}
+
+// Paired returns a simulator named "Front Door" with nukisim.DefaultPin and fast lock actions, and a store with an
+// authorization for it as PairedDeviceId.
+func Paired(t testing.TB) (*nukisim.Simulator, MemoryAuthStore) {
+	t.Helper()
+	sim := nukisim.New(nukisim.State{Name: "Front Door", Pin: nukisim.DefaultPin})
+	sim.SetActionDuration(10 * time.Millisecond)
+	store := MemoryAuthStore{}
+	flow, err := bleflows.NewUnauthenticatedFlow(sim.Connect(), PairedDeviceId, store)
+	if err != nil {
+		t.Fatalf("failed to connect to the simulator: %s", err)
+	}
+	defer flow.DisconnectDevice()
+	if err := flow.Authorize(context.Background(), nukisim.DefaultPin); err != nil {
+		t.Fatalf("failed to pair with the simulator: %s", err)
+	}
+	return sim, store
+}
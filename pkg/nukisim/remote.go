@@ -0,0 +1,187 @@
+package nukisim
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+)
+
+// Messages between a RemoteDevice and a served Simulator are framed as channel (1 byte),
+// length (2 bytes, little endian) and data. Each response of the simulator is sent as its own frame,
+// just like BLE notifications.
+
+var _ bleflows.Transport = &RemoteDevice{}
+
+// Serve accepts connections on l and serves each with its own session, until l is closed.
+func (s *Simulator) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go s.serveConn(conn)
+	}
+}
+
+func (s *Simulator) serveConn(conn net.Conn) {
+	defer conn.Close()
+	slog.Info("Simulator client connected", "remote", conn.RemoteAddr())
+	session := newSession(s)
+	var mu sync.Mutex
+	for {
+		ch, data, err := readFrame(conn)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				slog.Warn("Simulator failed to read from client", "error", err)
+			}
+			slog.Info("Simulator client disconnected", "remote", conn.RemoteAddr())
+			return
+		}
+		session.handle(ch, data, func(buf []byte) {
+			mu.Lock()
+			defer mu.Unlock()
+			if err := writeFrame(conn, ch, buf); err != nil {
+				slog.Warn("Simulator failed to write to client", "error", err)
+			}
+		})
+	}
+}
+
+// RemoteDevice is a connection to a Simulator served through TCP.
+type RemoteDevice struct {
+	addr string
+	conn net.Conn
+
+	mu      sync.Mutex
+	channel channel
+	deliver func([]byte)
+}
+
+// NewRemoteDevice returns a transport to the simulator served at addr, e.g. by 'nukictl sim'.
+// It connects on Connect.
+func NewRemoteDevice(addr string) *RemoteDevice {
+	return &RemoteDevice{addr: addr}
+}
+
+func (d *RemoteDevice) Connect() error {
+	if d.conn != nil {
+		return nil
+	}
+	conn, err := net.DialTimeout("tcp", d.addr, 5*time.Second)
+	if err != nil {
+		return fmt.Errorf("failed to connect to simulator at %s: %w", d.addr, err)
+	}
+	d.conn = conn
+	go d.receive(conn)
+	return nil
+}
+
+func (d *RemoteDevice) DiscoverPairing() error {
+	return nil
+}
+
+func (d *RemoteDevice) DiscoverKeyturnerUsdio() error {
+	return nil
+}
+
+func (d *RemoteDevice) IsOpener() bool {
+	return false
+}
+
+func (d *RemoteDevice) Disconnect() {
+	if d.conn == nil {
+		return
+	}
+	if err := d.conn.Close(); err != nil {
+		slog.Error("Error disconnecting from simulator", "error", err)
+	}
+	d.conn = nil
+}
+
+func (d *RemoteDevice) WritePairing(ctx context.Context, data []byte) ([]byte, error) {
+	ch, stop, err := d.stream(channelPairing, data)
+	if err != nil {
+		return nil, err
+	}
+	return readOne(ctx, ch, stop)
+}
+
+func (d *RemoteDevice) WriteUsdio(ctx context.Context, data []byte) ([]byte, error) {
+	ch, stop, err := d.stream(channelUsdio, data)
+	if err != nil {
+		return nil, err
+	}
+	return readOne(ctx, ch, stop)
+}
+
+// WriteUsdioStream sends data and returns a channel of responses and a stop function, like
+// nukible.Device.WriteUsdioStream.
+func (d *RemoteDevice) WriteUsdioStream(ctx context.Context, data []byte) (<-chan []byte, func()) {
+	ch, stop, err := d.stream(channelUsdio, data)
+	if err != nil {
+		slog.Error("Failed to write to simulator", "error", err)
+	}
+	return ch, stop
+}
+
+func (d *RemoteDevice) stream(ch channel, data []byte) (<-chan []byte, func(), error) {
+	out, deliver, stop := newNotifications()
+	d.mu.Lock()
+	d.channel = ch
+	d.deliver = deliver
+	d.mu.Unlock()
+	if d.conn == nil {
+		stop()
+		return out, stop, fmt.Errorf("not connected to simulator at %s", d.addr)
+	}
+	if err := writeFrame(d.conn, ch, data); err != nil {
+		stop()
+		return out, stop, fmt.Errorf("failed to write to simulator: %w", err)
+	}
+	return out, stop, nil
+}
+
+func (d *RemoteDevice) receive(conn net.Conn) {
+	for {
+		ch, data, err := readFrame(conn)
+		if err != nil {
+			return
+		}
+		d.mu.Lock()
+		deliver := d.deliver
+		subscribed := d.channel == ch
+		d.mu.Unlock()
+		if deliver != nil && subscribed {
+			deliver(data)
+		}
+	}
+}
+
+func writeFrame(w io.Writer, ch channel, data []byte) error {
+	buf := make([]byte, 0, 3+len(data))
+	buf = append(buf, byte(ch))
+	buf = binary.LittleEndian.AppendUint16(buf, uint16(len(data)))
+	buf = append(buf, data...)
+	_, err := w.Write(buf)
+	return err
+}
+
+func readFrame(r io.Reader) (channel, []byte, error) {
+	var header [3]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, nil, err
+	}
+	data := make([]byte, binary.LittleEndian.Uint16(header[1:]))
+	if _, err := io.ReadFull(r, data); err != nil {
+		return 0, nil, err
+	}
+	return channel(header[0]), data, nil
+}
@@ -0,0 +1,508 @@
+package nukisim
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/nuki-io/nuki-cli/pkg/blecommands"
+	"golang.org/x/crypto/nacl/box"
+)
+
+// channel is the characteristic a message is written to.
+type channel byte
+
+const (
+	channelPairing channel = 0x01 // pairing GDIO, unencrypted
+	channelUsdio   channel = 0x02 // keyturner USDIO, encrypted
+)
+
+// Error codes sent in error reports, see the errors in blecommands.
+const (
+	errNotPairing       byte = 0x10
+	errBadAuthenticator byte = 0x11
+	errPairingBadParam  byte = 0x12
+	errNotAuthorized    byte = 0x20
+	errBadPin           byte = 0x21
+	errBadNonce         byte = 0x22
+	errBadParameter     byte = 0x23
+	errInvalidAuthId    byte = 0x24
+	errBusy             byte = 0x45
+	errBadCrc           byte = 0xFD
+	errBadLength        byte = 0xFE
+	errUnknown          byte = 0xFF
+)
+
+// authorizationDataLength is the payload length of AuthorizationData: authenticator, ID type, app ID, name and nonce.
+const authorizationDataLength = 32 + 1 + 4 + 32 + 32
+
+// session is the device side of a single connection. It holds the state of a pairing in progress
+// and the last challenge handed out, which has to be sent back with the next protected command.
+type session struct {
+	sim *Simulator
+	mu  sync.Mutex
+
+	challenge []byte
+
+	cliPublicKey []byte
+	sharedKey    []byte
+	pending      *Authorization
+	pendingNonce []byte
+}
+
+func newSession(sim *Simulator) *session {
+	return &session{sim: sim}
+}
+
+// handle processes a message written to ch and passes each response to emit. Lock actions emit
+// their responses over time, handle returns once the command is complete.
+func (s *session) handle(ch channel, data []byte, emit func([]byte)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch ch {
+	case channelPairing:
+		s.handlePairing(data, emit)
+	case channelUsdio:
+		s.handleUsdio(data, emit)
+	default:
+		slog.Warn("Message written to unknown channel", "channel", ch)
+	}
+}
+
+// request is a decoded command together with the means to answer it.
+type request struct {
+	cmd     blecommands.CommandCode
+	payload []byte
+	auth    Authorization
+	reply   func(blecommands.Request)
+}
+
+func (r *request) fail(code byte) {
+	r.reply(&blecommands.ErrorReport{ErrorCode: code, CommandIdentifier: r.cmd})
+}
+
+func (s *session) handlePairing(data []byte, emit func([]byte)) {
+	h := blecommands.NewBleHandler(nil, nil)
+	r := &request{reply: func(res blecommands.Request) { emit(h.ToMessage(res)) }}
+	if len(data) < 4 {
+		r.fail(errBadLength)
+		return
+	}
+	r.cmd = blecommands.CommandCode(binary.LittleEndian.Uint16(data[0:2]))
+	r.payload = data[2 : len(data)-2]
+	if blecommands.CRC(data[:len(data)-2]) != binary.LittleEndian.Uint16(data[len(data)-2:]) {
+		r.fail(errBadCrc)
+		return
+	}
+	slog.Debug("Simulator received pairing command", "cmd", r.cmd, "payload", fmt.Sprintf("%x", r.payload))
+
+	switch r.cmd {
+	case blecommands.CommandRequestData:
+		if len(r.payload) != 2 || blecommands.CommandCode(binary.LittleEndian.Uint16(r.payload)) != blecommands.CommandPublicKey {
+			r.fail(errPairingBadParam)
+			return
+		}
+		s.sim.mu.Lock()
+		pairing := s.sim.config.PairingEnabled
+		s.sim.mu.Unlock()
+		if !pairing {
+			r.fail(errNotPairing)
+			return
+		}
+		r.reply(&blecommands.PublicKey{PublicKey: s.sim.publicKey})
+
+	case blecommands.CommandPublicKey:
+		if len(r.payload) != 32 {
+			r.fail(errBadLength)
+			return
+		}
+		s.cliPublicKey = slices.Clone(r.payload)
+		sharedKey := [32]byte{}
+		box.Precompute(&sharedKey, (*[32]byte)(s.cliPublicKey), (*[32]byte)(s.sim.state.PrivateKey))
+		s.sharedKey = sharedKey[:]
+		r.reply(s.newChallenge())
+
+	case blecommands.CommandAuthorizationAuthenticator:
+		if s.sharedKey == nil || !hmac.Equal(r.payload, s.authenticator(s.cliPublicKey, s.sim.publicKey, s.challenge)) {
+			r.fail(errBadAuthenticator)
+			return
+		}
+		r.reply(s.newChallenge())
+
+	case blecommands.CommandAuthorizationData:
+		if len(r.payload) != authorizationDataLength {
+			r.fail(errBadLength)
+			return
+		}
+		if s.sharedKey == nil || !hmac.Equal(r.payload[:32], s.authenticator(r.payload[32:], s.challenge)) {
+			r.fail(errBadAuthenticator)
+			return
+		}
+		s.pending = &Authorization{
+			ID:        s.sim.nextAuthorizationID(),
+			AppID:     slices.Clone(r.payload[33:37]),
+			Name:      string(bytes.Trim(r.payload[37:69], "\x00")),
+			SharedKey: s.sharedKey,
+		}
+		s.pendingNonce = randomBytes(32)
+		authId := binary.LittleEndian.AppendUint32(nil, s.pending.ID)
+		uuid := randomBytes(16)
+		r.reply(&blecommands.AuthorizationID{
+			Authenticator: s.authenticator(authId, uuid, s.pendingNonce, r.payload[69:]),
+			AuthId:        authId,
+			Uuid:          uuid,
+			Nonce:         s.pendingNonce,
+		})
+
+	case blecommands.CommandAuthorizationIDConfirmation:
+		if len(r.payload) != 36 {
+			r.fail(errBadLength)
+			return
+		}
+		if s.pending == nil || !hmac.Equal(r.payload[:32], s.authenticator(r.payload[32:], s.pendingNonce)) {
+			r.fail(errBadAuthenticator)
+			return
+		}
+		s.sim.addAuthorization(*s.pending)
+		slog.Info("Simulator paired new authorization", "id", s.pending.ID, "name", s.pending.Name)
+		s.pending = nil
+		r.reply(&blecommands.Status{Status: blecommands.StatusComplete})
+
+	default:
+		r.fail(errUnknown)
+	}
+}
+
+func (s *session) handleUsdio(data []byte, emit func([]byte)) {
+	if len(data) < 30 {
+		slog.Warn("Simulator dropped short USDIO message", "length", len(data))
+		return
+	}
+	authId := data[24:28]
+	auth, ok := s.sim.authorization(binary.LittleEndian.Uint32(authId))
+	var pdata []byte
+	if ok {
+		var err error
+		pdata, err = blecommands.NewCrypto(auth.SharedKey).Decrypt(data[0:24], data[30:])
+		ok = err == nil && len(pdata) >= 8 && bytes.Equal(pdata[0:4], authId)
+	}
+	if !ok {
+		// without a shared key the error can only be reported unencrypted
+		h := blecommands.NewBleHandler(nil, nil)
+		emit(h.ToMessage(&blecommands.ErrorReport{ErrorCode: errNotAuthorized}))
+		return
+	}
+
+	h := blecommands.NewBleHandler(blecommands.NewCrypto(auth.SharedKey), authId)
+	r := &request{
+		cmd:     blecommands.CommandCode(binary.LittleEndian.Uint16(pdata[4:6])),
+		payload: pdata[6 : len(pdata)-2],
+		auth:    auth,
+		reply:   func(res blecommands.Request) { emit(h.ToEncryptedMessage(res, randomBytes(24))) },
+	}
+	if blecommands.CRC(pdata[:len(pdata)-2]) != binary.LittleEndian.Uint16(pdata[len(pdata)-2:]) {
+		r.fail(errBadCrc)
+		return
+	}
+	slog.Debug("Simulator received command", "cmd", r.cmd, "payload", fmt.Sprintf("%x", r.payload))
+
+	switch r.cmd {
+	case blecommands.CommandRequestData:
+		s.requestData(r)
+	case blecommands.CommandRequestConfig:
+		if _, ok := s.verify(r, false); ok {
+			s.sim.mu.Lock()
+			cfg := s.sim.config
+			s.sim.mu.Unlock()
+			cfg.CurrentTime = time.Now()
+			r.reply(encodeConfig(&cfg))
+		}
+	case blecommands.CommandSetConfig:
+		if args, ok := s.verify(r, true); ok {
+			s.setConfig(r, args)
+		}
+	case blecommands.CommandLockAction:
+		if args, ok := s.verify(r, false); ok {
+			if len(args) < 6 {
+				r.fail(errBadLength)
+				return
+			}
+			s.lockAction(r, blecommands.Action(args[0]))
+		}
+	case blecommands.CommandRequestLogEntries:
+		if args, ok := s.verify(r, true); ok {
+			s.logEntries(r, args)
+		}
+	case blecommands.CommandEnableLogging:
+		if args, ok := s.verify(r, true); ok {
+			if len(args) != 1 {
+				r.fail(errBadLength)
+				return
+			}
+			s.sim.mu.Lock()
+			s.sim.logging = args[0] != 0
+			s.sim.addLog(r.auth.ID, r.auth.Name, blecommands.LoggingEnabledDisabled, []byte{args[0]})
+			s.sim.mu.Unlock()
+			r.reply(&blecommands.Status{Status: blecommands.StatusComplete})
+		}
+	case blecommands.CommandSetSecurityPIN:
+		if args, ok := s.verify(r, true); ok {
+			s.setSecurityPin(r, args)
+		}
+	case blecommands.CommandRemoveAuthorizationEntry:
+		if args, ok := s.verify(r, true); ok {
+			if len(args) != 4 {
+				r.fail(errBadLength)
+				return
+			}
+			if !s.sim.removeAuthorization(binary.LittleEndian.Uint32(args)) {
+				r.fail(errInvalidAuthId)
+				return
+			}
+			r.reply(&blecommands.Status{Status: blecommands.StatusComplete})
+		}
+	case blecommands.CommandVerifySecurityPIN, blecommands.CommandRequestReboot, blecommands.CommandUpdateTime:
+		if _, ok := s.verify(r, true); ok {
+			r.reply(&blecommands.Status{Status: blecommands.StatusComplete})
+		}
+	case blecommands.CommandRequestCalibration:
+		if _, ok := s.verify(r, true); ok {
+			s.calibrate(r)
+		}
+	default:
+		r.fail(errUnknown)
+	}
+}
+
+func (s *session) requestData(r *request) {
+	if len(r.payload) < 2 {
+		r.fail(errBadLength)
+		return
+	}
+	s.sim.mu.Lock()
+	states := s.sim.states
+	battery := s.sim.battery
+	s.sim.mu.Unlock()
+
+	switch blecommands.CommandCode(binary.LittleEndian.Uint16(r.payload)) {
+	case blecommands.CommandChallenge:
+		r.reply(s.newChallenge())
+	case blecommands.CommandKeyturnerStates:
+		states.CurrentTime = time.Now()
+		r.reply(encodeKeyturnerStates(&states))
+	case blecommands.CommandBatteryReport:
+		r.reply(encodeBatteryReport(&battery))
+	default:
+		r.fail(errBadParameter)
+	}
+}
+
+// verify checks the nonce of a protected command against the last challenge and, if withPin is set,
+// the security PIN that follows the nonce. It returns the arguments in front of the nonce. On failure,
+// the error is reported and ok is false. Each challenge can only be used once.
+func (s *session) verify(r *request, withPin bool) (args []byte, ok bool) {
+	challenge := s.challenge
+	s.challenge = nil
+
+	s.sim.mu.Lock()
+	pin := blecommands.NewPin(s.sim.state.Pin).GetPinBytes()
+	s.sim.mu.Unlock()
+	if !withPin {
+		pin = nil
+	}
+
+	if len(r.payload) < 32+len(pin) {
+		r.fail(errBadLength)
+		return nil, false
+	}
+	end := len(r.payload) - len(pin)
+	if challenge == nil || !bytes.Equal(r.payload[end-32:end], challenge) {
+		r.fail(errBadNonce)
+		return nil, false
+	}
+	if !bytes.Equal(r.payload[end:], pin) {
+		r.fail(errBadPin)
+		return nil, false
+	}
+	return r.payload[:end-32], true
+}
+
+func (s *session) lockAction(r *request, action blecommands.Action) {
+	var moving, final blecommands.LockState
+	switch action {
+	case blecommands.Unlock:
+		moving, final = blecommands.LockStateUnlocking, blecommands.LockStateUnlocked
+	case blecommands.Lock, blecommands.FullLock:
+		moving, final = blecommands.LockStateLocking, blecommands.LockStateLocked
+	case blecommands.Unlatch:
+		moving, final = blecommands.LockStateUnlatching, blecommands.LockStateUnlatched
+	case blecommands.LockAndGo, blecommands.LockAndGoUnlatch:
+		moving, final = blecommands.LockStateUnlockedLockNGo, blecommands.LockStateLocked
+	default:
+		r.fail(errBadParameter)
+		return
+	}
+
+	s.sim.mu.Lock()
+	if isMoving(s.sim.states.LockState) {
+		s.sim.mu.Unlock()
+		r.fail(errBusy)
+		return
+	}
+	s.sim.states.LockState = moving
+	s.sim.states.Trigger = blecommands.TriggerSystem
+	states := s.sim.states
+	duration := s.sim.actionDuration
+	s.sim.mu.Unlock()
+
+	r.reply(&blecommands.Status{Status: blecommands.StatusAccepted})
+	states.CurrentTime = time.Now()
+	r.reply(encodeKeyturnerStates(&states))
+
+	time.Sleep(duration)
+
+	s.sim.mu.Lock()
+	s.sim.states.LockState = final
+	s.sim.states.LastLockAction = final
+	s.sim.states.LastLockActionTrigger = blecommands.TriggerSystem
+	s.sim.states.LastLockActionCompletionStatus = blecommands.StatusComplete
+	s.sim.addLog(r.auth.ID, r.auth.Name, blecommands.LogLockAction,
+		[]byte{byte(action), byte(blecommands.TriggerSystem), 0, byte(blecommands.StatusComplete)})
+	states = s.sim.states
+	s.sim.mu.Unlock()
+
+	states.CurrentTime = time.Now()
+	r.reply(encodeKeyturnerStates(&states))
+	r.reply(&blecommands.Status{Status: blecommands.StatusComplete})
+}
+
+func isMoving(s blecommands.LockState) bool {
+	return s == blecommands.LockStateUnlocking || s == blecommands.LockStateLocking ||
+		s == blecommands.LockStateUnlatching || s == blecommands.LockStateCalibration
+}
+
+func (s *session) calibrate(r *request) {
+	s.sim.mu.Lock()
+	if isMoving(s.sim.states.LockState) {
+		s.sim.mu.Unlock()
+		r.fail(errBusy)
+		return
+	}
+	s.sim.states.LockState = blecommands.LockStateCalibration
+	duration := s.sim.actionDuration
+	s.sim.mu.Unlock()
+
+	r.reply(&blecommands.Status{Status: blecommands.StatusAccepted})
+	time.Sleep(duration)
+
+	s.sim.mu.Lock()
+	s.sim.states.LockState = blecommands.LockStateLocked
+	s.sim.addLog(r.auth.ID, r.auth.Name, blecommands.LogCalibration,
+		[]byte{byte(blecommands.Lock), byte(blecommands.TriggerSystem), 0, byte(blecommands.StatusComplete)})
+	s.sim.mu.Unlock()
+	r.reply(&blecommands.Status{Status: blecommands.StatusComplete})
+}
+
+func (s *session) logEntries(r *request, args []byte) {
+	if len(args) != 8 {
+		r.fail(errBadLength)
+		return
+	}
+	start := binary.LittleEndian.Uint32(args[0:4])
+	count := int(binary.LittleEndian.Uint16(args[4:6]))
+	order := blecommands.LogSortOrder(args[6])
+	withCount := args[7] != 0
+
+	s.sim.mu.Lock()
+	entries := slices.Clone(s.sim.logs)
+	logging := s.sim.logging
+	s.sim.mu.Unlock()
+
+	if order == blecommands.LogSortOrderDescending {
+		slices.Reverse(entries)
+	}
+	if withCount {
+		r.reply(encodeLogEntryCount(&blecommands.LogEntryCount{LoggingEnabled: logging, Count: uint16(len(entries))}))
+	}
+	sent := 0
+	for _, e := range entries {
+		if sent == count {
+			break
+		}
+		// a start index of 0 starts with the first entry in the requested order
+		if start != 0 && (order == blecommands.LogSortOrderDescending && e.Index > start ||
+			order != blecommands.LogSortOrderDescending && e.Index < start) {
+			continue
+		}
+		r.reply(encodeLogEntry(&e))
+		sent++
+	}
+	r.reply(&blecommands.Status{Status: blecommands.StatusComplete})
+}
+
+func (s *session) setConfig(r *request, args []byte) {
+	if len(args) != 55 {
+		r.fail(errBadLength)
+		return
+	}
+	s.sim.mu.Lock()
+	c := &s.sim.config
+	c.Name = string(bytes.Trim(args[0:32], "\x00"))
+	c.Latitude = math.Float32frombits(binary.LittleEndian.Uint32(args[32:36]))
+	c.Longitude = math.Float32frombits(binary.LittleEndian.Uint32(args[36:40]))
+	c.AutoUnlatch = args[40] != 0
+	c.PairingEnabled = args[41] != 0
+	c.ButtonEnabled = args[42] != 0
+	c.LedEnabled = args[43] != 0
+	c.LedBrightness = args[44]
+	c.TimezoneOffset = int16(binary.LittleEndian.Uint16(args[45:47]))
+	c.DstMode = args[47]
+	c.FobAction1 = args[48]
+	c.FobAction2 = args[49]
+	c.FobAction3 = args[50]
+	c.SingleLock = args[51] != 0
+	c.AdvertisingMode = args[52]
+	c.TimezoneID = binary.LittleEndian.Uint16(args[53:55])
+	s.sim.states.ConfigUpdateCount++
+	s.sim.state.Name = c.Name
+	s.sim.stateChanged()
+	s.sim.mu.Unlock()
+	r.reply(&blecommands.Status{Status: blecommands.StatusComplete})
+}
+
+func (s *session) setSecurityPin(r *request, args []byte) {
+	var pin string
+	switch len(args) {
+	case 2:
+		pin = fmt.Sprintf("%04d", binary.LittleEndian.Uint16(args))
+	case 4:
+		pin = fmt.Sprintf("%06d", binary.LittleEndian.Uint32(args))
+	default:
+		r.fail(errBadLength)
+		return
+	}
+	s.sim.mu.Lock()
+	s.sim.state.Pin = pin
+	s.sim.stateChanged()
+	s.sim.mu.Unlock()
+	r.reply(&blecommands.Status{Status: blecommands.StatusComplete})
+}
+
+func (s *session) newChallenge() *blecommands.Challenge {
+	s.challenge = randomBytes(32)
+	return &blecommands.Challenge{Nonce: s.challenge}
+}
+
+// authenticator returns the HMAC-SHA256 of parts with the shared key of the pairing in progress.
+func (s *session) authenticator(parts ...[]byte) []byte {
+	h := hmac.New(sha256.New, s.sharedKey)
+	h.Write(slices.Concat(parts...))
+	return h.Sum(nil)
+}
@@ -0,0 +1,253 @@
+// Package nukisim implements the device side of the Nuki BLE protocol, so that bleflows and the
+// CLI can be exercised without a Bluetooth adapter or a real Smart Lock.
+//
+// A Simulator holds the state of one virtual Smart Lock. Connections to it either run in-process
+// (see Simulator.Connect) or through TCP (see Simulator.Serve and NewRemoteDevice). Both implement bleflows.Transport.
+package nukisim
+
+import (
+	crypto_rand "crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/nuki-io/nuki-cli/pkg/blecommands"
+	"golang.org/x/crypto/curve25519"
+)
+
+// DefaultPin is the security PIN of a simulator created without one.
+const DefaultPin = "123456"
+
+// DefaultActionDuration is the time a simulated lock action takes.
+const DefaultActionDuration = 1500 * time.Millisecond
+
+// Authorization is a paired client of the simulator.
+type Authorization struct {
+	ID        uint32 `json:"id"`
+	Name      string `json:"name"`
+	AppID     []byte `json:"appId"`
+	SharedKey []byte `json:"sharedKey"`
+}
+
+// State is the persistent part of a simulator: its identity, keys and authorizations.
+type State struct {
+	NukiID         uint32          `json:"nukiId"`
+	Name           string          `json:"name"`
+	Pin            string          `json:"pin"`
+	PrivateKey     []byte          `json:"privateKey"`
+	Authorizations []Authorization `json:"authorizations"`
+}
+
+type Simulator struct {
+	mu sync.Mutex
+
+	state     State
+	publicKey []byte
+
+	config  blecommands.Config
+	states  blecommands.KeyturnerStates
+	battery blecommands.BatteryReport
+	logs    []blecommands.LogEntry
+	logging bool
+
+	actionDuration time.Duration
+	onStateChange  func(State)
+}
+
+// New creates a simulated Smart Lock from state. Missing values are initialized: a random Nuki ID
+// and key pair, the name "Nuki_SIM_" followed by the Nuki ID and DefaultPin as security PIN.
+func New(state State) *Simulator {
+	if state.NukiID == 0 {
+		state.NukiID = binary.LittleEndian.Uint32(randomBytes(4))
+	}
+	if state.Name == "" {
+		state.Name = fmt.Sprintf("Nuki_SIM_%08X", state.NukiID)
+	}
+	if state.Pin == "" {
+		state.Pin = DefaultPin
+	}
+	if len(state.PrivateKey) != curve25519.ScalarSize {
+		state.PrivateKey = randomBytes(curve25519.ScalarSize)
+	}
+	publicKey, err := curve25519.X25519(state.PrivateKey, curve25519.Basepoint)
+	if err != nil {
+		panic(err)
+	}
+
+	now := time.Now()
+	return &Simulator{
+		state:     state,
+		publicKey: publicKey,
+		config: blecommands.Config{
+			NukiID:           state.NukiID,
+			Name:             state.Name,
+			Latitude:         52.5200,
+			Longitude:        13.4050,
+			PairingEnabled:   true,
+			ButtonEnabled:    true,
+			LedEnabled:       true,
+			LedBrightness:    3,
+			TimezoneOffset:   60,
+			DstMode:          1,
+			FirmwareVersion:  "4.2.1",
+			HardwareRevision: "1.0",
+			TimezoneID:       37, // Europe/Berlin
+			DeviceType:       blecommands.DeviceTypeSmartLock3,
+		},
+		states: blecommands.KeyturnerStates{
+			NukiState:             blecommands.NukiStateDoorMode,
+			TimezoneOffset:        60,
+			LockState:             blecommands.LockStateLocked,
+			BatteryPercentage:     84,
+			LastLockAction:        blecommands.LockStateLocked,
+			DoorSensorState:       blecommands.DoorSensorUnavailable,
+			BleConnectionStrength: blecommands.ConnectionStrength{RSSI: -60, Status: blecommands.ConnectionStrengthOK},
+		},
+		battery: blecommands.BatteryReport{
+			BatteryDrain:      470,
+			BatteryVoltage:    5520,
+			LockAction:        blecommands.Lock,
+			StartVoltage:      5600,
+			LowestVoltage:     5380,
+			LockDistance:      540,
+			StartTemperature:  21,
+			MaxTurnCurrent:    410,
+			BatteryResistance: 180,
+		},
+		logs: []blecommands.LogEntry{{
+			Index: 1,
+			Time:  now.Add(-time.Hour).UTC().Truncate(time.Second),
+			Type:  blecommands.LogInitializationRun,
+			Data:  []byte{byte(blecommands.Lock), byte(blecommands.TriggerSystem), 0, byte(blecommands.StatusComplete)},
+		}},
+		logging:        true,
+		actionDuration: DefaultActionDuration,
+	}
+}
+
+// SetActionDuration sets the time a lock action takes until the final state is reached.
+func (s *Simulator) SetActionDuration(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.actionDuration = d
+}
+
+// SetPairingEnabled enables or disables the pairing mode. Requesting the public key fails with
+// P_ERROR_NOT_PAIRING while it is disabled.
+func (s *Simulator) SetPairingEnabled(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.config.PairingEnabled = enabled
+}
+
+// OnStateChange registers fn to be called with the new state whenever the persistent state changes,
+// e.g. after a client has been paired.
+func (s *Simulator) OnStateChange(fn func(State)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onStateChange = fn
+}
+
+// State returns a copy of the persistent state.
+func (s *Simulator) State() State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.snapshot()
+}
+
+// KeyturnerStates returns the current state of the simulated lock.
+func (s *Simulator) KeyturnerStates() blecommands.KeyturnerStates {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.states
+}
+
+// Connect returns a new in-process connection to the simulator.
+func (s *Simulator) Connect() *Device {
+	return &Device{session: newSession(s)}
+}
+
+func (s *Simulator) snapshot() State {
+	state := s.state
+	state.Authorizations = append([]Authorization(nil), s.state.Authorizations...)
+	return state
+}
+
+// stateChanged must be called with s.mu held.
+func (s *Simulator) stateChanged() {
+	if s.onStateChange != nil {
+		s.onStateChange(s.snapshot())
+	}
+}
+
+func (s *Simulator) authorization(id uint32) (Authorization, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, a := range s.state.Authorizations {
+		if a.ID == id {
+			return a, true
+		}
+	}
+	return Authorization{}, false
+}
+
+func (s *Simulator) addAuthorization(a Authorization) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.Authorizations = append(s.state.Authorizations, a)
+	s.stateChanged()
+}
+
+// removeAuthorization removes the authorization with the given ID and reports whether it existed.
+func (s *Simulator) removeAuthorization(id uint32) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := slices.IndexFunc(s.state.Authorizations, func(a Authorization) bool { return a.ID == id })
+	if i < 0 {
+		return false
+	}
+	s.state.Authorizations = slices.Delete(s.state.Authorizations, i, i+1)
+	s.stateChanged()
+	return true
+}
+
+func (s *Simulator) nextAuthorizationID() uint32 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := uint32(1)
+	for _, a := range s.state.Authorizations {
+		if a.ID >= id {
+			id = a.ID + 1
+		}
+	}
+	return id
+}
+
+// addLog appends a log entry, must be called with s.mu held.
+func (s *Simulator) addLog(authId uint32, authName string, t blecommands.LogEntryType, data []byte) {
+	if !s.logging && t != blecommands.LoggingEnabledDisabled {
+		return
+	}
+	var index uint32 = 1
+	if len(s.logs) > 0 {
+		index = s.logs[len(s.logs)-1].Index + 1
+	}
+	s.logs = append(s.logs, blecommands.LogEntry{
+		Index:    index,
+		Time:     time.Now().UTC().Truncate(time.Second),
+		AuthId:   authId,
+		AuthName: authName,
+		Type:     t,
+		Data:     data,
+	})
+}
+
+func randomBytes(n int) []byte {
+	buf := make([]byte, n)
+	if _, err := crypto_rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return buf
+}
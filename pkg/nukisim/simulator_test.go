@@ -0,0 +1,162 @@
+package nukisim_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/nuki-io/nuki-cli/pkg/blecommands"
+	"github.com/nuki-io/nuki-cli/pkg/bleflows"
+	"github.com/nuki-io/nuki-cli/pkg/nukisim"
+	"github.com/nuki-io/nuki-cli/pkg/nukisim/nukisimtest"
+	"github.com/stretchr/testify/require"
+)
+
+func newSimulator() *nukisim.Simulator {
+	sim := nukisim.New(nukisim.State{Name: "Front Door", Pin: "123456"})
+	sim.SetActionDuration(10 * time.Millisecond)
+	return sim
+}
+
+// pair returns a paired simulator and an authenticated flow to it on a new connection.
+func pair(t *testing.T) (*nukisim.Simulator, *bleflows.Flow) {
+	t.Helper()
+	sim, store := nukisimtest.Paired(t)
+	flow, err := bleflows.NewAuthenticatedFlow(sim.Connect(), nukisimtest.PairedDeviceId, store)
+	require.NoError(t, err)
+	return sim, flow
+}
+
+func TestPairAndReadConfig(t *testing.T) {
+	sim, flow := pair(t)
+	ctx := context.Background()
+
+	require.Len(t, sim.State().Authorizations, 1)
+
+	cfg, err := flow.GetConfig(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "Front Door", cfg.Name)
+	require.Equal(t, sim.State().NukiID, cfg.NukiID)
+	require.Equal(t, blecommands.DeviceTypeSmartLock3, cfg.DeviceType)
+	require.Equal(t, "4.2.1", cfg.FirmwareVersion)
+
+	states, err := flow.GetStatus(ctx)
+	require.NoError(t, err)
+	require.Equal(t, blecommands.LockStateLocked, states.LockState)
+	require.Equal(t, 84, states.BatteryPercentage)
+
+	battery, err := flow.GetBatteryReport(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint16(5520), battery.BatteryVoltage)
+}
+
+func TestPairingDisabled(t *testing.T) {
+	sim := newSimulator()
+	sim.SetPairingEnabled(false)
+
+	flow, err := bleflows.NewUnauthenticatedFlow(sim.Connect(), "sim", nukisimtest.MemoryAuthStore{})
+	require.NoError(t, err)
+	err = flow.Authorize(context.Background(), "123456")
+	require.ErrorContains(t, err, "P_ERROR_NOT_PAIRING")
+}
+
+func TestLockActionAndLogs(t *testing.T) {
+	sim, flow := pair(t)
+	ctx := context.Background()
+
+	require.NoError(t, flow.PerformLockOperation(ctx, blecommands.Unlock))
+	require.Equal(t, blecommands.LockStateUnlocked, sim.KeyturnerStates().LockState)
+
+	states, err := flow.GetStatus(ctx)
+	require.NoError(t, err)
+	require.Equal(t, blecommands.LockStateUnlocked, states.LockState)
+	require.Equal(t, blecommands.LockStateUnlocked, states.LastLockAction)
+
+	entries, count, err := flow.GetLogs(ctx, 0, 10, true)
+	require.NoError(t, err)
+	require.NotNil(t, count)
+	require.Equal(t, uint16(2), count.Count)
+	require.Len(t, entries, 2)
+	// newest first
+	require.Equal(t, blecommands.LogLockAction, entries[0].Type)
+	require.Equal(t, byte(blecommands.Unlock), entries[0].Data[0])
+	require.Contains(t, entries[0].AuthName, "Nuki CLI")
+}
+
+func TestLockActionProgress(t *testing.T) {
+	_, flow := pair(t)
+
+	var progress []bleflows.LockProgressType
+	var moving []blecommands.LockState
+	states, err := flow.PerformLockOperationWithProgress(context.Background(), blecommands.Unlock, func(p bleflows.LockProgress) {
+		progress = append(progress, p.Type)
+		if p.Type == bleflows.LockProgressStates {
+			moving = append(moving, p.States.LockState)
+		}
+	})
+	require.NoError(t, err)
+	require.Equal(t, []bleflows.LockProgressType{
+		bleflows.LockProgressAccepted, bleflows.LockProgressStates, bleflows.LockProgressStates, bleflows.LockProgressComplete,
+	}, progress)
+	require.Equal(t, []blecommands.LockState{blecommands.LockStateUnlocking, blecommands.LockStateUnlocked}, moving)
+	require.Equal(t, blecommands.LockStateUnlocked, states.LockState)
+	require.Contains(t, blecommands.ExpectedLockStates(blecommands.Unlock), states.LockState)
+}
+
+func TestBadPin(t *testing.T) {
+	sim, store := nukisimtest.Paired(t)
+	store[nukisimtest.PairedDeviceId].Pin = "654321"
+	flow, err := bleflows.NewAuthenticatedFlow(sim.Connect(), nukisimtest.PairedDeviceId, store)
+	require.NoError(t, err)
+
+	_, _, err = flow.GetLogs(context.Background(), 0, 10, false)
+	require.ErrorContains(t, err, "K_ERROR_BAD_PIN")
+
+	// commands without PIN are not affected
+	require.NoError(t, flow.PerformLockOperation(context.Background(), blecommands.Lock))
+}
+
+func TestRemoveAuthorization(t *testing.T) {
+	sim, flow := pair(t)
+	ctx := context.Background()
+
+	require.ErrorIs(t, flow.RemoveAuthorizationEntry(ctx, 42), blecommands.ErrInvalidAuthId)
+	require.NoError(t, flow.RemoveAuthorizationEntry(ctx, 1))
+	require.Empty(t, sim.State().Authorizations)
+}
+
+func TestRevokedAuthorization(t *testing.T) {
+	sim, store := nukisimtest.Paired(t)
+	flow, err := bleflows.NewAuthenticatedFlow(sim.Connect(), nukisimtest.PairedDeviceId, store)
+	require.NoError(t, err)
+	require.NoError(t, flow.RemoveAuthorizationEntry(context.Background(), 1))
+	require.NoError(t, flow.DisconnectDevice())
+
+	// the device reports the unknown authorization unencrypted
+	flow, err = bleflows.NewAuthenticatedFlow(sim.Connect(), nukisimtest.PairedDeviceId, store)
+	require.NoError(t, err)
+	_, err = flow.GetStatus(context.Background())
+	require.ErrorIs(t, err, blecommands.ErrNotAuthorized)
+}
+
+func TestRemoteDevice(t *testing.T) {
+	sim := newSimulator()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	go sim.Serve(l)
+
+	device := nukisim.NewRemoteDevice(l.Addr().String())
+	defer device.Disconnect()
+
+	store := nukisimtest.MemoryAuthStore{}
+	flow, err := bleflows.NewUnauthenticatedFlow(device, "sim", store)
+	require.NoError(t, err)
+	require.NoError(t, flow.Authorize(context.Background(), "123456"))
+
+	flow, err = bleflows.NewAuthenticatedFlow(device, "sim", store)
+	require.NoError(t, err)
+	require.NoError(t, flow.PerformLockOperation(context.Background(), blecommands.Unlatch))
+	require.Equal(t, blecommands.LockStateUnlatched, sim.KeyturnerStates().LockState)
+}